@@ -0,0 +1,32 @@
+/*
+This utility is a language server for eskip files, implementing the
+Language Server Protocol over stdin and stdout. It can be configured in
+the editors supporting LSP, for the files with the .eskip extension.
+
+It supports:
+
+- diagnostics: the syntax errors found by the eskip parser, and the
+unknown or invalid filters and predicates, validated against the
+built-in filter and predicate specifications of Skipper, the invalid
+backend addresses, the repeating route ids, and the shadowed routes, the
+same way as the eskip check -validate command,
+
+- completion of the filter names after '->', and of the predicate names
+in the other positions, with their arguments,
+
+- hover documentation of the filters and predicates,
+
+- go to definition of the route ids, e.g. when referenced in comments.
+
+Since only the built-in filters and predicates are known to the server,
+custom filters and predicates are reported as unknown.
+
+Example configuration for Neovim:
+
+	vim.lsp.start({
+		name = "eskip",
+		cmd = {"eskip-lsp"},
+		filetypes = {"eskip"},
+	})
+*/
+package main
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/eskip/validation"
+)
+
+const diagnosticSource = "eskip"
+
+// document is an open eskip file with the results of its last parsing.
+type document struct {
+	uri   string
+	text  string
+	lines []int
+
+	// the routes of the last successful parsing, kept while the
+	// document is invalid, to support the navigation
+	routes []*eskip.Route
+	err    error
+}
+
+func newDocument(uri, text string, previous *document) *document {
+	d := &document{uri: uri, text: text, lines: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	d.routes, d.err = eskip.ParseWithSource(text)
+	if d.err != nil && previous != nil {
+		d.routes = previous.routes
+	}
+
+	return d
+}
+
+// converts a byte offset to an LSP position, where the character is
+// counted in UTF-16 code units
+func (d *document) position(offset int) position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	if line < 0 {
+		line = 0
+	}
+
+	var character int
+	for _, r := range d.text[d.lines[line]:offset] {
+		character += len(utf16.Encode([]rune{r}))
+	}
+
+	return position{Line: line, Character: character}
+}
+
+// converts an LSP position to a byte offset
+func (d *document) offset(p position) int {
+	if p.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	offset := d.lines[p.Line]
+	for character := 0; character < p.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+
+		character += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+
+	return offset
+}
+
+func (d *document) rangeOf(s eskip.Span) lspRange {
+	return lspRange{Start: d.position(s.Start.Offset), End: d.position(s.End.Offset)}
+}
+
+func contains(s eskip.Span, offset int) bool {
+	return offset >= s.Start.Offset && offset < s.End.Offset
+}
+
+// returns the range until the end of the line, used for the parse
+// errors, that have only a starting position
+func (d *document) lineRange(offset int) lspRange {
+	end := strings.IndexByte(d.text[offset:], '\n')
+	if end < 0 {
+		end = len(d.text) - offset
+	}
+
+	if end == 0 && offset > 0 {
+		offset--
+		end = 1
+	}
+
+	return lspRange{Start: d.position(offset), End: d.position(offset + end)}
+}
+
+func isSymbolChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// returns the name at an offset, and its start offset
+func (d *document) word(offset int) (string, int) {
+	start, end := offset, offset
+	for start > 0 && isSymbolChar(d.text[start-1]) {
+		start--
+	}
+
+	for end < len(d.text) && isSymbolChar(d.text[end]) {
+		end++
+	}
+
+	return d.text[start:end], start
+}
+
+// the predicates stored by the parser in the fields of the route, and
+// not in the Predicates field
+var routeFieldPredicates = map[string]bool{
+	"*":            true,
+	"Any":          true,
+	"Path":         true,
+	"Host":         true,
+	"PathRegexp":   true,
+	"Method":       true,
+	"Header":       true,
+	"HeaderRegexp": true,
+}
+
+func (d *document) diagnostic(s lspRange, severity int, msg string) diagnostic {
+	return diagnostic{Range: s, Severity: severity, Source: diagnosticSource, Message: msg}
+}
+
+// returns the source of a predicate by its index in the Predicates
+// field of the route
+func predicateSource(r *eskip.Route, index int) (eskip.CallSource, bool) {
+	var next int
+	for _, ps := range r.Source.Predicates {
+		if routeFieldPredicates[ps.Name] {
+			continue
+		}
+
+		if next == index {
+			return ps, true
+		}
+
+		next++
+	}
+
+	return eskip.CallSource{}, false
+}
+
+// returns the source range of the route element that a finding of the
+// validation refers to. The findings about the whole route are shown
+// on the route id.
+func (d *document) findingRange(f validation.Finding) lspRange {
+	r := f.Route
+	switch f.Element {
+	case validation.Predicate, validation.PredicateName:
+		if ps, ok := predicateSource(r, f.Index); ok {
+			if f.Element == validation.PredicateName {
+				return d.rangeOf(ps.NameSpan)
+			}
+
+			return d.rangeOf(ps.Span)
+		}
+	case validation.Filter, validation.FilterName:
+		if f.Index < len(r.Source.Filters) {
+			fs := r.Source.Filters[f.Index]
+			if f.Element == validation.FilterName {
+				return d.rangeOf(fs.NameSpan)
+			}
+
+			return d.rangeOf(fs.Span)
+		}
+	case validation.Backend:
+		return d.rangeOf(r.Source.Backend)
+	}
+
+	if r.Source.ID.End.Offset > r.Source.ID.Start.Offset {
+		return d.rangeOf(r.Source.ID)
+	}
+
+	return d.rangeOf(r.Source.Span)
+}
+
+// diagnostics returns the parse error, or the findings of validating
+// the routes against the registry.
+func (d *document) diagnostics(reg *registry) []diagnostic {
+	diags := []diagnostic{}
+	if d.err != nil {
+		perr, ok := d.err.(*eskip.ParseError)
+		if !ok {
+			return append(diags, d.diagnostic(d.lineRange(0), severityError, d.err.Error()))
+		}
+
+		return append(diags, d.diagnostic(d.lineRange(perr.Position.Offset), severityError, perr.Error()))
+	}
+
+	for _, f := range reg.validator.Validate(d.routes) {
+		severity := severityError
+		if f.Severity == validation.Warning {
+			severity = severityWarning
+		}
+
+		diags = append(diags, d.diagnostic(d.findingRange(f), severity, f.Message))
+	}
+
+	return diags
+}
+
+// finds the previous non-whitespace token before an offset, to decide
+// whether a filter or a predicate is expected
+func (d *document) previousToken(offset int) string {
+	i := offset
+	for i > 0 && strings.ContainsRune(" \t\r\n", rune(d.text[i-1])) {
+		i--
+	}
+
+	switch {
+	case i >= 2 && d.text[i-2:i] == "->":
+		return "->"
+	case i >= 2 && d.text[i-2:i] == "&&":
+		return "&&"
+	case i >= 2 && d.text[i-2:i] == ":=":
+		return ":="
+	case i >= 1:
+		return d.text[i-1 : i]
+	default:
+		return ""
+	}
+}
+
+func markdown(name string, s signature) *markupContent {
+	v := "```\n" + s.format(name) + "\n```"
+	if s.doc != "" {
+		v += "\n\n" + s.doc
+	}
+
+	return &markupContent{Kind: "markdown", Value: v}
+}
+
+// completion returns the filter names after '->', and the predicate
+// names in the other positions.
+func (d *document) completion(reg *registry, offset int) []completionItem {
+	_, start := d.word(offset)
+	items := []completionItem{}
+	switch d.previousToken(start) {
+	case "->", ":=":
+		for _, name := range reg.filterNames() {
+			s, _ := reg.filterSignature(name)
+			items = append(items, completionItem{
+				Label:         name,
+				Kind:          functionKind,
+				Detail:        s.format(name),
+				Documentation: markdown(name, s),
+			})
+		}
+	case "", ":", ";", "&&":
+		for _, name := range reg.predicateNames() {
+			s, _ := reg.predicateSignature(name)
+			items = append(items, completionItem{
+				Label:         name,
+				Kind:          functionKind,
+				Detail:        s.format(name),
+				Documentation: markdown(name, s),
+			})
+		}
+	}
+
+	return items
+}
+
+// hover returns the documentation of the filter or predicate at the
+// offset.
+func (d *document) hover(reg *registry, offset int) *hover {
+	for _, r := range d.routes {
+		if r.Source == nil || !contains(r.Source.Span, offset) {
+			continue
+		}
+
+		for _, p := range r.Source.Predicates {
+			if !contains(p.NameSpan, offset) {
+				continue
+			}
+
+			if s, ok := reg.predicateSignature(p.Name); ok {
+				rg := d.rangeOf(p.NameSpan)
+				return &hover{Contents: *markdown(p.Name, s), Range: &rg}
+			}
+		}
+
+		for _, f := range r.Source.Filters {
+			if !contains(f.NameSpan, offset) {
+				continue
+			}
+
+			if s, ok := reg.filterSignature(f.Name); ok {
+				rg := d.rangeOf(f.NameSpan)
+				return &hover{Contents: *markdown(f.Name, s), Range: &rg}
+			}
+		}
+	}
+
+	return nil
+}
+
+// definition returns the location of the route id at the offset, e.g.
+// when it is referenced in a comment.
+func (d *document) definition(offset int) *location {
+	w, _ := d.word(offset)
+	if w == "" {
+		return nil
+	}
+
+	for _, r := range d.routes {
+		if r.Id == w && r.Source != nil {
+			return &location{URI: d.uri, Range: d.rangeOf(r.Source.ID)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDocument = `// see route2
+route1: Path("/foo") && Foo("bar") -> setPath() -> unknownFilter() -> "https://www.example.org";
+route2: Method("GET") && Traffic(0.3) -> setRequestHeader("X-Föö", "bar") -> status(201) -> "ftp://www.example.org";
+route1: * -> <shunt>`
+
+func TestPositions(t *testing.T) {
+	d := newDocument("file:///routes.eskip", "a: *\n-> \"ö\" -> <shunt>", nil)
+	for _, test := range []struct {
+		offset   int
+		expected position
+	}{
+		{0, position{0, 0}},
+		{5, position{1, 0}},
+		{9, position{1, 4}},
+		{11, position{1, 5}},
+	} {
+		p := d.position(test.offset)
+		if p != test.expected {
+			t.Errorf("invalid position of %d, expected: %v, got: %v", test.offset, test.expected, p)
+		}
+
+		if o := d.offset(p); o != test.offset {
+			t.Errorf("invalid offset of %v, expected: %d, got: %d", p, test.offset, o)
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	d := newDocument("file:///routes.eskip", testDocument, nil)
+	diags := d.diagnostics(newRegistry())
+
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{1, "unknown predicate: Foo"},
+		{1, "invalid filter setPath"},
+		{1, "unknown filter: unknownFilter"},
+		{2, "invalid backend scheme"},
+		{3, "repeating route id: route1"},
+	}
+
+	if len(diags) != len(expected) {
+		t.Fatalf("invalid number of diagnostics, expected: %d, got: %d: %v", len(expected), len(diags), diags)
+	}
+
+	for i, e := range expected {
+		if diags[i].Range.Start.Line != e.line || !strings.HasPrefix(diags[i].Message, e.message) {
+			t.Errorf("invalid diagnostic, expected: %d %s, got: %v", e.line, e.message, diags[i])
+		}
+	}
+
+	if r := diags[0].Range; r.Start.Character != 24 || r.End.Character != 27 {
+		t.Errorf("invalid range of the predicate name: %v", r)
+	}
+}
+
+func TestNestedPredicateDiagnostics(t *testing.T) {
+	d := newDocument("file:///routes.eskip", `route1: Or(Not(Host(/^www/)), Traffic(0.3)) -> <shunt>;
+route2: Or(Method("GET"), Not(Foo())) -> <shunt>;
+route3: Not(PathSubtree("/foo")) -> <shunt>`, nil)
+	diags := d.diagnostics(newRegistry())
+	if len(diags) != 2 {
+		t.Fatalf("invalid number of diagnostics: %v", diags)
+	}
+
+	if diags[0].Range.Start.Line != 1 || diags[0].Message != "unknown predicate: Foo" {
+		t.Errorf("invalid diagnostic: %v", diags[0])
+	}
+
+	if diags[1].Range.Start.Line != 2 || !strings.HasPrefix(diags[1].Message, "invalid predicate PathSubtree") {
+		t.Errorf("invalid diagnostic: %v", diags[1])
+	}
+}
+
+func TestShadowedRouteDiagnostic(t *testing.T) {
+	d := newDocument("file:///routes.eskip", `route1: Path("/foo") -> <shunt>;
+route2: Path("/foo") -> <shunt>`, nil)
+	diags := d.diagnostics(newRegistry())
+	if len(diags) != 1 || diags[0].Severity != severityWarning {
+		t.Fatalf("invalid diagnostics: %v", diags)
+	}
+
+	if r := diags[0].Range; r.Start.Line != 1 || r.Start.Character != 0 || r.End.Character != 6 {
+		t.Errorf("invalid range of the shadowed route: %v", r)
+	}
+}
+
+func TestParseErrorDiagnostic(t *testing.T) {
+	d := newDocument("file:///routes.eskip", "route1: Path(\"/foo\") -> \nroute2: * -> <shunt>", nil)
+	diags := d.diagnostics(newRegistry())
+	if len(diags) != 1 || diags[0].Severity != severityError || diags[0].Range.Start.Line != 1 {
+		t.Errorf("invalid diagnostics: %v", diags)
+	}
+}
+
+func TestKeepsRoutesOfLastValidParse(t *testing.T) {
+	previous := newDocument("file:///routes.eskip", testDocument, nil)
+	d := newDocument("file:///routes.eskip", testDocument+" ->", previous)
+	if d.err == nil || len(d.routes) != 3 {
+		t.Error("failed to keep the previous routes")
+	}
+}
+
+func completionLabels(items []completionItem) map[string]completionItem {
+	m := make(map[string]completionItem)
+	for _, i := range items {
+		m[i.Label] = i
+	}
+
+	return m
+}
+
+func TestCompletion(t *testing.T) {
+	reg := newRegistry()
+	d := newDocument("file:///routes.eskip", `route1: Pa -> setR`, nil)
+
+	predicates := completionLabels(d.completion(reg, 10))
+	if _, ok := predicates["Path"]; !ok {
+		t.Error("failed to complete predicates")
+	}
+
+	if _, ok := predicates["setPath"]; ok {
+		t.Error("unexpected filter in the predicate completion")
+	}
+
+	filters := completionLabels(d.completion(reg, len(d.text)))
+	setPath, ok := filters["setPath"]
+	if !ok {
+		t.Fatal("failed to complete filters")
+	}
+
+	if setPath.Detail != `setPath("/path")` {
+		t.Errorf("invalid signature: %s", setPath.Detail)
+	}
+
+	if _, ok := filters["Path"]; ok {
+		t.Error("unexpected predicate in the filter completion")
+	}
+}
+
+func TestHover(t *testing.T) {
+	d := newDocument("file:///routes.eskip", testDocument, nil)
+	reg := newRegistry()
+
+	h := d.hover(reg, strings.Index(testDocument, "etRequestHeader"))
+	if h == nil || !strings.Contains(h.Contents.Value, `setRequestHeader("name", "value")`) {
+		t.Errorf("invalid hover: %v", h)
+	}
+
+	h = d.hover(reg, strings.Index(testDocument, "raffic"))
+	if h == nil || !strings.Contains(h.Contents.Value, "Traffic(chance") {
+		t.Errorf("invalid hover: %v", h)
+	}
+
+	if h := d.hover(reg, strings.Index(testDocument, "oo(")); h != nil {
+		t.Errorf("unexpected hover of an unknown predicate: %v", h)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	d := newDocument("file:///routes.eskip", testDocument, nil)
+	l := d.definition(8)
+	if l == nil {
+		t.Fatal("failed to find the definition")
+	}
+
+	if l.Range.Start.Line != 2 || l.Range.Start.Character != 0 || l.Range.End.Character != 6 {
+		t.Errorf("invalid definition: %v", l.Range)
+	}
+
+	if l := d.definition(4); l != nil {
+		t.Errorf("unexpected definition: %v", l)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+const (
+	versionUsage = "print the version and exit"
+	stdioUsage   = "communicate over stdin and stdout, the default and only supported mode"
+)
+
+var (
+	version string
+	commit  string
+)
+
+func main() {
+	var printVersion bool
+	flag.BoolVar(&printVersion, "version", false, versionUsage)
+
+	// editors usually start the language servers with --stdio
+	flag.Bool("stdio", true, stdioUsage)
+	flag.Parse()
+
+	if printVersion {
+		fmt.Printf("%s version %s (commit: %s)\n", serverName, version, commit)
+		return
+	}
+
+	if !newServer(os.Stdin, os.Stdout).serve() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+const (
+	contentLengthHeader = "Content-Length"
+	jsonRPCVersion      = "2.0"
+)
+
+// JSON-RPC error codes used by the server
+const (
+	parseErrorCode     = -32700
+	invalidParamsCode  = -32602
+	methodNotFoundCode = -32601
+)
+
+var missingContentLength = errors.New("missing content length")
+
+// message is an incoming JSON-RPC request or notification. The
+// notifications don't have an id.
+type message struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   *responseError   `json:"error"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// conn reads and writes the messages framed with the LSP base protocol
+// headers.
+type conn struct {
+	reader *textproto.Reader
+	mx     sync.Mutex
+	writer io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{
+		reader: textproto.NewReader(bufio.NewReader(r)),
+		writer: w,
+	}
+}
+
+func (c *conn) read() (*message, error) {
+	h, err := c.reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	l := h.Get(contentLengthHeader)
+	if l == "" {
+		return nil, missingContentLength
+	}
+
+	n, err := strconv.Atoi(l)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content length: %v", err)
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(c.reader.R, b); err != nil {
+		return nil, err
+	}
+
+	var m message
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, &responseError{Code: parseErrorCode, Message: err.Error()}
+	}
+
+	return &m, nil
+}
+
+func (c *conn) write(m interface{}) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if _, err := fmt.Fprintf(c.writer, "%s: %d\r\n\r\n", contentLengthHeader, len(b)); err != nil {
+		return err
+	}
+
+	_, err = c.writer.Write(b)
+	return err
+}
+
+func (c *conn) respond(id *json.RawMessage, result interface{}) error {
+	return c.write(&response{JSONRPC: jsonRPCVersion, ID: id, Result: result})
+}
+
+func (c *conn) respondError(id *json.RawMessage, code int, msg string) error {
+	return c.write(&errorResponse{
+		JSONRPC: jsonRPCVersion,
+		ID:      id,
+		Error:   &responseError{Code: code, Message: msg},
+	})
+}
+
+func (c *conn) notify(method string, params interface{}) error {
+	return c.write(&notification{JSONRPC: jsonRPCVersion, Method: method, Params: params})
+}
+
+func (e *responseError) Error() string { return e.Message }
+
+// the used subset of the LSP types
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type contentChange struct {
+	Text string `json:"text"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []contentChange        `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+// diagnostic severities
+const (
+	severityError   = 1
+	severityWarning = 2
+)
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+// completion item kind of the filters and predicates
+const functionKind = 3
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type completionItem struct {
+	Label         string         `json:"label"`
+	Kind          int            `json:"kind"`
+	Detail        string         `json:"detail,omitempty"`
+	Documentation *markupContent `json:"documentation,omitempty"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    *lspRange     `json:"range,omitempty"`
+}
+
+const fullSync = 1
+
+type completionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters"`
+}
+
+type serverCapabilities struct {
+	TextDocumentSync   int                `json:"textDocumentSync"`
+	CompletionProvider *completionOptions `json:"completionProvider"`
+	HoverProvider      bool               `json:"hoverProvider"`
+	DefinitionProvider bool               `json:"definitionProvider"`
+}
+
+type serverInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type initializeResult struct {
+	Capabilities serverCapabilities `json:"capabilities"`
+	ServerInfo   serverInfo         `json:"serverInfo"`
+}
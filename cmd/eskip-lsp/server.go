@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const serverName = "eskip-lsp"
+
+// server handles the LSP requests and notifications of a single client,
+// sequentially.
+type server struct {
+	conn      *conn
+	registry  *registry
+	documents map[string]*document
+	shutdown  bool
+}
+
+func newServer(r io.Reader, w io.Writer) *server {
+	return &server{
+		conn:      newConn(r, w),
+		registry:  newRegistry(),
+		documents: make(map[string]*document),
+	}
+}
+
+func (s *server) publishDiagnostics(d *document) error {
+	return s.conn.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         d.uri,
+		Diagnostics: d.diagnostics(s.registry),
+	})
+}
+
+func (s *server) update(uri, text string) error {
+	d := newDocument(uri, text, s.documents[uri])
+	s.documents[uri] = d
+	return s.publishDiagnostics(d)
+}
+
+func (s *server) initialize() interface{} {
+	return &initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync: fullSync,
+			CompletionProvider: &completionOptions{
+				TriggerCharacters: []string{">", "&", ":"},
+			},
+			HoverProvider:      true,
+			DefinitionProvider: true,
+		},
+		ServerInfo: serverInfo{Name: serverName, Version: version},
+	}
+}
+
+// returns the document and the byte offset of a position request
+func (s *server) documentPosition(p *textDocumentPositionParams) (*document, int, bool) {
+	d, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil, 0, false
+	}
+
+	return d, d.offset(p.Position), true
+}
+
+// handles the notifications, that don't have a response
+func (s *server) handleNotification(m *message) error {
+	switch m.Method {
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return err
+		}
+
+		return s.update(p.TextDocument.URI, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return err
+		}
+
+		// with full sync, the last change contains the whole document
+		if len(p.ContentChanges) == 0 {
+			return nil
+		}
+
+		return s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return err
+		}
+
+		delete(s.documents, p.TextDocument.URI)
+		return s.conn.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+			URI:         p.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+	default:
+		return nil
+	}
+}
+
+// handles the requests, and returns the result or the error code and
+// message of the response
+func (s *server) handleRequest(m *message) (interface{}, *responseError) {
+	if m.Method == "initialize" {
+		return s.initialize(), nil
+	}
+
+	if m.Method == "shutdown" {
+		s.shutdown = true
+		return nil, nil
+	}
+
+	var p textDocumentPositionParams
+	switch m.Method {
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, &responseError{Code: invalidParamsCode, Message: err.Error()}
+		}
+	default:
+		return nil, &responseError{Code: methodNotFoundCode, Message: "method not found: " + m.Method}
+	}
+
+	d, offset, ok := s.documentPosition(&p)
+	if !ok {
+		return nil, nil
+	}
+
+	switch m.Method {
+	case "textDocument/completion":
+		return d.completion(s.registry, offset), nil
+	case "textDocument/hover":
+		if h := d.hover(s.registry, offset); h != nil {
+			return h, nil
+		}
+	default:
+		if l := d.definition(offset); l != nil {
+			return l, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// serve processes the incoming messages until the exit notification
+// or the end of the input. It returns false, when the client didn't
+// request a shutdown before exiting.
+func (s *server) serve() bool {
+	for {
+		m, err := s.conn.read()
+		if rerr, ok := err.(*responseError); ok {
+			if err := s.conn.respondError(nil, rerr.Code, rerr.Message); err != nil {
+				log.Errorf("failed to send response: %v", err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				log.Errorf("failed to read message: %v", err)
+			}
+
+			return s.shutdown
+		}
+
+		if m.Method == "exit" {
+			return s.shutdown
+		}
+
+		if m.ID == nil {
+			if err := s.handleNotification(m); err != nil {
+				log.Errorf("failed to handle %s: %v", m.Method, err)
+			}
+
+			continue
+		}
+
+		result, rerr := s.handleRequest(m)
+		if rerr != nil {
+			err = s.conn.respondError(m.ID, rerr.Code, rerr.Message)
+		} else {
+			err = s.conn.respond(m.ID, result)
+		}
+
+		if err != nil {
+			log.Errorf("failed to send response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func frame(messages ...string) string {
+	var b bytes.Buffer
+	for _, m := range messages {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+
+	return b.String()
+}
+
+func readResponses(t *testing.T, out string) []map[string]interface{} {
+	c := newConn(strings.NewReader(out), nil)
+	var responses []map[string]interface{}
+	for {
+		h, err := c.reader.ReadMIMEHeader()
+		if err != nil {
+			return responses
+		}
+
+		var n int
+		fmt.Sscan(h.Get(contentLengthHeader), &n)
+		b := make([]byte, n)
+		if _, err := io.ReadFull(c.reader.R, b); err != nil {
+			t.Fatal(err)
+		}
+
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+
+		responses = append(responses, m)
+	}
+}
+
+func TestServer(t *testing.T) {
+	in := frame(
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`,
+		`{"jsonrpc": "2.0", "method": "initialized", "params": {}}`,
+		`{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": {"textDocument": {"uri": "file:///r.eskip", "text": "r1: Path(\"/foo\") -> foo() -> <shunt>"}}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "textDocument/hover", "params": {"textDocument": {"uri": "file:///r.eskip"}, "position": {"line": 0, "character": 5}}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "textDocument/unknown", "params": {}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "shutdown"}`,
+		`{"jsonrpc": "2.0", "method": "exit"}`,
+	)
+
+	var out bytes.Buffer
+	if !newServer(strings.NewReader(in), &out).serve() {
+		t.Error("failed to shut down")
+	}
+
+	responses := readResponses(t, out.String())
+	if len(responses) != 5 {
+		t.Fatalf("invalid number of responses: %d", len(responses))
+	}
+
+	capabilities := responses[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	if capabilities["hoverProvider"] != true || capabilities["textDocumentSync"] != float64(fullSync) {
+		t.Errorf("invalid capabilities: %v", capabilities)
+	}
+
+	if responses[1]["method"] != "textDocument/publishDiagnostics" {
+		t.Fatalf("invalid notification: %v", responses[1])
+	}
+
+	diags := responses[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diags) != 1 || diags[0].(map[string]interface{})["message"] != "unknown filter: foo" {
+		t.Errorf("invalid diagnostics: %v", diags)
+	}
+
+	hover := responses[2]["result"].(map[string]interface{})["contents"].(map[string]interface{})
+	if !strings.Contains(hover["value"].(string), `Path("/path")`) {
+		t.Errorf("invalid hover: %v", hover)
+	}
+
+	if code := responses[3]["error"].(map[string]interface{})["code"]; code != float64(methodNotFoundCode) {
+		t.Errorf("invalid error code: %v", code)
+	}
+
+	if result, ok := responses[4]["result"]; !ok || result != nil {
+		t.Errorf("invalid shutdown response: %v", responses[4])
+	}
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	in := frame(`{"jsonrpc": "2.0", "method": "exit"}`)
+	if newServer(strings.NewReader(in), &bytes.Buffer{}).serve() {
+		t.Error("unexpected clean exit")
+	}
+}
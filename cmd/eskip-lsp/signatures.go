@@ -0,0 +1,288 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/zalando/skipper/eskip/validation"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/loadbalancer"
+	predicates "github.com/zalando/skipper/predicates/builtin"
+	"github.com/zalando/skipper/predicates/cookie"
+	"github.com/zalando/skipper/predicates/source"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/predicates/value"
+	"github.com/zalando/skipper/routing"
+)
+
+// signature documents a filter or a predicate for the completion and the
+// hover information.
+type signature struct {
+	args string
+	doc  string
+}
+
+// the predicates processed by the routing itself, without a predicate
+// spec
+var routingPredicates = map[string]signature{
+	"Path": {
+		`"/path"`,
+		"Matches the exact request path. It can contain wildcards, e.g. " +
+			`"/some/:dir/:name", or end with a free wildcard, e.g. "/some/*rest".`,
+	},
+	"PathSubtree": {
+		`"/path"`,
+		"Matches the path and any sub path below it.",
+	},
+	"PathRegexp": {
+		`/regexp/`,
+		"Matches the request path with a regular expression.",
+	},
+	"Host": {
+		`/regexp/`,
+		"Matches the host header of the request with a regular expression.",
+	},
+	"Method": {
+		`"GET"`,
+		"Matches the method of the request.",
+	},
+	"Header": {
+		`"name", "value"`,
+		"Matches the exact value of a request header.",
+	},
+	"HeaderRegexp": {
+		`"name", /regexp/`,
+		"Matches a request header with a regular expression.",
+	},
+	"Weight": {
+		`2`,
+		"Adds to the priority of the route among the routes matching the same path. It doesn't affect the matching.",
+	},
+	"Not": {
+		`Predicate()`,
+		"Matches when the nested predicate doesn't match.",
+	},
+	"Or": {
+		`Predicate1(), Predicate2()`,
+		"Matches when any of the nested predicates match.",
+	},
+	"And": {
+		`Predicate1(), Predicate2()`,
+		"Matches when all of the nested predicates match. It can be used to group predicates in Not or Or.",
+	},
+}
+
+var predicateSignatures = map[string]signature{
+	source.Name: {
+		`"network", ...`,
+		"Matches the source IP of the request, or the first address of the X-Forwarded-For header, " +
+			"with a list of IP addresses or networks in CIDR notation.",
+	},
+	source.NameLast: {
+		`"network", ...`,
+		"Like Source, but it uses the last address of the X-Forwarded-For header.",
+	},
+	"Between": {
+		`"from", "until"`,
+		"Matches the requests between two points in time, RFC3339 dates or unix timestamps.",
+	},
+	"Before": {
+		`"until"`,
+		"Matches the requests before a point in time.",
+	},
+	"After": {
+		`"from"`,
+		"Matches the requests after a point in time.",
+	},
+	cookie.Name: {
+		`"name", /regexp/`,
+		"Matches the value of a request cookie with a regular expression.",
+	},
+	"QueryParam": {
+		`"name"[, /regexp/]`,
+		"Matches the existence, or the value of a query parameter.",
+	},
+	traffic.PredicateName: {
+		`chance[, "stickyCookie", "trafficGroup"]`,
+		"Matches a random share of the requests, chance between 0 and 1.",
+	},
+	traffic.HashPredicateName: {
+		`from, to, "header|cookie|source|jwt"[, "name"]`,
+		"Matches the requests whose hashed header, cookie, source IP or JWT claim falls between from and to, " +
+			"so the same client always matches the same route.",
+	},
+	value.HeaderAbsentName: {
+		`"name"`,
+		"Matches the requests without the header.",
+	},
+	value.HeaderAllName: {
+		`"name", /regexp/`,
+		"Matches when all the values of the header match the regular expression.",
+	},
+	value.HeaderEqualFoldName: {
+		`"name", "value"`,
+		"Matches a value of the header, compared case-insensitively.",
+	},
+	value.HeaderNumberName: {
+		`"name", ">=", 42`,
+		"Compares a value of the header as a number, with ==, !=, <, <=, > or >=.",
+	},
+	value.HeaderVersionName: {
+		`"name", ">=", "2.3.0"`,
+		"Compares a value of the header as a semantic version, with ==, !=, <, <=, > or >=.",
+	},
+	value.QueryParamAbsentName: {
+		`"name"`,
+		"Matches the requests without the query parameter.",
+	},
+	value.QueryParamAllName: {
+		`"name", /regexp/`,
+		"Matches when all the values of the query parameter match the regular expression.",
+	},
+	value.QueryParamEqualFoldName: {
+		`"name", "value"`,
+		"Matches a value of the query parameter, compared case-insensitively.",
+	},
+	value.QueryParamNumberName: {
+		`"name", ">=", 42`,
+		"Compares a value of the query parameter as a number, with ==, !=, <, <=, > or >=.",
+	},
+	value.QueryParamVersionName: {
+		`"name", ">=", "2.3.0"`,
+		"Compares a value of the query parameter as a semantic version, with ==, !=, <, <=, > or >=.",
+	},
+	loadbalancer.GroupPredicateName: {
+		`"group"`,
+		"Matches the requests of a load balancer group, used together with lbDecide.",
+	},
+	loadbalancer.MemberPredicateName: {
+		`"group", index`,
+		"Matches the requests decided for a member of a load balancer group.",
+	},
+}
+
+var filterSignatures = map[string]signature{
+	builtin.SetRequestHeaderName:     {`"name", "value"`, "Sets a request header, overwriting the existing values."},
+	builtin.SetResponseHeaderName:    {`"name", "value"`, "Sets a response header, overwriting the existing values."},
+	builtin.AppendRequestHeaderName:  {`"name", "value"`, "Appends a value to a request header."},
+	builtin.AppendResponseHeaderName: {`"name", "value"`, "Appends a value to a response header."},
+	builtin.DropRequestHeaderName:    {`"name"`, "Removes a request header."},
+	builtin.DropResponseHeaderName:   {`"name"`, "Removes a response header."},
+	builtin.RequestHeaderName:        {`"name", "value"`, "Deprecated, use setRequestHeader or appendRequestHeader."},
+	builtin.ResponseHeaderName:       {`"name", "value"`, "Deprecated, use setResponseHeader or appendResponseHeader."},
+	"requestCopyHeader":              {`"from", "to"`, "Copies the value of a request header to another request header."},
+	"responseCopyHeader":             {`"from", "to"`, "Copies the value of a response header to another response header."},
+	builtin.ModPathName:              {`/regexp/, "replacement"`, "Replaces the matching part of the request path."},
+	builtin.SetPathName:              {`"/path"`, "Sets the request path. It can contain the path params as ${name}."},
+	builtin.SetQueryName:             {`"key"[, "value"]`, "Sets a query parameter, or the whole query when only one argument is set."},
+	builtin.DropQueryName:            {`"key"`, "Removes a query parameter."},
+	builtin.HealthCheckName:          {``, "Responds with 200 OK, when the route has a shunt backend."},
+	builtin.StaticName:               {`"/prefix", "/directory"`, "Serves static files from a directory."},
+	builtin.RedirectName:             {`code, "location"`, "Deprecated, use redirectTo."},
+	builtin.RedirectToName:           {`code, "location"`, "Responds with a redirect to the location, keeping the request path when the location has none."},
+	builtin.RedirectToLowerName:      {`code, "location"`, "Like redirectTo, but with the path lower cased."},
+	builtin.StripQueryName:           {`["true"]`, "Removes the query, optionally preserving the parameters as X-Query-Param-* headers."},
+	builtin.PreserveHostName:         {`"true"|"false"`, "Controls whether the host header of the request is sent to the backend."},
+	builtin.StatusName:               {`code`, "Sets the status code of the response."},
+	builtin.CompressName:             {`[level, ]["mime-type", ...]`, "Compresses the response body."},
+	builtin.InlineContentName:        {`"content"[, "mime-type"]`, "Responds with the content, usually used with a shunt backend."},
+	"flowId":                         {`["reuse"]`, "Sets the X-Flow-Id header, optionally reusing the incoming one."},
+	"randomContent":                  {`length`, "Responds with random content, for testing."},
+	"latency":                        {`milliseconds`, "Delays the response, for testing."},
+	"bandwidth":                      {`kbps`, "Limits the bandwidth of the response, for testing."},
+	"chunks":                         {`size, milliseconds`, "Responds in delayed chunks, for testing."},
+	"backendLatency":                 {`milliseconds`, "Delays the request to the backend, for testing."},
+	"backendBandwidth":               {`kbps`, "Limits the bandwidth of the request to the backend, for testing."},
+	"backendChunks":                  {`size, milliseconds`, "Sends the request to the backend in delayed chunks, for testing."},
+	"tee":                            {`"https://backend"`, "Sends a copy of the request to another backend, ignoring its response."},
+	"Tee":                            {`"https://backend"`, "Deprecated, use tee."},
+	"teenf":                          {`"https://backend"`, "Like tee, but it doesn't follow the redirects."},
+	"basicAuth":                      {`"/path/to/htpasswd"[, "realm"]`, "Requires basic authentication, checked against an htpasswd file."},
+	"requestCookie":                  {`"name", "value"`, "Sets a cookie of the request."},
+	"responseCookie":                 {`"name", "value"[, ttl[, "change-only"]]`, "Sets a cookie in the response."},
+	"jsCookie":                       {`"name", "value"[, ttl[, "change-only"]]`, "Sets a cookie in the response, readable by JavaScript."},
+	"consecutiveBreaker":             {`failures[, "timeout", halfOpenRequests, "idleTTL"]`, "Opens the circuit breaker of the backend after consecutive failures."},
+	"rateBreaker":                    {`failures, window[, "timeout", halfOpenRequests, "idleTTL"]`, "Opens the circuit breaker of the backend after a number of failures in a window of requests."},
+	"disableBreaker":                 {``, "Disables the circuit breakers for the route."},
+	"localRatelimit":                 {`maxHits, "timeWindow"[, "lookupType"]`, "Limits the requests per client in the instance."},
+	"ratelimit":                      {`maxHits, "timeWindow"`, "Limits the requests to the route in the instance."},
+	"disableRatelimit":               {``, "Disables the rate limit for the route."},
+	loadbalancer.DecideFilterName:    {`"group", size`, "Decides the member of a load balancer group, used together with LBGroup and LBMember."},
+	"lua":                            {`"script"[, "param", ...]`, "Executes a Lua script, inline or from a file."},
+	"corsOrigin":                     {`["origin", ...]`, "Sets the Access-Control-Allow-Origin header, for all or for the listed origins."},
+	"tracingSampling":                {`ratio`, "Overrides the sampling decision of the tracer, ratio between 0 and 1."},
+	"maskAccessLogFields":            {`"field", ...`, "Masks the values of the listed fields in the access log."},
+	"omitAccessLogFields":            {`"field", ...`, "Leaves the listed fields out of the access log."},
+	"disableAccessLog":               {`[statusPrefix, ...]`, "Disables the access log, for all or for the listed statuses."},
+	"enableAccessLog":                {`[statusPrefix, ...]`, "Enables the access log, for all or for the listed statuses."},
+}
+
+// registry contains the known filters and predicates
+type registry struct {
+	filters    filters.Registry
+	predicates map[string]routing.PredicateSpec
+	validator  *validation.Validator
+}
+
+func newRegistry() *registry {
+	specs := predicates.Specs()
+	r := &registry{
+		filters:    builtin.MakeRegistry(),
+		predicates: make(map[string]routing.PredicateSpec),
+	}
+
+	for _, s := range specs {
+		r.predicates[s.Name()] = s
+	}
+
+	r.validator = validation.NewWithSpecs(r.filters, specs)
+	return r
+}
+
+func (r *registry) filterNames() []string {
+	var names []string
+	for name := range r.filters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry) predicateNames() []string {
+	var names []string
+	for name := range routingPredicates {
+		names = append(names, name)
+	}
+
+	for name := range r.predicates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry) filterSignature(name string) (signature, bool) {
+	if _, ok := r.filters[name]; !ok {
+		return signature{}, false
+	}
+
+	return filterSignatures[name], true
+}
+
+func (r *registry) predicateSignature(name string) (signature, bool) {
+	if s, ok := routingPredicates[name]; ok {
+		return s, true
+	}
+
+	if _, ok := r.predicates[name]; !ok {
+		return signature{}, false
+	}
+
+	return predicateSignatures[name], true
+}
+
+func (s signature) format(name string) string {
+	return name + "(" + s.args + ")"
+}
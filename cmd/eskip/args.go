@@ -26,21 +26,32 @@ import (
 )
 
 const (
-	etcdUrlsFlag       = "etcd-urls"
-	etcdPrefixFlag     = "etcd-prefix"
-	etcdOAuthTokenFlag = "etcd-oauth-token"
-	innkeeperUrlFlag   = "innkeeper-url"
-	oauthTokenFlag     = "oauth-token"
-	inlineRoutesFlag   = "routes"
-	inlineIdsFlag      = "ids"
-	insecureFlag       = "insecure"
-	prependFiltersFlag = "prepend"
-	prependFileFlag    = "prepend-file"
-	appendFiltersFlag  = "append"
-	appendFileFlag     = "append-file"
-	prettyFlag         = "pretty"
-	indentStrFlag      = "indent"
-	jsonFlag           = "json"
+	etcdUrlsFlag         = "etcd-urls"
+	etcdPrefixFlag       = "etcd-prefix"
+	etcdOAuthTokenFlag   = "etcd-oauth-token"
+	innkeeperUrlFlag     = "innkeeper-url"
+	oauthTokenFlag       = "oauth-token"
+	inlineRoutesFlag     = "routes"
+	inlineIdsFlag        = "ids"
+	insecureFlag         = "insecure"
+	prependFiltersFlag   = "prepend"
+	prependFileFlag      = "prepend-file"
+	appendFiltersFlag    = "append"
+	appendFileFlag       = "append-file"
+	prettyFlag           = "pretty"
+	indentStrFlag        = "indent"
+	jsonFlag             = "json"
+	validateFlag         = "validate"
+	checkFormatFlag      = "check"
+	sortRoutesFlag       = "sort"
+	dryRunFlag           = "dry-run"
+	maxDeletesFlag       = "max-deletes"
+	confirmFlag          = "confirm"
+	requestsFileFlag     = "requests"
+	fromFormatFlag       = "from"
+	toFormatFlag         = "to"
+	ingressNameFlag      = "ingress-name"
+	ingressNamespaceFlag = "ingress-namespace"
 
 	defaultEtcdUrls     = "http://127.0.0.1:2379,http://127.0.0.1:4001"
 	defaultEtcdPrefix   = "/skipper"
@@ -77,6 +88,17 @@ var (
 	pretty            bool
 	indentStr         string
 	printJson         bool
+	validate          bool
+	checkFormat       bool
+	sortRoutes        bool
+	dryRun            bool
+	maxDeletes        int
+	confirmWrite      bool
+	requestsFile      string
+	fromFormat        string
+	toFormat          string
+	ingressName       string
+	ingressNamespace  string
 )
 
 var (
@@ -112,6 +134,17 @@ func initFlags() {
 	flags.BoolVar(&pretty, prettyFlag, false, prettyUsage)
 	flags.StringVar(&indentStr, indentStrFlag, "  ", indentStrUsage)
 	flags.BoolVar(&printJson, jsonFlag, false, jsonUsage)
+	flags.BoolVar(&validate, validateFlag, false, validateUsage)
+	flags.BoolVar(&checkFormat, checkFormatFlag, false, checkFormatUsage)
+	flags.BoolVar(&sortRoutes, sortRoutesFlag, false, sortRoutesUsage)
+	flags.BoolVar(&dryRun, dryRunFlag, false, dryRunUsage)
+	flags.IntVar(&maxDeletes, maxDeletesFlag, -1, maxDeletesUsage)
+	flags.BoolVar(&confirmWrite, confirmFlag, false, confirmUsage)
+	flags.StringVar(&requestsFile, requestsFileFlag, "", requestsFileUsage)
+	flags.StringVar(&fromFormat, fromFormatFlag, string(eskipFormat), fromFormatUsage)
+	flags.StringVar(&toFormat, toFormatFlag, string(eskipFormat), toFormatUsage)
+	flags.StringVar(&ingressName, ingressNameFlag, defaultIngressName, ingressNameUsage)
+	flags.StringVar(&ingressNamespace, ingressNamespaceFlag, defaultIngressNamespace, ingressNamespaceUsage)
 }
 
 func init() {
@@ -193,20 +226,27 @@ func processInnkeeperArgs(innkeeperUrl, oauthToken string) (*medium, error) {
 		oauthToken: oauthToken}, nil
 }
 
-// returns file type medium if a positional parameter is defined.
-func processFileArg() (*medium, error) {
+// returns file type media if positional parameters are defined. Only
+// the diff command accepts two files, the rest accepts one.
+func processFileArgs() ([]*medium, error) {
+	maxFiles := 1
+	if len(os.Args) > 1 && command(os.Args[1]) == diff {
+		maxFiles = 2
+	}
+
 	nonFlagArgs := flags.Args()
-	if len(nonFlagArgs) > 1 {
+	if len(nonFlagArgs) > maxFiles {
 		return nil, invalidNumberOfArgs
 	}
 
-	if len(nonFlagArgs) == 0 {
-		return nil, nil
+	var media []*medium
+	for _, path := range nonFlagArgs {
+		media = append(media, &medium{
+			typ:  file,
+			path: path})
 	}
 
-	return &medium{
-		typ:  file,
-		path: nonFlagArgs[0]}, nil
+	return media, nil
 }
 
 // if pretty print then check that indent matches pattern
@@ -293,7 +333,7 @@ func processArgs() ([]*medium, error) {
 			ids: strings.Split(inlineRouteIds, ",")})
 	}
 
-	fileArg, err := processFileArg()
+	fileArgs, err := processFileArgs()
 	if err != nil {
 		return nil, err
 	}
@@ -303,8 +343,8 @@ func processArgs() ([]*medium, error) {
 		return nil, err
 	}
 
-	if fileArg != nil {
-		media = append(media, fileArg)
+	if len(fileArgs) > 0 {
+		media = append(media, fileArgs...)
 	} else {
 		stdinArg := processStdin()
 
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/zalando/skipper/eskip"
+	"gopkg.in/yaml.v2"
+)
+
+type routeFormat string
+
+const (
+	eskipFormat      routeFormat = "eskip"
+	jsonFormat       routeFormat = "json"
+	yamlFormat       routeFormat = "yaml"
+	kubernetesFormat routeFormat = "kubernetes"
+)
+
+const (
+	skipperFilterAnnotation    = "zalando.org/skipper-filter"
+	skipperPredicateAnnotation = "zalando.org/skipper-predicate"
+	skipperRoutesAnnotation    = "zalando.org/skipper-routes"
+
+	defaultIngressName      = "routes"
+	defaultIngressNamespace = "default"
+)
+
+// the YAML representation of a route, following the same structure as
+// the JSON one
+type (
+	yamlCall struct {
+		Name string        `yaml:"name" json:"name"`
+		Args []interface{} `yaml:"args,omitempty" json:"args"`
+	}
+
+	yamlRoute struct {
+		Id          string            `yaml:"id,omitempty" json:"id"`
+		Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+		Predicates  []yamlCall        `yaml:"predicates,omitempty" json:"predicates"`
+		Filters     []yamlCall        `yaml:"filters,omitempty" json:"filters"`
+		Backend     string            `yaml:"backend" json:"backend"`
+	}
+)
+
+// the subset of the Kubernetes Ingress resource used by the conversion
+type (
+	ingressBackend struct {
+		ServiceName string      `yaml:"serviceName"`
+		ServicePort interface{} `yaml:"servicePort"`
+	}
+
+	ingressPath struct {
+		Path    string          `yaml:"path,omitempty"`
+		Backend *ingressBackend `yaml:"backend"`
+	}
+
+	ingressHTTP struct {
+		Paths []*ingressPath `yaml:"paths"`
+	}
+
+	ingressRule struct {
+		Host string       `yaml:"host,omitempty"`
+		HTTP *ingressHTTP `yaml:"http,omitempty"`
+	}
+
+	ingressSpec struct {
+		Backend *ingressBackend `yaml:"backend,omitempty"`
+		Rules   []*ingressRule  `yaml:"rules,omitempty"`
+	}
+
+	ingressMetadata struct {
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace,omitempty"`
+		Annotations map[string]string `yaml:"annotations,omitempty"`
+	}
+
+	kubernetesResource struct {
+		APIVersion string                `yaml:"apiVersion"`
+		Kind       string                `yaml:"kind"`
+		Metadata   *ingressMetadata      `yaml:"metadata,omitempty"`
+		Spec       *ingressSpec          `yaml:"spec,omitempty"`
+		Items      []*kubernetesResource `yaml:"items,omitempty"`
+	}
+)
+
+var (
+	invalidFormat       = errors.New("invalid format")
+	invalidConvertInput = errors.New("only stdin or file input can be converted from json, yaml or kubernetes")
+
+	nonWord         = regexp.MustCompile(`\W`)
+	exactHostRegexp = regexp.MustCompile(`^\^([a-zA-Z0-9-]+(\[\.\][a-zA-Z0-9-]+)*)\$$`)
+)
+
+func parseFormat(f string) (routeFormat, error) {
+	switch rf := routeFormat(strings.ToLower(f)); rf {
+	case eskipFormat, jsonFormat, yamlFormat, kubernetesFormat:
+		return rf, nil
+	default:
+		return "", fmt.Errorf("%v: %s", invalidFormat, f)
+	}
+}
+
+func routesFromYAML(doc []byte) ([]*eskip.Route, error) {
+	var yr []yamlRoute
+	if err := yaml.Unmarshal(doc, &yr); err != nil {
+		return nil, err
+	}
+
+	// converted via JSON, so that the routes are processed the same
+	// way as when reading them from JSON
+	b, err := json.Marshal(yr)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []*eskip.Route
+	err = json.Unmarshal(b, &routes)
+	return routes, err
+}
+
+func routesToYAML(routes []*eskip.Route) ([]byte, error) {
+	if len(routes) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(routes)
+	if err != nil {
+		return nil, err
+	}
+
+	var yr []yamlRoute
+	if err := json.Unmarshal(b, &yr); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(yr)
+}
+
+// returns the host name when the route has a single host regexp matching
+// exactly one host, in the form that the Kubernetes dataclient generates
+// from the ingress rules, e.g. ^www[.]example[.]org$
+func exactHost(r *eskip.Route) (string, bool) {
+	if len(r.HostRegexps) != 1 {
+		return "", false
+	}
+
+	m := exactHostRegexp.FindStringSubmatch(r.HostRegexps[0])
+	if len(m) == 0 {
+		return "", false
+	}
+
+	return strings.Replace(m[1], "[.]", ".", -1), true
+}
+
+func hostRegexp(host string) string {
+	return "^" + strings.Replace(host, ".", "[.]", -1) + "$"
+}
+
+func newIngress(name, host string, routes []*eskip.Route) *kubernetesResource {
+	ing := &kubernetesResource{
+		APIVersion: "extensions/v1beta1",
+		Kind:       "Ingress",
+		Metadata: &ingressMetadata{
+			Name:      name,
+			Namespace: ingressNamespace,
+			Annotations: map[string]string{
+				skipperRoutesAnnotation: eskip.Print(
+					eskip.PrettyPrintInfo{Pretty: pretty, IndentStr: indentStr},
+					routes...,
+				),
+			},
+		},
+		Spec: &ingressSpec{},
+	}
+
+	if host != "" {
+		// the rule needs the http field, otherwise the routes of the
+		// annotation are ignored
+		ing.Spec.Rules = []*ingressRule{{
+			Host: host,
+			HTTP: &ingressHTTP{Paths: []*ingressPath{}},
+		}}
+	}
+
+	return ing
+}
+
+// converts the routes to ingress resources with the routes in the
+// skipper-routes annotation. The routes are grouped by their exact host,
+// and the host is set as the rule of the ingress. The routes without an
+// exact host are put in a single ingress without rules.
+func routesToIngresses(routes []*eskip.Route) []*kubernetesResource {
+	var (
+		hosts     []string
+		noHost    []*eskip.Route
+		hostRoute = make(map[string][]*eskip.Route)
+	)
+
+	for _, r := range routes {
+		host, ok := exactHost(r)
+		if !ok {
+			noHost = append(noHost, r)
+			continue
+		}
+
+		if _, exists := hostRoute[host]; !exists {
+			hosts = append(hosts, host)
+		}
+
+		// the host is set by the kubernetes dataclient from the rule
+		rc := *r
+		rc.HostRegexps = nil
+		hostRoute[host] = append(hostRoute[host], &rc)
+	}
+
+	var ingresses []*kubernetesResource
+	if len(noHost) > 0 {
+		printStderr(
+			"warning: the kubernetes dataclient applies the routes of the",
+			skipperRoutesAnnotation,
+			"annotation only for the hosts of the ingress rules, routes without an exact host:",
+			len(noHost),
+		)
+
+		ingresses = append(ingresses, newIngress(ingressName, "", noHost))
+	}
+
+	for _, h := range hosts {
+		name := ingressName + "-" + strings.Replace(h, ".", "-", -1)
+		ingresses = append(ingresses, newIngress(name, h, hostRoute[h]))
+	}
+
+	return ingresses
+}
+
+func routesToKubernetes(routes []*eskip.Route) ([]byte, error) {
+	var b bytes.Buffer
+	for i, ing := range routesToIngresses(routes) {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+
+		y, err := yaml.Marshal(ing)
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(y)
+	}
+
+	return b.Bytes(), nil
+}
+
+func backendRoute(ns, name, host, path string, b *ingressBackend) *eskip.Route {
+	r := &eskip.Route{
+		Id: fmt.Sprintf(
+			"kube_%s__%s__%s__%s__%s",
+			nonWord.ReplaceAllString(ns, "_"),
+			nonWord.ReplaceAllString(name, "_"),
+			nonWord.ReplaceAllString(host, "_"),
+			nonWord.ReplaceAllString(path, "_"),
+			nonWord.ReplaceAllString(b.ServiceName, "_"),
+		),
+		Backend: fmt.Sprintf(
+			"http://%s.%s.svc.cluster.local:%v",
+			b.ServiceName,
+			ns,
+			b.ServicePort,
+		),
+	}
+
+	if host != "" {
+		r.HostRegexps = []string{hostRegexp(host)}
+	}
+
+	if path != "" {
+		r.PathRegexps = []string{"^" + path}
+	}
+
+	return r
+}
+
+// sets the predicates like Path or Header, taken from the annotation, to
+// the corresponding fields of the route, the same way as when parsing
+// the routes
+func normalizeRoute(r *eskip.Route) (*eskip.Route, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var n eskip.Route
+	err = json.Unmarshal(b, &n)
+	return &n, err
+}
+
+// converts an ingress to routes similar to the kubernetes dataclient,
+// but without resolving the services: the routes of the ingress rules
+// point to the cluster internal address of the services.
+func ingressToRoutes(ing *kubernetesResource) ([]*eskip.Route, error) {
+	if ing.Metadata == nil {
+		return nil, errors.New("invalid ingress: missing metadata")
+	}
+
+	var (
+		ns, name    = ing.Metadata.Namespace, ing.Metadata.Name
+		annotations = ing.Metadata.Annotations
+		filters     []*eskip.Filter
+		predicates  []*eskip.Predicate
+		custom      []*eskip.Route
+		routes      []*eskip.Route
+		err         error
+	)
+
+	if ns == "" {
+		ns = defaultIngressNamespace
+	}
+
+	if filters, err = eskip.ParseFilters(annotations[skipperFilterAnnotation]); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation in %s/%s: %v", skipperFilterAnnotation, ns, name, err)
+	}
+
+	if predicates, err = eskip.ParsePredicates(annotations[skipperPredicateAnnotation]); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation in %s/%s: %v", skipperPredicateAnnotation, ns, name, err)
+	}
+
+	if custom, err = eskip.Parse(annotations[skipperRoutesAnnotation]); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation in %s/%s: %v", skipperRoutesAnnotation, ns, name, err)
+	}
+
+	var rules []*ingressRule
+	if ing.Spec != nil {
+		rules = ing.Spec.Rules
+		if ing.Spec.Backend != nil {
+			routes = append(routes, backendRoute(ns, name, "", "", ing.Spec.Backend))
+		}
+	}
+
+	if len(rules) == 0 {
+		routes = append(routes, custom...)
+	}
+
+	for _, rule := range rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, cr := range custom {
+			rc := *cr
+			if rule.Host != "" {
+				rc.HostRegexps = []string{hostRegexp(rule.Host)}
+			}
+
+			if len(rules) > 1 {
+				rc.Id = rc.Id + "__" + nonWord.ReplaceAllString(rule.Host, "_")
+			}
+
+			routes = append(routes, &rc)
+		}
+
+		for _, p := range rule.HTTP.Paths {
+			if p.Backend == nil {
+				return nil, fmt.Errorf("invalid path rule, missing backend in: %s/%s/%s", ns, name, rule.Host)
+			}
+
+			r := backendRoute(ns, name, rule.Host, p.Path, p.Backend)
+			r.Predicates = append(r.Predicates, predicates...)
+			r.Filters = append(r.Filters, filters...)
+			if r, err = normalizeRoute(r); err != nil {
+				return nil, fmt.Errorf("invalid route from %s/%s/%s: %v", ns, name, rule.Host, err)
+			}
+
+			routes = append(routes, r)
+		}
+	}
+
+	return routes, nil
+}
+
+func collectIngresses(r *kubernetesResource) []*kubernetesResource {
+	if r.Kind == "Ingress" {
+		return []*kubernetesResource{r}
+	}
+
+	var ingresses []*kubernetesResource
+	for _, i := range r.Items {
+		if i != nil {
+			ingresses = append(ingresses, collectIngresses(i)...)
+		}
+	}
+
+	return ingresses
+}
+
+// reads the ingress resources from YAML or JSON documents, accepting
+// lists and multiple YAML documents, and ignoring other resources
+func routesFromKubernetes(doc []byte) ([]*eskip.Route, error) {
+	var routes []*eskip.Route
+	d := yaml.NewDecoder(bytes.NewReader(doc))
+	for {
+		var r kubernetesResource
+		if err := d.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, ing := range collectIngresses(&r) {
+			rs, err := ingressToRoutes(ing)
+			if err != nil {
+				return nil, err
+			}
+
+			routes = append(routes, rs...)
+		}
+	}
+
+	return routes, nil
+}
+
+func readRoutes(in *medium, f routeFormat) ([]*eskip.Route, error) {
+	if f == eskipFormat {
+		return loadRoutesChecked(in)
+	}
+
+	if in.typ != stdin && in.typ != file {
+		return nil, invalidConvertInput
+	}
+
+	doc, _, err := readDocument(in)
+	if err != nil {
+		return nil, err
+	}
+
+	switch f {
+	case jsonFormat:
+		var routes []*eskip.Route
+		err := json.Unmarshal([]byte(doc), &routes)
+		return routes, err
+	case yamlFormat:
+		return routesFromYAML([]byte(doc))
+	default:
+		return routesFromKubernetes([]byte(doc))
+	}
+}
+
+func writeRoutes(routes []*eskip.Route, f routeFormat) error {
+	switch f {
+	case eskipFormat:
+		eskip.Fprint(stdout, eskip.PrettyPrintInfo{Pretty: pretty, IndentStr: indentStr}, routes...)
+		return nil
+	case jsonFormat:
+		e := json.NewEncoder(stdout)
+		e.SetEscapeHTML(false)
+		if pretty {
+			e.SetIndent("", indentStr)
+		}
+
+		return e.Encode(routes)
+	case yamlFormat:
+		b, err := routesToYAML(routes)
+		if err != nil {
+			return err
+		}
+
+		_, err = stdout.Write(b)
+		return err
+	default:
+		b, err := routesToKubernetes(routes)
+		if err != nil {
+			return err
+		}
+
+		_, err = stdout.Write(b)
+		return err
+	}
+}
+
+// command executed for convert.
+func convertCmd(a cmdArgs) error {
+	from, err := parseFormat(fromFormat)
+	if err != nil {
+		return err
+	}
+
+	to, err := parseFormat(toFormat)
+	if err != nil {
+		return err
+	}
+
+	routes, err := readRoutes(a.in, from)
+	if err != nil {
+		return err
+	}
+
+	return writeRoutes(routes, to)
+}
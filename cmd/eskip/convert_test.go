@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sanity-io/litter"
+	"github.com/zalando/skipper/eskip"
+)
+
+const convertRoutes = `
+	route1: Method("PUT") && Path("/foo") && PathRegexp(/^\/foo/) &&
+		Header("X-Foo", "bar") && HeaderRegexp("Accept", /json/) && HeaderRegexp("Accept", /xml/) &&
+		Traffic(0.3) && Custom(3.14, "baz")
+		-> setRequestHeader("X-Bar", "baz") -> status(201) -> xsrf()
+		-> "https://www.example.org";
+	route2: @owner("team-x") @deprecated() Host(/^www[.]example[.]org$/) && Path("/bar") -> <shunt>;
+	route3: Host(/^www[.]example[.]org$/) && Path("/baz") -> setPath("/qux") -> <loopback>;
+	route4: Host(/^api[.]example[.]org$/) -> "https://api.example.org";
+	route5: Host(/example/) -> <shunt>;
+`
+
+func withConvertOptions(t *testing.T, test func()) {
+	defer func(name, namespace string) {
+		ingressName, ingressNamespace = name, namespace
+	}(ingressName, ingressNamespace)
+
+	ingressName, ingressNamespace = defaultIngressName, defaultIngressNamespace
+	test()
+}
+
+func checkConvertedRoutes(t *testing.T, expected, got []*eskip.Route) {
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("invalid routes, expected:\n%s\ngot:\n%s", litter.Sdump(expected), litter.Sdump(got))
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []string{"eskip", "json", "YAML", "kubernetes"} {
+		if _, err := parseFormat(f); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := parseFormat("xml"); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestConvertYAMLRoundTrip(t *testing.T) {
+	routes, err := eskip.Parse(convertRoutes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := routesToYAML(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr, err := routesFromYAML(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkConvertedRoutes(t, routes, rr)
+}
+
+func TestConvertKubernetesRoundTrip(t *testing.T) {
+	withConvertOptions(t, func() {
+		routes, err := eskip.Parse(convertRoutes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ingresses := routesToIngresses(routes)
+		if len(ingresses) != 3 {
+			t.Fatalf("invalid number of ingresses: %d", len(ingresses))
+		}
+
+		for i, name := range []string{"routes", "routes-www-example-org", "routes-api-example-org"} {
+			if ingresses[i].Metadata.Name != name {
+				t.Errorf("invalid ingress name, expected: %s, got: %s", name, ingresses[i].Metadata.Name)
+			}
+		}
+
+		k, err := routesToKubernetes(routes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr, err := routesFromKubernetes(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the routes without exact host come first
+		expected := append([]*eskip.Route{routes[0], routes[4]}, routes[1:4]...)
+		checkConvertedRoutes(t, expected, rr)
+	})
+}
+
+func TestConvertFromIngress(t *testing.T) {
+	const ingressList = `{
+		"apiVersion": "v1",
+		"kind": "List",
+		"items": [{
+			"apiVersion": "v1",
+			"kind": "Service",
+			"metadata": {"name": "foo"}
+		}, {
+			"apiVersion": "extensions/v1beta1",
+			"kind": "Ingress",
+			"metadata": {
+				"name": "foo",
+				"namespace": "bar",
+				"annotations": {
+					"zalando.org/skipper-filter": "setPath(\"/baz\")",
+					"zalando.org/skipper-predicate": "Header(\"X-Foo\", \"qux\")",
+					"zalando.org/skipper-routes": "health: Path(\"/health\") -> <shunt>"
+				}
+			},
+			"spec": {
+				"rules": [{
+					"host": "foo.example.org",
+					"http": {"paths": [{
+						"path": "/api",
+						"backend": {"serviceName": "foo-service", "servicePort": 8080}
+					}]}
+				}]
+			}
+		}]
+	}`
+
+	expected, err := eskip.Parse(`
+		health: Host(/^foo[.]example[.]org$/) && Path("/health") -> <shunt>;
+		kube_bar__foo__foo_example_org___api__foo_service:
+			Host(/^foo[.]example[.]org$/) && PathRegexp(/^\/api/) && Header("X-Foo", "qux")
+			-> setPath("/baz")
+			-> "http://foo-service.bar.svc.cluster.local:8080";
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes, err := routesFromKubernetes([]byte(ingressList))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkConvertedRoutes(t, expected, routes)
+}
+
+func TestConvertCommand(t *testing.T) {
+	withFormatFile(t, `route1: Path("/foo") -> <shunt>`, func(path string) {
+		var buf bytes.Buffer
+		stdout, fromFormat, toFormat = &buf, "eskip", "yaml"
+		defer func() { stdout, fromFormat, toFormat = os.Stdout, "", "" }()
+
+		if err := convertCmd(cmdArgs{in: &medium{typ: file, path: path}}); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "- id: route1\n  predicates:\n  - name: Path\n    args:\n    - /foo\n  backend: <shunt>\n"
+		if buf.String() != expected {
+			t.Errorf("invalid output, expected:\n%s\ngot:\n%s", expected, buf.String())
+		}
+	})
+}
+
+func TestConvertInvalidInput(t *testing.T) {
+	fromFormat, toFormat = "json", "eskip"
+	defer func() { fromFormat, toFormat = "", "" }()
+
+	if err := convertCmd(cmdArgs{in: &medium{typ: inline, eskip: "* -> <shunt>"}}); err != invalidConvertInput {
+		t.Errorf("failed to fail: %v", err)
+	}
+}
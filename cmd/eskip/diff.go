@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+type routeChange struct {
+	ID    string `json:"id"`
+	Route string `json:"route,omitempty"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+
+	fromRoute, toRoute *eskip.Route
+}
+
+type routesDiff struct {
+	Added   []routeChange `json:"added"`
+	Removed []routeChange `json:"removed"`
+	Changed []routeChange `json:"changed"`
+}
+
+var differentRoutes = errors.New("the routes are different")
+
+func mediumName(m *medium) string {
+	switch m.typ {
+	case file:
+		return m.path
+	case stdin:
+		return "stdin"
+	case inline:
+		return "inline"
+	case etcd:
+		return "etcd"
+	case innkeeper:
+		return "innkeeper"
+	default:
+		return "unknown"
+	}
+}
+
+func sortedIds(m routeMap) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// compares the routes by id, and returns the added, removed and changed
+// routes in the order of their ids.
+func diffRoutes(from, to []*eskip.Route) routesDiff {
+	d := routesDiff{
+		Added:   []routeChange{},
+		Removed: []routeChange{},
+		Changed: []routeChange{},
+	}
+
+	mfrom, mto := mapRoutes(from), mapRoutes(to)
+	for _, id := range sortedIds(mfrom) {
+		if r, exists := mto[id]; !exists {
+			d.Removed = append(d.Removed, routeChange{
+				ID:        id,
+				Route:     mfrom[id].String(),
+				fromRoute: mfrom[id],
+			})
+		} else if routesDiffer(mfrom[id], r) {
+			d.Changed = append(d.Changed, routeChange{
+				ID:        id,
+				From:      mfrom[id].String(),
+				To:        r.String(),
+				fromRoute: mfrom[id],
+				toRoute:   r,
+			})
+		}
+	}
+
+	for _, id := range sortedIds(mto) {
+		if _, exists := mfrom[id]; !exists {
+			d.Added = append(d.Added, routeChange{
+				ID:      id,
+				Route:   mto[id].String(),
+				toRoute: mto[id],
+			})
+		}
+	}
+
+	return d
+}
+
+func (d routesDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// returns the lines of the longest common subsequence based diff of
+// two sets of lines, prefixed with ' ', '-' or '+'.
+func diffLines(from, to []string) []string {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			lines = append(lines, " "+from[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+from[i])
+			i++
+		default:
+			lines = append(lines, "+"+to[j])
+			j++
+		}
+	}
+
+	for ; i < len(from); i++ {
+		lines = append(lines, "-"+from[i])
+	}
+
+	for ; j < len(to); j++ {
+		lines = append(lines, "+"+to[j])
+	}
+
+	return lines
+}
+
+// prints a route definition in pretty format, split into lines
+func definitionLines(r *eskip.Route) []string {
+	return strings.Split(eskip.Print(eskip.PrettyPrintInfo{Pretty: true, IndentStr: indentStr}, r), "\n")
+}
+
+func prefixLines(prefix string, lines []string) []string {
+	p := make([]string, len(lines))
+	for i, l := range lines {
+		p[i] = prefix + l
+	}
+
+	return p
+}
+
+func printDiff(from, to *medium, d routesDiff) error {
+	if printJson {
+		e := json.NewEncoder(stdout)
+		e.SetEscapeHTML(false)
+		return e.Encode(d)
+	}
+
+	if d.empty() {
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "--- %s\n+++ %s\n", mediumName(from), mediumName(to))
+	for _, c := range d.Removed {
+		fmt.Fprintf(stdout, "@@ %s removed @@\n", c.ID)
+		fmt.Fprintln(stdout, strings.Join(prefixLines("-", definitionLines(c.fromRoute)), "\n"))
+	}
+
+	for _, c := range d.Added {
+		fmt.Fprintf(stdout, "@@ %s added @@\n", c.ID)
+		fmt.Fprintln(stdout, strings.Join(prefixLines("+", definitionLines(c.toRoute)), "\n"))
+	}
+
+	for _, c := range d.Changed {
+		fmt.Fprintf(stdout, "@@ %s changed @@\n", c.ID)
+		lines := diffLines(definitionLines(c.fromRoute), definitionLines(c.toRoute))
+		fmt.Fprintln(stdout, strings.Join(lines, "\n"))
+	}
+
+	return nil
+}
+
+// command executed for diff. It fails when the routes are different,
+// so that it can be used in scripts.
+func diffCmd(a cmdArgs) error {
+	from, err := loadRoutesChecked(a.out)
+	if err != nil {
+		return err
+	}
+
+	to, err := loadRoutesChecked(a.in)
+	if err != nil {
+		return err
+	}
+
+	d := diffRoutes(from, to)
+	if err := printDiff(a.out, a.in, d); err != nil {
+		return err
+	}
+
+	if !d.empty() {
+		return differentRoutes
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const (
+	diffFromRoutes = `
+		route1: Path("/foo") -> setPath("/a") -> <shunt>;
+		route2: Path("/bar") -> "https://www.example.org";
+		route3: * -> <loopback>;`
+
+	diffToRoutes = `
+		route1: Path("/foo") -> setPath("/b") -> <shunt>;
+		route3: * -> <loopback>;
+		route4: Method("POST") -> <shunt>;`
+)
+
+func testDiff(from, to string, jsonOutput bool) (string, error) {
+	var buf bytes.Buffer
+	stdout, printJson = &buf, jsonOutput
+	defer func() { stdout, printJson = os.Stdout, false }()
+
+	err := diffCmd(cmdArgs{
+		out: &medium{typ: inline, eskip: from},
+		in:  &medium{typ: inline, eskip: to},
+	})
+
+	return buf.String(), err
+}
+
+func TestDiff(t *testing.T) {
+	output, err := testDiff(diffFromRoutes, diffToRoutes, false)
+	if err != differentRoutes {
+		t.Errorf("failed to fail: %v", err)
+	}
+
+	expected := `--- inline
++++ inline
+@@ route2 removed @@
+-route2: Path("/bar")
+-  -> "https://www.example.org";
+@@ route4 added @@
++route4: Method("POST")
++  -> <shunt>;
+@@ route1 changed @@
+ route1: Path("/foo")
+-  -> setPath("/a")
++  -> setPath("/b")
+   -> <shunt>;
+`
+
+	if output != expected {
+		t.Errorf("invalid diff, expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	output, err := testDiff(diffFromRoutes, diffToRoutes, true)
+	if err != differentRoutes {
+		t.Errorf("failed to fail: %v", err)
+	}
+
+	var d routesDiff
+	if err := json.Unmarshal([]byte(output), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := routesDiff{
+		Added:   []routeChange{{ID: "route4", Route: `Method("POST") -> <shunt>`}},
+		Removed: []routeChange{{ID: "route2", Route: `Path("/bar") -> "https://www.example.org"`}},
+		Changed: []routeChange{{
+			ID:   "route1",
+			From: `Path("/foo") -> setPath("/a") -> <shunt>`,
+			To:   `Path("/foo") -> setPath("/b") -> <shunt>`,
+		}},
+	}
+
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("invalid diff, expected: %v, got: %v", expected, d)
+	}
+}
+
+func TestDiffEqual(t *testing.T) {
+	output, err := testDiff(diffFromRoutes, diffFromRoutes, false)
+	if err != nil || output != "" {
+		t.Errorf("unexpected diff: %v, %s", err, output)
+	}
+
+	output, err = testDiff(diffFromRoutes, diffFromRoutes, true)
+	if err != nil || output != `{"added":[],"removed":[],"changed":[]}`+"\n" {
+		t.Errorf("unexpected diff: %v, %s", err, output)
+	}
+}
+
+func TestDiffArgs(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	os.Args = []string{"eskip", "diff", "old.eskip", "new.eskip"}
+	resetFlagVars()
+	initFlags()
+
+	media, err := processArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := validateSelectDiff(media)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.out.path != "old.eskip" || a.in.path != "new.eskip" {
+		t.Error("failed to select media", a.out.path, a.in.path)
+	}
+
+	if _, err := validateSelectDiff([]*medium{{typ: etcd}}); err != missingInput {
+		t.Error("failed to fail on missing input", err)
+	}
+
+	a, err = validateSelectDiff([]*medium{{typ: file, path: "routes.eskip"}})
+	if err != nil || a.in.path != "routes.eskip" || a.out != nil {
+		t.Error("failed to select input", err)
+	}
+}
@@ -13,46 +13,78 @@
 // limitations under the License.
 
 /*
-This utility can be used to verify, print, update or delete eskip
+This utility can be used to verify, print, format, update or delete eskip
 formatted routes from and to different data sources.
 
 For command line help, enter:
 
-    eskip -help
+	eskip -help
 
-Examples
+# Examples
 
 Check if an eskip file has valid syntax:
 
-    eskip check routes.eskip
+	eskip check routes.eskip
+
+Check if the filters, predicates and backends of the routes in an eskip
+file are valid, and print the findings as JSON:
+
+	eskip check -validate -json routes.eskip
+
+Format an eskip file in place, or check if it is formatted:
+
+	eskip fmt routes.eskip
+	eskip fmt -check routes.eskip
+
+Show the differences between the routes in etcd and in an eskip file:
+
+	eskip diff -etcd-urls https://etcd.example.org routes.eskip
+
+Show the differences between two eskip files as JSON:
+
+	eskip diff -json old.eskip new.eskip
 
 Print routes stored in etcd:
 
-    eskip print -etcd-urls https://etcd.example.org
+	eskip print -etcd-urls https://etcd.example.org
 
 Print routes as JSON:
 
-    eskip print -json
+	eskip print -json
 
 Insert/update routes in etcd from an eskip file:
 
-    eskip upsert routes.eskip
+	eskip upsert routes.eskip
 
 Sync routes from an eskip file to etcd:
 
-    eskip reset routes.eskip
+	eskip reset routes.eskip
+
+Check which routes would be changed in etcd by a sync from an eskip
+file, without changing them:
+
+	eskip reset -dry-run routes.eskip
+
+Test which routes the sample requests in a JSON file match:
+
+	eskip test -requests requests.json routes.eskip
+
+Convert the routes in etcd to YAML, or a YAML file to eskip:
+
+	eskip convert -to yaml
+	eskip convert -from yaml routes.yaml
 
 Delete routes from etcd:
 
-    eskip delete -ids route1,route2,route3
+	eskip delete -ids route1,route2,route3
 
 Delete all routes from etcd:
 
-    eskip print | eskip delete
+	eskip print | eskip delete
 
 Copy all routes in etcd under a different prefix:
 
-    eskip print | eskip upsert -etcd-prefix /skipper-backup
+	eskip print | eskip upsert -etcd-prefix /skipper-backup
 
 (Where -etcd-urls is not set for write operations like upsert, reset and
 delete, the default etcd cluster urls are used:
@@ -70,25 +102,36 @@ const (
 	helpHint = "To print eskip usage, enter: eskip -help"
 
 	// flag usage strings:
-	etcdUrlsUsage       = "urls of nodes in an etcd cluster"
-	etcdPrefixUsage     = "path prefix for routes in etcd"
-	innkeeperUrlUsage   = "url for the innkeeper service"
-	oauthTokenUsage     = "oauth token used to authenticate to innkeeper"
-	etcdOAuthTokenUsage = "oauth token used to authenticate to etcd"
-	inlineRoutesUsage   = "inline: routes in eskip format"
-	inlineIdsUsage      = "inline ids: comma separated route ids"
-	insecureUsage       = "skip TLS certificate verification"
-	prependFiltersUsage = "prepend filters to each patched route"
-	prependFileUsage    = "prepend filters from a file to each patched route"
-	appendFiltersUsage  = "append filters to each patched route"
-	appendFileUsage     = "append filters from a file to each patched route"
-	prettyUsage         = "prints routes in a more readable format"
-	indentStrUsage      = "indent string used in pretty printing. Must match regexp \\s"
-	jsonUsage           = "prints routes as JSON"
+	etcdUrlsUsage         = "urls of nodes in an etcd cluster"
+	etcdPrefixUsage       = "path prefix for routes in etcd"
+	innkeeperUrlUsage     = "url for the innkeeper service"
+	oauthTokenUsage       = "oauth token used to authenticate to innkeeper"
+	etcdOAuthTokenUsage   = "oauth token used to authenticate to etcd"
+	inlineRoutesUsage     = "inline: routes in eskip format"
+	inlineIdsUsage        = "inline ids: comma separated route ids"
+	insecureUsage         = "skip TLS certificate verification"
+	prependFiltersUsage   = "prepend filters to each patched route"
+	prependFileUsage      = "prepend filters from a file to each patched route"
+	appendFiltersUsage    = "append filters to each patched route"
+	appendFileUsage       = "append filters from a file to each patched route"
+	prettyUsage           = "prints routes in a more readable format"
+	indentStrUsage        = "indent string used in pretty printing. Must match regexp \\s"
+	jsonUsage             = "prints routes as JSON, the check diagnostics with -validate, the differences with diff, or the test results"
+	validateUsage         = "check validates the filters, predicates and backends of the routes, and reports shadowed routes"
+	checkFormatUsage      = "fmt only checks if the routes are formatted, and fails if they are not"
+	sortRoutesUsage       = "fmt sorts the routes by their id"
+	dryRunUsage           = "upsert, reset and delete only print the routes that would be inserted, updated or deleted"
+	maxDeletesUsage       = "upsert, reset and delete fail without changes when more routes would be deleted. Negative means no limit"
+	confirmUsage          = "upsert, reset and delete print the changes and ask for confirmation before applying them"
+	requestsFileUsage     = "test reads the sample requests and the expectations from this JSON file"
+	fromFormatUsage       = "convert reads the routes in this format: eskip, json, yaml or kubernetes"
+	toFormatUsage         = "convert prints the routes in this format: eskip, json, yaml or kubernetes"
+	ingressNameUsage      = "convert uses this name, or prefix of the names, for the generated ingress resources"
+	ingressNamespaceUsage = "convert uses this namespace for the generated ingress resources"
 
 	// command line help (1):
 	help1 = `Usage: eskip <command> [media flags] [--] [file]
-Commands: check|print|upsert|reset|delete|patch
+Commands: check|print|upsert|reset|delete|patch|fmt|diff|test|convert
 Verify, print, update or delete Skipper routes.
 See more: https://github.com/zalando/skipper
 
@@ -125,6 +168,15 @@ check    verifies the syntax of routes. Accepts one input medium
          Example:
          eskip check -etcd-urls http://etcd.example.org
 
+         With -validate, the filters and predicates are created with
+         their arguments using the standard registry, the backend
+         addresses are verified, and the routes shadowed by other
+         routes with the same conditions are reported, except for the
+         routes with random conditions, like Traffic. The diagnostics
+         contain the line and column of the routes, when available,
+         and they are printed as JSON with -json. Example:
+         eskip check -validate -json routes.eskip
+
 print    same as check, but also prints the routes.
 
 upsert   insert/update routes from input to output. Expects one input
@@ -141,11 +193,76 @@ delete   deletes routes from the output that are specified in the input.
          Example:
          eskip delete -ids route1,route2,route3
 
+         upsert, reset and delete accept the following safety flags:
+         -dry-run prints the routes that would be inserted, updated or
+         deleted, without changing the output; -max-deletes fails the
+         command without changes when more routes would be deleted;
+         -confirm prints the changes, and asks for confirmation on the
+         terminal before applying them. Example:
+         eskip reset -dry-run -max-deletes 3 routes.eskip
+
 patch    takes a list of routes as input from any media except of inline
          ids, and prepends or appends a common filter chain to each
 		 route. Example:
 		 eskip patch -append 'filter1() -> filter2()'
 
+fmt      formats the routes in a canonical form, preserving the
+         comments. Expects one input medium of the following types:
+         stdin, file, inline. A file is rewritten in place, while the
+         routes from the other media are printed. With -check, the
+         input is not changed, and the command fails if the routes
+         are not formatted. With -sort, the routes are ordered by
+         their id, otherwise their order is kept. Example:
+         eskip fmt -pretty routes.eskip
+
+diff     prints the differences between the routes of two media: the
+         removed, added and changed routes, with a line diff of the
+         changed ones, or, with -json, a JSON object with the added,
+         removed and changed fields. The routes in etcd or innkeeper,
+         or in the first of two files, are compared to the routes in
+         the other medium. When only a stdin, file or inline input is
+         specified, it is compared to the routes in etcd. Fails when
+         the routes are different. Example:
+         eskip diff -etcd-urls http://etcd.example.org routes.eskip
+
+test     evaluates sample requests against the routes, without running
+         a proxy, and reports the matching route ids and path params.
+         Accepts the same input media as check. The requests are read
+         from the JSON file set by -requests, containing a list of
+         objects with the fields: name, method, host, path (with the
+         query), headers, sourceIP and expect. The optional expect
+         object contains the expected route id (empty for no match),
+         and the expected path params. Fails when an expectation
+         doesn't hold, or when any of the routes is invalid. Example:
+         eskip test -requests requests.json routes.eskip
+
+         A requests file example:
+         [{
+           "name": "user details",
+           "method": "GET",
+           "host": "api.example.org",
+           "path": "/users/42?details=true",
+           "headers": {"Accept": "application/json"},
+           "sourceIP": "10.0.0.1",
+           "expect": {"route": "users", "params": {"id": "42"}}
+         }]
+
+convert  converts the routes between the formats eskip, json, yaml
+         and kubernetes, set by -from and -to, both defaulting to
+         eskip. Accepts the same input media as check, but only stdin
+         and file when converting from other formats than eskip. The
+         json format is the same as printed by print -json, and the
+         yaml format has the same structure. The kubernetes format
+         contains Ingress resources with the routes in the
+         zalando.org/skipper-routes annotation, one per host, where
+         the routes with an exact host, e.g. Host(/^www[.]example[.]org$/),
+         are grouped by their host and the host is set as the rule of
+         the ingress. When reading ingress resources, the routes of the
+         annotations are taken, and the ingress rules are converted to
+         routes pointing to the cluster address of the services.
+         Example:
+         eskip convert -to kubernetes -ingress-namespace my-team routes.eskip
+
 version  print eskip version`
 )
 
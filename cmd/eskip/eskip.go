@@ -27,13 +27,17 @@ type (
 )
 
 const (
-	check  command = "check"
-	print  command = "print"
-	upsert command = "upsert"
-	reset  command = "reset"
-	delete command = "delete"
-	patch  command = "patch"
-	ver    command = "version"
+	check   command = "check"
+	print   command = "print"
+	upsert  command = "upsert"
+	reset   command = "reset"
+	delete  command = "delete"
+	patch   command = "patch"
+	format  command = "fmt"
+	diff    command = "diff"
+	test    command = "test"
+	convert command = "convert"
+	ver     command = "version"
 )
 
 var (
@@ -43,13 +47,17 @@ var (
 
 // map command string to command function
 var commands = map[command]commandFunc{
-	check:  checkCmd,
-	print:  printCmd,
-	upsert: upsertCmd,
-	reset:  resetCmd,
-	delete: deleteCmd,
-	patch:  patchCmd,
-	ver:    versionCmd}
+	check:   checkCmd,
+	print:   printCmd,
+	upsert:  upsertCmd,
+	reset:   resetCmd,
+	delete:  deleteCmd,
+	patch:   patchCmd,
+	format:  formatCmd,
+	diff:    diffCmd,
+	test:    testCmd,
+	convert: convertCmd,
+	ver:     versionCmd}
 
 var (
 	missingCommand = errors.New("missing command")
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+var notFormatted = errors.New("routes are not formatted")
+
+// command executed for fmt.
+func formatCmd(a cmdArgs) error {
+	doc, _, err := readDocument(a.in)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := eskip.Format(doc, eskip.FormatOptions{
+		PrettyPrintInfo: eskip.PrettyPrintInfo{Pretty: pretty, IndentStr: indentStr},
+		SortRoutes:      sortRoutes,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if checkFormat {
+		if formatted == doc {
+			return nil
+		}
+
+		if a.in.typ == file {
+			fmt.Fprintln(stdout, a.in.path)
+		}
+
+		return notFormatted
+	}
+
+	if a.in.typ != file {
+		_, err := fmt.Fprint(stdout, formatted)
+		return err
+	}
+
+	if formatted == doc {
+		return nil
+	}
+
+	info, err := os.Stat(a.in.path)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(a.in.path, []byte(formatted), info.Mode())
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	unformattedRoutes = "route1: Path(`/foo`)->`https://www.example.org`; // foo\n"
+	formattedRoutes   = "route1: Path(\"/foo\") -> \"https://www.example.org\"; // foo\n"
+)
+
+func withFormatFile(t *testing.T, content string, test func(path string)) {
+	dir, err := ioutil.TempDir("", "eskip-fmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "routes.eskip")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	test(path)
+}
+
+func testFormat(in *medium, check bool) (string, error) {
+	var buf bytes.Buffer
+	stdout, checkFormat = &buf, check
+	defer func() { stdout, checkFormat = os.Stdout, false }()
+
+	err := formatCmd(cmdArgs{in: in})
+	return buf.String(), err
+}
+
+func TestFormatFile(t *testing.T) {
+	withFormatFile(t, unformattedRoutes, func(path string) {
+		if _, err := testFormat(&medium{typ: file, path: path}, false); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != formattedRoutes {
+			t.Errorf("failed to format file: %s", string(b))
+		}
+	})
+}
+
+func TestFormatCheck(t *testing.T) {
+	withFormatFile(t, unformattedRoutes, func(path string) {
+		output, err := testFormat(&medium{typ: file, path: path}, true)
+		if err != notFormatted {
+			t.Errorf("failed to fail: %v", err)
+		}
+
+		if output != path+"\n" {
+			t.Errorf("failed to print the file name: %s", output)
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != unformattedRoutes {
+			t.Error("file modified in check mode")
+		}
+	})
+
+	withFormatFile(t, formattedRoutes, func(path string) {
+		if output, err := testFormat(&medium{typ: file, path: path}, true); err != nil || output != "" {
+			t.Errorf("unexpected check result: %v, %s", err, output)
+		}
+	})
+}
+
+func TestFormatInline(t *testing.T) {
+	output, err := testFormat(&medium{typ: inline, eskip: unformattedRoutes}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != formattedRoutes {
+		t.Errorf("failed to print the formatted routes: %s", output)
+	}
+}
+
+func TestFormatSelectMedia(t *testing.T) {
+	if _, err := validateSelectFormat(nil); err != missingInput {
+		t.Error("failed to fail on missing input", err)
+	}
+
+	if _, err := validateSelectFormat([]*medium{{typ: etcd}}); err != invalidInputType {
+		t.Error("failed to fail on invalid input", err)
+	}
+
+	if a, err := validateSelectFormat([]*medium{{typ: file, path: "routes.eskip"}}); err != nil || a.in.path != "routes.eskip" {
+		t.Error("failed to select the input", err)
+	}
+}
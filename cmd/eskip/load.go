@@ -98,6 +98,10 @@ func loadRoutesUnchecked(in *medium) []*eskip.Route {
 
 // command executed for check.
 func checkCmd(a cmdArgs) error {
+	if validate {
+		return validateCmd(a)
+	}
+
 	routes, err := loadRoutesChecked(a.in)
 	if err != nil {
 		return err
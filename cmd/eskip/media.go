@@ -39,12 +39,16 @@ const (
 )
 
 var commandToValidations = map[command]validateSelectFunc{
-	check:  validateSelectRead,
-	print:  validateSelectRead,
-	upsert: validateSelectWrite,
-	reset:  validateSelectWrite,
-	delete: validateSelectDelete,
-	patch:  validateSelectPatch}
+	check:   validateSelectRead,
+	print:   validateSelectRead,
+	upsert:  validateSelectWrite,
+	reset:   validateSelectWrite,
+	delete:  validateSelectDelete,
+	patch:   validateSelectPatch,
+	format:  validateSelectFormat,
+	diff:    validateSelectDiff,
+	test:    validateSelectRead,
+	convert: validateSelectRead}
 
 type medium struct {
 	typ          mediaType
@@ -172,6 +176,66 @@ func validateSelectPatch(media []*medium) (a cmdArgs, err error) {
 	return
 }
 
+// validate media from args, and check if there is exactly one input of
+// the following types: stdin, file, inline.
+func validateSelectFormat(media []*medium) (a cmdArgs, err error) {
+	if len(media) == 0 {
+		err = missingInput
+		return
+	}
+
+	if len(media) > 1 {
+		err = tooManyInputs
+		return
+	}
+
+	switch media[0].typ {
+	case stdin, file, inline:
+	default:
+		err = invalidInputType
+		return
+	}
+
+	a.in = media[0]
+	return
+}
+
+// validate media from args for diff. The first medium is the one that
+// the other is compared to. When only one medium is specified, it needs
+// to be a local input, and it is compared to the default output.
+func validateSelectDiff(media []*medium) (a cmdArgs, err error) {
+	if len(media) == 0 {
+		err = missingInput
+		return
+	}
+
+	if len(media) > 2 {
+		err = tooManyInputs
+		return
+	}
+
+	for _, m := range media {
+		switch m.typ {
+		case inlineIds, patchPrepend, patchPrependFile, patchAppend, patchAppendFile:
+			err = invalidInputType
+			return
+		}
+	}
+
+	if len(media) == 1 {
+		if media[0].typ == etcd || media[0].typ == innkeeper {
+			err = missingInput
+			return
+		}
+
+		a.in = media[0]
+		return
+	}
+
+	a.out, a.in = media[0], media[1]
+	return
+}
+
 // Validates media from args for the current command, and selects input and/or output.
 func validateSelectMedia(cmd command, media []*medium) (cmdArgs cmdArgs, err error) {
 	a, err := commandToValidations[cmd](media)
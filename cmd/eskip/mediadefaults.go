@@ -4,12 +4,16 @@ type defaultFunc func(cmdArgs) (cmdArgs, error)
 
 // map command string to defaults
 var commandToDefaultMediums = map[command]defaultFunc{
-	check:  defaultRead,
-	print:  defaultRead,
-	upsert: defaultWrite,
-	reset:  defaultWrite,
-	delete: defaultWrite,
-	patch:  defaultRead}
+	check:   defaultRead,
+	print:   defaultRead,
+	upsert:  defaultWrite,
+	reset:   defaultWrite,
+	delete:  defaultWrite,
+	patch:   defaultRead,
+	format:  defaultRead,
+	diff:    defaultWrite,
+	test:    defaultRead,
+	convert: defaultRead}
 
 func defaultRead(a cmdArgs) (aa cmdArgs, err error) {
 	aa = a
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+// writePlan collects the changes of the write commands, implementing the
+// writeClient interface, so that they can be printed, checked or
+// confirmed before they are applied to the output.
+type writePlan struct {
+	upserts []*eskip.Route
+	deletes []*eskip.Route
+}
+
+var (
+	tooManyDeletes = errors.New("too many routes to delete")
+	notConfirmed   = errors.New("changes not confirmed")
+)
+
+// used to ask for the confirmation, even when the routes are read from
+// the stdin
+var openConfirmInput = func() (io.ReadCloser, error) {
+	return os.Open("/dev/tty")
+}
+
+func (p *writePlan) UpsertAll(routes []*eskip.Route) error {
+	p.upserts = append(p.upserts, routes...)
+	return nil
+}
+
+func (p *writePlan) DeleteAllIf(routes []*eskip.Route, cond eskip.RoutePredicate) error {
+	for _, r := range routes {
+		if cond(r) {
+			p.deletes = append(p.deletes, r)
+		}
+	}
+
+	return nil
+}
+
+// prints the routes that would be inserted, updated or deleted. The
+// upserted routes that don't differ from the existing ones are not
+// printed.
+func (p *writePlan) print(existing []*eskip.Route) {
+	mexisting := mapRoutes(existing)
+	for _, r := range p.upserts {
+		er, exists := mexisting[r.Id]
+		switch {
+		case r.Id == "":
+			fmt.Fprintf(stdout, "insert: %s\n", r.String())
+		case !exists:
+			fmt.Fprintf(stdout, "insert: %s: %s;\n", r.Id, r.String())
+		case routesDiffer(er, r):
+			fmt.Fprintf(stdout, "update: %s: %s;\n", r.Id, r.String())
+		}
+	}
+
+	for _, r := range p.deletes {
+		fmt.Fprintf(stdout, "delete: %s\n", r.Id)
+	}
+}
+
+func (p *writePlan) apply(wc writeClient) error {
+	if err := wc.UpsertAll(p.upserts); err != nil {
+		return err
+	}
+
+	return wc.DeleteAllIf(p.deletes, any)
+}
+
+func confirmChanges() (bool, error) {
+	in, err := openConfirmInput()
+	if err != nil {
+		return false, err
+	}
+
+	defer in.Close()
+
+	fmt.Fprint(stdout, "Apply the changes? [y/N] ")
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// executes the write function of a command on the write client of the
+// output. With -dry-run, -max-deletes or -confirm, the changes are
+// collected first, and applied only when they pass the checks.
+func applyChanges(out *medium, wc writeClient, write func(writeClient) error) error {
+	if !dryRun && !confirmWrite && maxDeletes < 0 {
+		return write(wc)
+	}
+
+	p := &writePlan{}
+	if err := write(p); err != nil {
+		return err
+	}
+
+	if dryRun || confirmWrite {
+		p.print(loadRoutesUnchecked(out))
+	}
+
+	if maxDeletes >= 0 && len(p.deletes) > maxDeletes {
+		return fmt.Errorf("%v: %d, allowed: %d", tooManyDeletes, len(p.deletes), maxDeletes)
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if confirmWrite {
+		if len(p.upserts) == 0 && len(p.deletes) == 0 {
+			return nil
+		}
+
+		confirmed, err := confirmChanges()
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			return notConfirmed
+		}
+	}
+
+	return p.apply(wc)
+}
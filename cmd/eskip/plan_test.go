@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+type recordingClient struct {
+	upserted []string
+	deleted  []string
+}
+
+const planExistingRoutes = `
+	route1: Path("/foo") -> <shunt>;
+	route2: Path("/bar") -> <shunt>;
+	route3: Path("/baz") -> <shunt>;`
+
+func (c *recordingClient) UpsertAll(routes []*eskip.Route) error {
+	for _, r := range routes {
+		c.upserted = append(c.upserted, r.Id)
+	}
+
+	return nil
+}
+
+func (c *recordingClient) DeleteAllIf(routes []*eskip.Route, cond eskip.RoutePredicate) error {
+	for _, r := range routes {
+		if cond(r) {
+			c.deleted = append(c.deleted, r.Id)
+		}
+	}
+
+	return nil
+}
+
+func testPlan(answer string, f func()) string {
+	var buf bytes.Buffer
+	preserveStdout, preserveConfirmInput := stdout, openConfirmInput
+	stdout = &buf
+	openConfirmInput = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(answer)), nil
+	}
+
+	defer func() {
+		stdout, openConfirmInput = preserveStdout, preserveConfirmInput
+		dryRun, maxDeletes, confirmWrite = false, -1, false
+	}()
+
+	f()
+	return buf.String()
+}
+
+// applies a reset to the existing routes, recording the changes
+func testReset(t *testing.T, routes string) (*recordingClient, error) {
+	existing, err := eskip.Parse(planExistingRoutes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update, err := eskip.Parse(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &recordingClient{}
+	err = applyChanges(&medium{typ: inline, eskip: planExistingRoutes}, c, func(wc writeClient) error {
+		if err := upsertDifferent(existing, update, wc); err != nil {
+			return err
+		}
+
+		rm := mapRoutes(update)
+		return wc.DeleteAllIf(existing, func(r *eskip.Route) bool {
+			_, set := rm[r.Id]
+			return !set
+		})
+	})
+
+	return c, err
+}
+
+const planUpdatedRoutes = `
+	route1: Path("/foo") -> <shunt>;
+	route2: Path("/bar") -> setPath("/") -> <shunt>;
+	route4: Path("/qux") -> <shunt>;`
+
+func TestPlanDryRun(t *testing.T) {
+	var (
+		c   *recordingClient
+		err error
+	)
+
+	output := testPlan("", func() {
+		dryRun = true
+		c, err = testReset(t, planUpdatedRoutes)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.upserted) != 0 || len(c.deleted) != 0 {
+		t.Error("unexpected changes in dry-run mode", c.upserted, c.deleted)
+	}
+
+	expected := `update: route2: Path("/bar") -> setPath("/") -> <shunt>;
+insert: route4: Path("/qux") -> <shunt>;
+delete: route3
+`
+	if output != expected {
+		t.Errorf("invalid output, expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestPlanMaxDeletes(t *testing.T) {
+	var (
+		c   *recordingClient
+		err error
+	)
+
+	testPlan("", func() {
+		maxDeletes = 1
+		c, err = testReset(t, `route1: Path("/foo") -> <shunt>`)
+	})
+
+	if err == nil || !strings.Contains(err.Error(), tooManyDeletes.Error()) {
+		t.Errorf("failed to fail: %v", err)
+	}
+
+	if len(c.upserted) != 0 || len(c.deleted) != 0 {
+		t.Error("unexpected changes", c.upserted, c.deleted)
+	}
+
+	testPlan("", func() {
+		maxDeletes = 1
+		c, err = testReset(t, planUpdatedRoutes)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(c.upserted, ",") != "route2,route4" || strings.Join(c.deleted, ",") != "route3" {
+		t.Error("failed to apply the changes", c.upserted, c.deleted)
+	}
+}
+
+func TestPlanConfirm(t *testing.T) {
+	for _, test := range []struct {
+		answer  string
+		applied bool
+	}{
+		{"y\n", true},
+		{"yes", true},
+		{"n\n", false},
+		{"", false},
+	} {
+		var (
+			c   *recordingClient
+			err error
+		)
+
+		output := testPlan(test.answer, func() {
+			confirmWrite = true
+			c, err = testReset(t, planUpdatedRoutes)
+		})
+
+		if !strings.Contains(output, "delete: route3\nApply the changes? [y/N] ") {
+			t.Errorf("failed to print the changes: %s", output)
+		}
+
+		if test.applied {
+			if err != nil || len(c.upserted) != 2 || len(c.deleted) != 1 {
+				t.Errorf("failed to apply the changes: %v, %v, %v", err, c.upserted, c.deleted)
+			}
+		} else if err != notConfirmed || len(c.upserted) != 0 || len(c.deleted) != 0 {
+			t.Errorf("unexpected changes: %v, %v, %v", err, c.upserted, c.deleted)
+		}
+	}
+}
+
+func TestPlanDisabled(t *testing.T) {
+	var (
+		c   *recordingClient
+		err error
+	)
+
+	output := testPlan("", func() {
+		c, err = testReset(t, planUpdatedRoutes)
+	})
+
+	if err != nil || output != "" {
+		t.Errorf("unexpected result: %v, %s", err, output)
+	}
+
+	if strings.Join(c.upserted, ",") != "route2,route4" || strings.Join(c.deleted, ",") != "route3" {
+		t.Error("failed to apply the changes", c.upserted, c.deleted)
+	}
+}
+
+func TestPlanConfirmInputFails(t *testing.T) {
+	preserveConfirmInput := openConfirmInput
+	defer func() { openConfirmInput = preserveConfirmInput }()
+
+	openConfirmInput = func() (io.ReadCloser, error) { return nil, os.ErrNotExist }
+	if _, err := confirmChanges(); err != os.ErrNotExist {
+		t.Error("failed to fail", err)
+	}
+}
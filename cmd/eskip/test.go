@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/builtin"
+	predicates "github.com/zalando/skipper/predicates/builtin"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+// requestTest is a sample request in the file of the test command, with
+// the optional expectations about the matching route.
+type requestTest struct {
+	Name     string            `json:"name"`
+	Method   string            `json:"method"`
+	Host     string            `json:"host"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers"`
+	SourceIP string            `json:"sourceIP"`
+	Expect   *testExpectation  `json:"expect"`
+}
+
+// testExpectation contains the expected route id, where empty means
+// that no route is expected to match, and the expected path params.
+type testExpectation struct {
+	Route  *string           `json:"route"`
+	Params map[string]string `json:"params"`
+}
+
+type testResult struct {
+	Name   string            `json:"name"`
+	Route  string            `json:"route"`
+	Params map[string]string `json:"params,omitempty"`
+	Passed bool              `json:"passed"`
+	Errors []string          `json:"errors,omitempty"`
+}
+
+const firstLoadTimeout = 3 * time.Second
+
+var (
+	missingRequestsFile = errors.New("missing requests file")
+	testsFailed         = errors.New("one or more tests failed")
+)
+
+func loadRequestTests(path string) ([]*requestTest, error) {
+	if path == "" {
+		return nil, missingRequestsFile
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tests []*requestTest
+	if err := json.Unmarshal(b, &tests); err != nil {
+		return nil, fmt.Errorf("invalid requests file: %v", err)
+	}
+
+	return tests, nil
+}
+
+func (t *requestTest) request() (*http.Request, error) {
+	method, path := t.Method, t.Path
+	if method == "" {
+		method = "GET"
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	u, err := url.ParseRequestURI(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &http.Request{
+		Method:     strings.ToUpper(method),
+		URL:        u,
+		RequestURI: path,
+		Host:       t.Host,
+		Header:     make(http.Header),
+	}
+
+	for k, v := range t.Headers {
+		r.Header.Set(k, v)
+	}
+
+	if t.SourceIP != "" {
+		r.RemoteAddr = net.JoinHostPort(t.SourceIP, "0")
+	}
+
+	return r, nil
+}
+
+func (t *requestTest) check(r *testResult) {
+	if t.Expect == nil {
+		return
+	}
+
+	if t.Expect.Route != nil && *t.Expect.Route != r.Route {
+		if *t.Expect.Route == "" {
+			r.Errors = append(r.Errors, fmt.Sprintf("expected no match, got: %s", r.Route))
+		} else {
+			r.Errors = append(r.Errors, fmt.Sprintf("expected route: %s, got: %s", *t.Expect.Route, routeOrNone(r.Route)))
+		}
+	}
+
+	if t.Expect.Params != nil && !reflect.DeepEqual(t.Expect.Params, r.Params) &&
+		(len(t.Expect.Params) > 0 || len(r.Params) > 0) {
+		r.Errors = append(r.Errors, fmt.Sprintf(
+			"expected params: %s, got: %s",
+			paramsString(t.Expect.Params),
+			paramsString(r.Params),
+		))
+	}
+
+	r.Passed = len(r.Errors) == 0
+}
+
+func routeOrNone(id string) string {
+	if id == "" {
+		return "<none>"
+	}
+
+	return id
+}
+
+func paramsString(p map[string]string) string {
+	var s []string
+	for k, v := range p {
+		s = append(s, k+"="+v)
+	}
+
+	sort.Strings(s)
+	return "{" + strings.Join(s, ", ") + "}"
+}
+
+// builds a routing instance with the default filters and predicates,
+// and waits until the routes are applied
+func createTestRouting(routes []*eskip.Route) (*routing.Routing, error) {
+	// the route table updates are logged on info level
+	log.SetLevel(log.WarnLevel)
+
+	rt := routing.New(routing.Options{
+		FilterRegistry:  builtin.MakeRegistry(),
+		DataClients:     []routing.DataClient{testdataclient.New(routes)},
+		Predicates:      predicates.Specs(),
+		SignalFirstLoad: true,
+	})
+
+	select {
+	case <-rt.FirstLoad():
+		return rt, nil
+	case <-time.After(firstLoadTimeout):
+		rt.Close()
+		return nil, errors.New("timeout while loading the routes")
+	}
+}
+
+func runRequestTests(rt *routing.Routing, tests []*requestTest) ([]*testResult, error) {
+	results := make([]*testResult, len(tests))
+	for i, t := range tests {
+		req, err := t.request()
+		if err != nil {
+			return nil, fmt.Errorf("invalid request in test %s: %v", t.Name, err)
+		}
+
+		r := &testResult{Name: t.Name, Passed: true}
+		if t.Name == "" {
+			r.Name = fmt.Sprintf("#%d", i+1)
+		}
+
+		if route, params := rt.Route(req); route != nil {
+			r.Route = route.Id
+			if len(params) > 0 {
+				r.Params = params
+			}
+		}
+
+		t.check(r)
+		results[i] = r
+	}
+
+	return results, nil
+}
+
+func printTestResults(results []*testResult) error {
+	if printJson {
+		e := json.NewEncoder(stdout)
+		e.SetEscapeHTML(false)
+		return e.Encode(results)
+	}
+
+	for _, r := range results {
+		status := "ok  "
+		if !r.Passed {
+			status = "FAIL"
+		}
+
+		fmt.Fprintf(stdout, "%s %s: %s", status, r.Name, routeOrNone(r.Route))
+		if len(r.Params) > 0 {
+			fmt.Fprintf(stdout, " %s", paramsString(r.Params))
+		}
+
+		fmt.Fprintln(stdout)
+		for _, e := range r.Errors {
+			fmt.Fprintf(stdout, "     %s\n", e)
+		}
+	}
+
+	return nil
+}
+
+// command executed for test.
+func testCmd(a cmdArgs) error {
+	tests, err := loadRequestTests(requestsFile)
+	if err != nil {
+		return err
+	}
+
+	routes, err := loadRoutesChecked(a.in)
+	if err != nil {
+		return err
+	}
+
+	var invalid bool
+	for _, d := range validateRoutes(routes) {
+		if d.Severity == severityError {
+			printStderr(d.RouteID, d.Message)
+			invalid = true
+		}
+	}
+
+	if invalid {
+		return invalidRouteExpression
+	}
+
+	rt, err := createTestRouting(routes)
+	if err != nil {
+		return err
+	}
+
+	defer rt.Close()
+
+	results, err := runRequestTests(rt, tests)
+	if err != nil {
+		return err
+	}
+
+	if err := printTestResults(results); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if !r.Passed {
+			return testsFailed
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testRoutes = `
+	users: Path("/users/:id") && Method("GET") -> "https://users.example.org";
+	internal: Path("/users/:id") && Source("10.0.0.0/8") -> "https://internal.example.org";
+	api: Host(/^api[.]example[.]org$/) && PathSubtree("/") && Header("Accept", "application/json") -> "https://api.example.org";
+	catchAll: * -> <shunt>;`
+
+func withRequestsFile(t *testing.T, requests string, f func()) {
+	dir, err := ioutil.TempDir("", "eskip-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "requests.json")
+	if err := ioutil.WriteFile(path, []byte(requests), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	requestsFile = path
+	defer func() { requestsFile = "" }()
+	f()
+}
+
+func runTestCmd(t *testing.T, routes, requests string, jsonOutput bool) (output string, err error) {
+	withRequestsFile(t, requests, func() {
+		var buf bytes.Buffer
+		stdout, printJson = &buf, jsonOutput
+		defer func() { stdout, printJson = os.Stdout, false }()
+
+		err = testCmd(cmdArgs{in: &medium{typ: inline, eskip: routes}})
+		output = buf.String()
+	})
+
+	return
+}
+
+func TestTestCmdPasses(t *testing.T) {
+	output, err := runTestCmd(t, testRoutes, `[{
+		"name": "user",
+		"path": "/users/42",
+		"expect": {"route": "users", "params": {"id": "42"}}
+	}, {
+		"name": "internal user",
+		"method": "post",
+		"path": "/users/42",
+		"sourceIP": "10.0.0.1",
+		"expect": {"route": "internal", "params": {"id": "42"}}
+	}, {
+		"name": "api",
+		"host": "api.example.org",
+		"path": "/foo?bar=baz",
+		"headers": {"Accept": "application/json"},
+		"expect": {"route": "api"}
+	}, {
+		"path": "/foo"
+	}]`, false)
+
+	if err != nil {
+		t.Fatal(err, output)
+	}
+
+	expected := `ok   user: users {id=42}
+ok   internal user: internal {id=42}
+ok   api: api {*=/foo}
+ok   #4: catchAll
+`
+	if output != expected {
+		t.Errorf("invalid output, expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestTestCmdFails(t *testing.T) {
+	output, err := runTestCmd(t, testRoutes, `[{
+		"name": "wrong route",
+		"path": "/users/42",
+		"method": "POST",
+		"expect": {"route": "users", "params": {"id": "43"}}
+	}, {
+		"name": "no match",
+		"path": "/foo",
+		"expect": {"route": ""}
+	}]`, true)
+
+	if err != testsFailed {
+		t.Errorf("failed to fail: %v", err)
+	}
+
+	var results []*testResult
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 ||
+		results[0].Passed || results[0].Route != "catchAll" || len(results[0].Errors) != 2 ||
+		results[1].Passed || results[1].Errors[0] != "expected no match, got: catchAll" {
+		t.Errorf("invalid results: %s", output)
+	}
+}
+
+func TestTestCmdInvalid(t *testing.T) {
+	if _, err := runTestCmd(t, `route1: * -> noSuchFilter() -> <shunt>`, `[]`, false); err != invalidRouteExpression {
+		t.Error("failed to fail on invalid routes", err)
+	}
+
+	if _, err := runTestCmd(t, testRoutes, `{"path": "/"}`, false); err == nil {
+		t.Error("failed to fail on invalid requests file")
+	}
+
+	requestsFile = ""
+	if err := testCmd(cmdArgs{in: &medium{typ: inline, eskip: testRoutes}}); err != missingRequestsFile {
+		t.Error("failed to fail on missing requests file", err)
+	}
+}
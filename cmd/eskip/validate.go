@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/eskip/validation"
+)
+
+type severity string
+
+const (
+	severityError   severity = "error"
+	severityWarning severity = "warning"
+)
+
+// diagnostic is a single finding of the validation. Line and Column
+// are 1 based, and 0 when the position is not known, e.g. when the
+// routes were loaded from etcd.
+type diagnostic struct {
+	Severity severity `json:"severity"`
+	RouteID  string   `json:"routeId,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func parseErrorDiagnostic(err error) diagnostic {
+	d := diagnostic{Severity: severityError, Message: err.Error()}
+	if perr, ok := err.(*eskip.ParseError); ok {
+		d.RouteID = perr.RouteID
+		d.Line, d.Column = perr.Position.Line, perr.Position.Column
+	}
+
+	return d
+}
+
+// validates the routes, and reports the findings at the start of the
+// routes, when the source positions are known
+func validateRoutes(routes []*eskip.Route) []diagnostic {
+	var diags []diagnostic
+	for _, f := range validation.New().Validate(routes) {
+		d := diagnostic{Severity: severityError, RouteID: f.Route.Id, Message: f.Message}
+		if f.Severity == validation.Warning {
+			d.Severity = severityWarning
+		}
+
+		if f.Route.Source != nil {
+			d.Line, d.Column = f.Route.Source.Span.Start.Line, f.Route.Source.Span.Start.Column
+		}
+
+		diags = append(diags, d)
+	}
+
+	return diags
+}
+
+// reads the raw document from the input media that have one, in order
+// to report the positions of the findings
+func readDocument(in *medium) (string, bool, error) {
+	switch in.typ {
+	case file:
+		b, err := ioutil.ReadFile(in.path)
+		return string(b), true, err
+	case stdin:
+		b, err := ioutil.ReadAll(os.Stdin)
+		return string(b), true, err
+	case inline:
+		return in.eskip, true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+func printDiagnostics(in *medium, diags []diagnostic) error {
+	if printJson {
+		if diags == nil {
+			diags = []diagnostic{}
+		}
+
+		e := json.NewEncoder(stdout)
+		e.SetEscapeHTML(false)
+		return e.Encode(diags)
+	}
+
+	for _, d := range diags {
+		var prefix []string
+		if in.typ == file {
+			prefix = append(prefix, in.path)
+		}
+
+		if d.Line > 0 {
+			prefix = append(prefix, strconv.Itoa(d.Line), strconv.Itoa(d.Column))
+		}
+
+		prefix = append(prefix, string(d.Severity))
+		if d.RouteID != "" {
+			prefix = append(prefix, d.RouteID)
+		}
+
+		fmt.Fprintf(stdout, "%s: %s\n", strings.Join(prefix, ":"), d.Message)
+	}
+
+	return nil
+}
+
+// semantic validation of the routes, executed for check when the
+// -validate flag is set.
+func validateCmd(a cmdArgs) error {
+	doc, hasDoc, err := readDocument(a.in)
+	if err != nil {
+		return err
+	}
+
+	var (
+		routes []*eskip.Route
+		diags  []diagnostic
+	)
+
+	if hasDoc {
+		routes, err = eskip.ParseWithSource(doc)
+		if err != nil {
+			diags = append(diags, parseErrorDiagnostic(err))
+		}
+	} else {
+		lr, err := loadRoutes(a.in)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range lr.routes {
+			if perr, ok := lr.parseErrors[r.Id]; ok {
+				diags = append(diags, diagnostic{Severity: severityError, RouteID: r.Id, Message: perr.Error()})
+				continue
+			}
+
+			routes = append(routes, r)
+		}
+	}
+
+	diags = append(diags, validateRoutes(routes)...)
+	if err := printDiagnostics(a.in, diags); err != nil {
+		return err
+	}
+
+	for _, d := range diags {
+		if d.Severity == severityError {
+			return invalidRouteExpression
+		}
+	}
+
+	return nil
+}
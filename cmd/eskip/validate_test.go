@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testValidate(t *testing.T, doc string, jsonOutput bool) (string, error) {
+	var buf bytes.Buffer
+	stdout, printJson = &buf, jsonOutput
+	defer func() { stdout, printJson = os.Stdout, false }()
+
+	err := validateCmd(cmdArgs{in: &medium{typ: inline, eskip: doc}})
+	return buf.String(), err
+}
+
+func TestValidateValid(t *testing.T) {
+	output, err := testValidate(t, `
+		// health check
+		health: Path("/health") -> status(204) -> <shunt>;
+		api: PathSubtree("/api") && Traffic(0.3) -> setRequestHeader("X-Foo", "bar") -> "https://api.example.org";
+		loop: Host(/^www[.]example[.]org$/) -> setPath("/") -> <loopback>;
+		weighted: Path("/ready") && Weight(2) -> <shunt>;
+		beta: Or(Not(Host(/^www[.]/)), And(Header("X-Beta", "true"), Traffic(0.1))) -> <shunt>;
+	`, false)
+
+	if err != nil || output != "" {
+		t.Errorf("unexpected findings: %v, %s", err, output)
+	}
+}
+
+func TestValidateDiagnostics(t *testing.T) {
+	output, err := testValidate(t, `route1: Path("/foo") -> setPath() -> "https://www.example.org";
+route2: Cookie("foo") && PathRegexp(/(foo/) -> "https://www.example.org";
+
+route3: Path("/bar") -> noSuchFilter() -> "www.example.org";
+route4: Path("/foo") -> "https://other.example.org";
+route5: Unknown("foo") -> <shunt>;
+route6: Not(Path("/foo"), Unknown()) -> <shunt>;
+`, true)
+
+	if err != invalidRouteExpression {
+		t.Errorf("failed to fail: %v", err)
+	}
+
+	var diags []diagnostic
+	if err := json.Unmarshal([]byte(output), &diags); err != nil {
+		t.Fatal(err)
+	}
+
+	type finding struct {
+		severity severity
+		id       string
+		line     int
+		column   int
+		message  string
+	}
+
+	var got []finding
+	for _, d := range diags {
+		got = append(got, finding{d.Severity, d.RouteID, d.Line, d.Column, strings.SplitN(d.Message, ":", 2)[0]})
+	}
+
+	expected := []finding{
+		{severityError, "route1", 1, 1, "invalid filter setPath"},
+		{severityError, "route2", 2, 1, "invalid predicate Cookie"},
+		{severityError, "route2", 2, 1, "invalid regular expression"},
+		{severityError, "route3", 4, 1, "unknown filter"},
+		{severityError, "route3", 4, 1, "invalid backend address"},
+		{severityError, "route5", 6, 1, "unknown predicate"},
+		{severityError, "route6", 7, 1, "invalid predicate Not"},
+		{severityWarning, "route4", 5, 1, "route is shadowed by route1, it has the same conditions"},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected diagnostics, expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestValidateWeight(t *testing.T) {
+	output, err := testValidate(t, `route1: Path("/foo") -> <shunt>;
+route2: Path("/foo") && Weight(2) -> <shunt>;
+route3: Weight(1.5) && Weight(1) -> <shunt>;
+`, false)
+
+	if err != invalidRouteExpression {
+		t.Errorf("failed to fail: %v", err)
+	}
+
+	for _, expected := range []string{
+		"3:1:error:route3: multiple Weight predicates",
+		"3:1:error:route3: invalid predicate Weight",
+		"1:1:warning:route1: route is shadowed by route2",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("missing finding: %s, got: %s", expected, output)
+		}
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	output, err := testValidate(t, "route1: Path(\"/foo\") -> <shunt>;\nroute2: Path(\"/bar\") -> ", false)
+	if err != invalidRouteExpression {
+		t.Errorf("failed to fail: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "2:") || !strings.Contains(output, "error: parse failed") {
+		t.Errorf("unexpected output: %s", output)
+	}
+}
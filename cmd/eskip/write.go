@@ -70,7 +70,9 @@ func upsertCmd(a cmdArgs) error {
 		return err
 	}
 
-	return wc.UpsertAll(routes)
+	return applyChanges(a.out, wc, func(wc writeClient) error {
+		return wc.UpsertAll(routes)
+	})
 }
 
 // command executed for reset.
@@ -84,24 +86,27 @@ func resetCmd(a cmdArgs) error {
 	// take existing routes from output:
 	existing := loadRoutesUnchecked(a.out)
 
-	// upsert routes that don't exist or are different:
 	wc, err := createWriteClient(a.out)
 	if err != nil {
 		return err
 	}
-	err = upsertDifferent(existing, routes, wc)
-	if err != nil {
-		return err
-	}
 
-	// delete routes from existing that were not upserted:
-	rm := mapRoutes(routes)
-	notSet := func(r *eskip.Route) bool {
-		_, set := rm[r.Id]
-		return !set
-	}
+	return applyChanges(a.out, wc, func(wc writeClient) error {
+		// upsert routes that don't exist or are different:
+		err := upsertDifferent(existing, routes, wc)
+		if err != nil {
+			return err
+		}
+
+		// delete routes from existing that were not upserted:
+		rm := mapRoutes(routes)
+		notSet := func(r *eskip.Route) bool {
+			_, set := rm[r.Id]
+			return !set
+		}
 
-	return wc.DeleteAllIf(existing, notSet)
+		return wc.DeleteAllIf(existing, notSet)
+	})
 }
 
 // command executed for delete.
@@ -117,5 +122,8 @@ func deleteCmd(a cmdArgs) error {
 	if err != nil {
 		return err
 	}
-	return wc.DeleteAllIf(routes, any)
+
+	return applyChanges(a.out, wc, func(wc writeClient) error {
+		return wc.DeleteAllIf(routes, any)
+	})
 }
@@ -25,6 +25,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/zalando/skipper"
+	"github.com/zalando/skipper/loadbalancer"
+	"github.com/zalando/skipper/metrics"
 	"github.com/zalando/skipper/proxy"
 )
 
@@ -50,6 +52,8 @@ const (
 	defaultTLSHandshakeTimeoutBackend      = 60 * time.Second
 	defaultMaxIdleConnsBackend             = 0
 	defaultLoadBalancerHealthCheckInterval = 0 // disabled
+	defaultRouteHistory                    = 10
+	defaultFilterCloseDelay                = time.Minute
 
 	addressUsage                   = "network address that skipper should listen on"
 	etcdUrlsUsage                  = "urls of nodes in an etcd cluster, storing route definitions"
@@ -91,6 +95,9 @@ const (
 	routeStreamErrorCountersUsage  = "enables counting streaming errors for each route"
 	routeBackendMetricsUsage       = "enables reporting backend response time metrics for each route"
 	metricsUseExpDecaySampleUsage  = "use exponentially decaying sample in metrics"
+	labelledRequestMetricsUsage    = "enables reporting serve and backend response time metrics labelled with route, host, method, code and backend, only for prometheus"
+	metricsHostLabelLimitUsage     = "maximum number of distinct incoming and backend hosts in the prometheus metrics, additional hosts are reported as _unknownhost_, the hosts of the routes are always kept, -1 disables the limit"
+	routeAnnotationLabelsUsage     = "comma separated list of route annotations, e.g. owner, whose values are added as labels to the labelled request metrics"
 	disableMetricsCompatsUsage     = "disables the default true value for all-filters-metrics, route-response-metrics, route-backend-errorCounters and route-stream-error-counters"
 	applicationLogUsage            = "output file for the application log. When not set, /dev/stderr is used"
 	applicationLogLevelUsage       = "log level for application logs, possible values: PANIC, FATAL, ERROR, WARN, INFO, DEBUG"
@@ -98,6 +105,9 @@ const (
 	accessLogUsage                 = "output file for the access log, When not set, /dev/stderr is used"
 	accessLogDisabledUsage         = "when this flag is set, no access log is printed"
 	accessLogJSONEnabledUsage      = "when this flag is set, log in JSON format is used"
+	accessLogFormatUsage           = "format of the access log entries, fields are referenced as ${field-name}, e.g. ${route-id}, ${request-header:User-Agent}"
+	accessLogJSONFieldsUsage       = "comma separated list of the fields of the access log entries, when the JSON format is used"
+	accessLogSampleRatioUsage      = "ratio of the requests, between 0 and 1, whose access log entries are written. With 0, only the entries of the routes with the enableAccessLog filter are written"
 	debugEndpointUsage             = "when this address is set, skipper starts an additional listener returning the original and transformed requests"
 	certPathTLSUsage               = "the path on the local filesystem to the certificate file (including any intermediates)"
 	keyPathTLSUsage                = "the path on the local filesystem to the certificate's private key file"
@@ -109,6 +119,11 @@ const (
 	defaultHTTPStatusUsage         = "default HTTP status used when no route is found for a request"
 	pluginDirUsage                 = "set the directory to load plugins from, default is ./"
 	suppressRouteUpdateLogsUsage   = "print only summaries on route updates/deletes"
+	maxRouteDropUsage              = "rejects the routing updates removing more valid routes than this number, keeping the previous routing table. Zero means no limit"
+	maxRouteDropRatioUsage         = "rejects the routing updates removing a higher ratio (0-1) of the valid routes. Zero means no limit"
+	maxInvalidRouteRatioUsage      = "rejects the routing updates with a higher ratio (0-1) of invalid routes. Zero means no limit"
+	routeHistoryUsage              = "number of the last routing tables kept for the history, diff and rollback support endpoints"
+	filterCloseDelayUsage          = "time to wait for the in-flight requests before releasing the resources of the filters of removed or replaced routes, once their routing table dropped out of the route history"
 	enablePrometheusMetricsUsage   = "siwtch to Prometheus metrics format to expose metrics. *Deprecated*: use metrics-flavour"
 
 	loadBalancerHealthCheckIntervalUsage = "use to set the health checker interval to check healthiness of former dead or unhealthy routes"
@@ -125,6 +140,9 @@ const (
 	tlsHandshakeTimeoutBackendUsage      = "sets the TLS handshake timeout for backend connections"
 	maxIdleConnsBackendUsage             = "sets the maximum idle connections for all backend connections"
 	enableHopHeadersRemovalUsage         = "enables removal of Hop-Headers according to RFC-2616"
+	stickySessionSecretUsage             = "enables session affinity for load balanced routes, using the secret to sign the affinity cookies"
+	stickySessionCookieNameUsage         = "name prefix of the session affinity cookies"
+	stickySessionMaxAgeUsage             = "max age of the session affinity cookies, session cookies are used when not set"
 )
 
 var (
@@ -172,12 +190,19 @@ var (
 	routeBackendMetrics             bool
 	metricsUseExpDecaySample        bool
 	disableMetricsCompat            bool
+	labelledRequestMetrics          bool
+	histogramMetricBuckets          bucketFlags
+	metricsHostLabelLimit           int
+	routeAnnotationLabels           string
 	applicationLog                  string
 	applicationLogLevel             string
 	applicationLogPrefix            string
 	accessLog                       string
 	accessLogDisabled               bool
 	accessLogJSONEnabled            bool
+	accessLogFormat                 string
+	accessLogJSONFields             string
+	accessLogSampleRatio            float64
 	debugListener                   string
 	certPathTLS                     string
 	keyPathTLS                      string
@@ -193,6 +218,11 @@ var (
 	defaultHTTPStatus               int
 	pluginDir                       string
 	suppressRouteUpdateLogs         bool
+	maxRouteDrop                    int
+	maxRouteDropRatio               float64
+	maxInvalidRouteRatio            float64
+	routeHistory                    int
+	filterCloseDelay                time.Duration
 	enablePrometheusMetrics         bool
 	metricsFlavour                  metricsFlags
 	loadBalancerHealthCheckInterval time.Duration
@@ -211,6 +241,9 @@ var (
 	filterPlugins                   pluginFlags
 	predicatePlugins                pluginFlags
 	dataclientPlugins               pluginFlags
+	stickySessionSecret             string
+	stickySessionCookieName         string
+	stickySessionMaxAge             time.Duration
 )
 
 func init() {
@@ -256,12 +289,19 @@ func init() {
 	flag.BoolVar(&routeBackendMetrics, "route-backend-metrics", false, routeBackendMetricsUsage)
 	flag.BoolVar(&metricsUseExpDecaySample, "metrics-exp-decay-sample", false, metricsUseExpDecaySampleUsage)
 	flag.BoolVar(&disableMetricsCompat, "disable-metrics-compat", false, disableMetricsCompatsUsage)
+	flag.BoolVar(&labelledRequestMetrics, "labelled-request-metrics", false, labelledRequestMetricsUsage)
+	flag.Var(&histogramMetricBuckets, "histogram-metric-buckets", histogramMetricBucketsUsage)
+	flag.IntVar(&metricsHostLabelLimit, "metrics-host-label-limit", metrics.DefaultHostLabelLimit, metricsHostLabelLimitUsage)
+	flag.StringVar(&routeAnnotationLabels, "route-annotation-labels", "", routeAnnotationLabelsUsage)
 	flag.StringVar(&applicationLog, "application-log", "", applicationLogUsage)
 	flag.StringVar(&applicationLogLevel, "application-log-level", defaultApplicationLogLevel, applicationLogLevelUsage)
 	flag.StringVar(&applicationLogPrefix, "application-log-prefix", defaultApplicationLogPrefix, applicationLogPrefixUsage)
 	flag.StringVar(&accessLog, "access-log", "", accessLogUsage)
 	flag.BoolVar(&accessLogDisabled, "access-log-disabled", false, accessLogDisabledUsage)
 	flag.BoolVar(&accessLogJSONEnabled, "access-log-json-enabled", false, accessLogJSONEnabledUsage)
+	flag.StringVar(&accessLogFormat, "access-log-format", "", accessLogFormatUsage)
+	flag.StringVar(&accessLogJSONFields, "access-log-json-fields", "", accessLogJSONFieldsUsage)
+	flag.Float64Var(&accessLogSampleRatio, "access-log-sample-ratio", 1, accessLogSampleRatioUsage)
 	flag.StringVar(&debugListener, "debug-listener", "", debugEndpointUsage)
 	flag.StringVar(&certPathTLS, "tls-cert", "", certPathTLSUsage)
 	flag.StringVar(&keyPathTLS, "tls-key", "", keyPathTLSUsage)
@@ -277,6 +317,11 @@ func init() {
 	flag.StringVar(&pluginDir, "plugindir", "", pluginDirUsage)
 	flag.IntVar(&defaultHTTPStatus, "default-http-status", http.StatusNotFound, defaultHTTPStatusUsage)
 	flag.BoolVar(&suppressRouteUpdateLogs, "suppress-route-update-logs", false, suppressRouteUpdateLogsUsage)
+	flag.IntVar(&maxRouteDrop, "max-route-drop", 0, maxRouteDropUsage)
+	flag.Float64Var(&maxRouteDropRatio, "max-route-drop-ratio", 0, maxRouteDropRatioUsage)
+	flag.Float64Var(&maxInvalidRouteRatio, "max-invalid-route-ratio", 0, maxInvalidRouteRatioUsage)
+	flag.IntVar(&routeHistory, "route-history", defaultRouteHistory, routeHistoryUsage)
+	flag.DurationVar(&filterCloseDelay, "filter-close-delay", defaultFilterCloseDelay, filterCloseDelayUsage)
 	flag.BoolVar(&enablePrometheusMetrics, "enable-prometheus-metrics", false, enablePrometheusMetricsUsage)
 	flag.Var(&metricsFlavour, "metrics-flavour", metricsFlavourUsage)
 	flag.DurationVar(&loadBalancerHealthCheckInterval, "lb-healthcheck-interval", defaultLoadBalancerHealthCheckInterval, loadBalancerHealthCheckIntervalUsage)
@@ -295,6 +340,9 @@ func init() {
 	flag.Var(&filterPlugins, "filter-plugin", filterPluginUsage)
 	flag.Var(&predicatePlugins, "predicate-plugin", predicatePluginUsage)
 	flag.Var(&dataclientPlugins, "dataclient-plugin", dataclientPluginUsage)
+	flag.StringVar(&stickySessionSecret, "sticky-session-secret", "", stickySessionSecretUsage)
+	flag.StringVar(&stickySessionCookieName, "sticky-session-cookie-name", loadbalancer.DefaultStickyCookieName, stickySessionCookieNameUsage)
+	flag.DurationVar(&stickySessionMaxAge, "sticky-session-max-age", 0, stickySessionMaxAgeUsage)
 
 	flag.Parse()
 
@@ -339,6 +387,16 @@ func main() {
 		eus = strings.Split(etcdUrls, ",")
 	}
 
+	var jsonFields []string
+	if len(accessLogJSONFields) > 0 {
+		jsonFields = strings.Split(accessLogJSONFields, ",")
+	}
+
+	var annotationLabels []string
+	if len(routeAnnotationLabels) > 0 {
+		annotationLabels = strings.Split(routeAnnotationLabels, ",")
+	}
+
 	clsic, err := parseDurationFlag(closeIdleConnsPeriod)
 	if err != nil {
 		flag.PrintDefaults()
@@ -386,11 +444,19 @@ func main() {
 		EnableRouteBackendMetrics:           routeBackendMetrics,
 		MetricsUseExpDecaySample:            metricsUseExpDecaySample,
 		DisableMetricsCompatibilityDefaults: disableMetricsCompat,
+		EnableLabelledRequestMetrics:        labelledRequestMetrics,
+		HistogramMetricBuckets:              histogramMetricBuckets.Get(),
+		MetricsHostLabelLimit:               metricsHostLabelLimit,
+		MetricsRouteAnnotationLabels:        annotationLabels,
 		ApplicationLogOutput:                applicationLog,
 		ApplicationLogPrefix:                applicationLogPrefix,
 		AccessLogOutput:                     accessLog,
 		AccessLogDisabled:                   accessLogDisabled,
 		AccessLogJSONEnabled:                accessLogJSONEnabled,
+		AccessLogFormat:                     accessLogFormat,
+		AccessLogJSONFields:                 jsonFields,
+		AccessLogSampling:                   accessLogSampleRatio < 1,
+		AccessLogSampleRatio:                accessLogSampleRatio,
 		DebugListener:                       debugListener,
 		CertPathTLS:                         certPathTLS,
 		KeyPathTLS:                          keyPathTLS,
@@ -405,6 +471,11 @@ func main() {
 		PluginDirs:                          []string{skipper.DefaultPluginDir},
 		DefaultHTTPStatus:                   defaultHTTPStatus,
 		SuppressRouteUpdateLogs:             suppressRouteUpdateLogs,
+		MaxRouteDrop:                        maxRouteDrop,
+		MaxRouteDropRatio:                   maxRouteDropRatio,
+		MaxInvalidRouteRatio:                maxInvalidRouteRatio,
+		RouteHistory:                        routeHistory,
+		FilterCloseDelay:                    filterCloseDelay,
 		EnablePrometheusMetrics:             enablePrometheusMetrics,
 		MetricsFlavours:                     metricsFlavour.Get(),
 		LoadBalancerHealthCheckInterval:     loadBalancerHealthCheckInterval,
@@ -418,6 +489,9 @@ func main() {
 		FilterPlugins:                       filterPlugins.Get(),
 		PredicatePlugins:                    predicatePlugins.Get(),
 		DataClientPlugins:                   dataclientPlugins.Get(),
+		StickySessionSecret:                 stickySessionSecret,
+		StickySessionCookieName:             stickySessionCookieName,
+		StickySessionMaxAge:                 stickySessionMaxAge,
 	}
 
 	if pluginDir != "" {
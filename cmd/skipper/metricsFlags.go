@@ -2,17 +2,27 @@ package main
 
 import (
 	"errors"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-const metricsFlavourUsage = "Metrics flavour is used to change the exposed metrics format. Supported metric formats: 'codahale' and 'prometheus', you can select both of them"
+const (
+	metricsFlavourUsage         = "Metrics flavour is used to change the exposed metrics format. Supported metric formats: 'codahale' and 'prometheus', you can select both of them"
+	histogramMetricBucketsUsage = "comma separated list of the upper bounds of the prometheus histogram buckets, in seconds"
+)
 
 type metricsFlags struct {
 	values []string
 }
 
+type bucketFlags struct {
+	values []float64
+}
+
 var (
 	errInvalidMetricsFlag = errors.New("invalid metrics flavour, valid ones are 'codahale' and 'prometheus'")
+	errInvalidBucketsFlag = errors.New("invalid histogram buckets, expected a comma separated list of positive numbers")
 	allowed               = map[string]bool{
 		"codahale":   true,
 		"prometheus": true,
@@ -40,3 +50,31 @@ func (m *metricsFlags) Set(value string) error {
 func (m *metricsFlags) Get() []string {
 	return m.values
 }
+
+func (b *bucketFlags) String() string {
+	s := make([]string, len(b.values))
+	for i, v := range b.values {
+		s[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return strings.Join(s, ",")
+}
+
+func (b *bucketFlags) Set(value string) error {
+	b.values = nil
+	for _, s := range strings.Split(value, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || v <= 0 {
+			return errInvalidBucketsFlag
+		}
+
+		b.values = append(b.values, v)
+	}
+
+	sort.Float64s(b.values)
+	return nil
+}
+
+func (b *bucketFlags) Get() []float64 {
+	return b.values
+}
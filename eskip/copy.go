@@ -0,0 +1,87 @@
+package eskip
+
+func copyArgs(a []interface{}) []interface{} {
+	if a == nil {
+		return nil
+	}
+
+	c := make([]interface{}, len(a))
+	for i, ai := range a {
+		if p, ok := ai.(*Predicate); ok {
+			ai = copyPredicate(p)
+		}
+
+		c[i] = ai
+	}
+
+	return c
+}
+
+func copyPredicate(p *Predicate) *Predicate {
+	if p == nil {
+		return nil
+	}
+
+	return &Predicate{Name: p.Name, Args: copyArgs(p.Args)}
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+
+	return append([]string(nil), s...)
+}
+
+// Copy creates a deep copy of a route definition. The predicates and
+// the filters are copied together with their args, except for the
+// args that are not predicates, which are shared. The source positions
+// are shared, too.
+func Copy(r *Route) *Route {
+	if r == nil {
+		return nil
+	}
+
+	c := *r
+	c.HostRegexps = copyStrings(r.HostRegexps)
+	c.PathRegexps = copyStrings(r.PathRegexps)
+
+	if r.Headers != nil {
+		c.Headers = make(map[string]string, len(r.Headers))
+		for k, v := range r.Headers {
+			c.Headers[k] = v
+		}
+	}
+
+	if r.HeaderRegexps != nil {
+		c.HeaderRegexps = make(map[string][]string, len(r.HeaderRegexps))
+		for k, v := range r.HeaderRegexps {
+			c.HeaderRegexps[k] = copyStrings(v)
+		}
+	}
+
+	if r.Predicates != nil {
+		c.Predicates = make([]*Predicate, len(r.Predicates))
+		for i, p := range r.Predicates {
+			c.Predicates[i] = copyPredicate(p)
+		}
+	}
+
+	if r.Filters != nil {
+		c.Filters = make([]*Filter, len(r.Filters))
+		for i, f := range r.Filters {
+			if f != nil {
+				c.Filters[i] = &Filter{Name: f.Name, Args: copyArgs(f.Args)}
+			}
+		}
+	}
+
+	if r.Annotations != nil {
+		c.Annotations = make(map[string]string, len(r.Annotations))
+		for k, v := range r.Annotations {
+			c.Annotations[k] = v
+		}
+	}
+
+	return &c
+}
@@ -0,0 +1,24 @@
+package eskip
+
+import "testing"
+
+func TestCopy(t *testing.T) {
+	r, err := Parse(`route1: Path("/foo") && Host(/^www/) && Header("X-Foo", "bar") && Not(Traffic(.3)) -> setPath("/bar") -> "https://www.example.org"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Copy(r[0])
+	if !Eq(r[0], c) {
+		t.Fatal("copy is not equal")
+	}
+
+	c.HostRegexps[0] = "^api"
+	c.Headers["X-Foo"] = "baz"
+	c.Predicates[0].Args[0].(*Predicate).Args[0] = .5
+	c.Filters[0].Args[0] = "/baz"
+
+	if r[0].String() != `Path("/foo") && Host(/^www/) && Header("X-Foo", "bar") && Not(Traffic(0.3)) -> setPath("/bar") -> "https://www.example.org"` {
+		t.Errorf("the original route was modified: %s", r[0].String())
+	}
+}
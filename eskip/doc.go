@@ -76,6 +76,11 @@ expression can be surrounded by '/' or '"'.
 The host predicate accepts a regular expression as a single argument
 that needs to be matched by the host header in the request.
 
+The named capture groups of the PathRegexp and Host predicates are
+provided as path parameters, e.g. "user" in:
+
+	PathRegexp(/^\/users\/(?P<user>[^\/]+)/)
+
 	Method("HEAD")
 
 The method predicate is used to match the http request method.
@@ -99,6 +104,30 @@ Catch all predicate.
 
 Former, deprecated form of the catch all predicate.
 
+	Weight(2)
+
+The weight predicate doesn't affect whether a request matches the route,
+but it adds to the priority of the route among the routes matching the
+same path. By default, the routes with more conditions are evaluated
+first. The argument needs to be an integer number.
+
+	Not(Host(/^www[.]/))
+
+The not predicate negates the predicate passed to it as its single
+argument.
+
+	Or(Header("X-Beta", "true"), Cookie("beta", /^true$/))
+
+The or predicate matches when any of the predicates passed to it as
+arguments match.
+
+	Or(And(Method("POST"), PathRegexp(/^\/api/)), Header("X-Test", "on"))
+
+The and predicate matches when all of its nested predicates match, and it
+can be used to group predicates in Or or Not. The nested predicates can
+be any predicate, except for Path and PathSubtree, and they can be
+nested further.
+
 
 Custom Predicates
 
@@ -232,6 +261,47 @@ Example with comments:
 	route2: * -> <shunt> // everything else 404
 
 
+Annotations
+
+A route expression can start with annotations, containing metadata
+about the route, e.g. its owner or a description. They don't change the
+routing, but they are preserved by the parser and when the routes are
+printed, they are listed together with the routes, and they can be used
+as labels of the metrics and the access logs. An annotation accepts a
+single string argument, or no arguments. The same annotation can appear
+only once in a route.
+
+Example with annotations:
+
+	route1: @owner("team-x") @ticket("ABC-1") Path("/api") -> "https://api.example.org";
+
+
+Macros
+
+A routing document can define macros, to avoid repeating the same
+filter chains or similar routes. A macro definition contains the name of
+the macro, its parameters, and, after ':=', either a filter chain or a
+route expression without id. The macros are expanded during parsing,
+and the parameters are substituted in the string arguments and the
+backend address, using the same placeholder syntax as Template. When an
+argument contains only a placeholder, the argument of the macro is used
+with its original type, e.g. as a number. The placeholders that don't
+refer to a parameter are left unchanged.
+
+A filter chain is referenced like a filter, while a route template is
+referenced in place of the route expression:
+
+	authChain(scope) := oauthTokeninfoAnyScope("${scope}") -> flowId("reuse");
+	api(path, backend) := Path("${path}") -> authChain("read") -> "${backend}";
+
+	users: api("/users", "https://users.example.org");
+	orders: Method("POST") && Path("/orders") -> authChain("write") -> "https://orders.example.org";
+
+The macros can be defined before or after they are used. Referencing an
+undefined route template, or recursive filter chains, result in a parse
+error.
+
+
 Regular expressions
 
 The matching predicates and the built-in filters that use regular
@@ -253,6 +323,16 @@ eskip.Parse function. In case of grammar error, it returns an error with
 the approximate position of the invalid syntax element, otherwise it
 returns a list of structured, in-memory route definitions.
 
+The returned errors are of type *eskip.ParseError, containing the line
+and column of the error, the unexpected token and the expected ones, or,
+when the syntax is valid but a route definition is not, the id of the
+invalid route.
+
+Tooling, like editors or linters, can use the eskip.ParseWithSource
+function, that sets the Source field of the parsed routes. It contains
+the positions of the routes, their ids, predicates, filters, arguments
+and backends in the parsed document.
+
 The eskip parser does not validate the routes against all semantic rules,
 e.g., whether a filter or a custom predicate implementation is available.
 This validation happens during processing the parsed definitions.
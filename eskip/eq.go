@@ -0,0 +1,126 @@
+package eskip
+
+import "reflect"
+
+func eqArgs(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		pa, aok := a[i].(*Predicate)
+		pb, bok := b[i].(*Predicate)
+		switch {
+		case aok != bok:
+			return false
+		case aok:
+			if !eqPredicate(pa, pb) {
+				return false
+			}
+		case !reflect.DeepEqual(a[i], b[i]):
+			return false
+		}
+	}
+
+	return true
+}
+
+func eqPredicate(a, b *Predicate) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Name == b.Name && eqArgs(a.Args, b.Args)
+}
+
+func eqStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func eqStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func eq2(a, b *Route) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if a.Id != b.Id ||
+		a.Path != b.Path ||
+		a.Method != b.Method ||
+		a.Shunt != b.Shunt ||
+		a.BackendType != b.BackendType ||
+		a.Backend != b.Backend ||
+		!eqStrings(a.HostRegexps, b.HostRegexps) ||
+		!eqStrings(a.PathRegexps, b.PathRegexps) ||
+		!eqStringMaps(a.Headers, b.Headers) ||
+		!eqStringMaps(a.Annotations, b.Annotations) ||
+		len(a.HeaderRegexps) != len(b.HeaderRegexps) ||
+		len(a.Predicates) != len(b.Predicates) ||
+		len(a.Filters) != len(b.Filters) {
+		return false
+	}
+
+	for k, v := range a.HeaderRegexps {
+		if bv, ok := b.HeaderRegexps[k]; !ok || !eqStrings(v, bv) {
+			return false
+		}
+	}
+
+	for i := range a.Predicates {
+		if !eqPredicate(a.Predicates[i], b.Predicates[i]) {
+			return false
+		}
+	}
+
+	for i := range a.Filters {
+		fa, fb := a.Filters[i], b.Filters[i]
+		if fa == nil || fb == nil {
+			if fa != fb {
+				return false
+			}
+
+			continue
+		}
+
+		if fa.Name != fb.Name || !eqArgs(fa.Args, fb.Args) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Eq checks whether the route definitions are equal, comparing all
+// their fields, except for the source positions. It is cheaper than
+// comparing the printed form of the routes.
+func Eq(r ...*Route) bool {
+	for i := 1; i < len(r); i++ {
+		if !eq2(r[0], r[i]) {
+			return false
+		}
+	}
+
+	return true
+}
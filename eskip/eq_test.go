@@ -0,0 +1,61 @@
+package eskip
+
+import "testing"
+
+func TestEq(t *testing.T) {
+	for _, test := range []struct {
+		title string
+		a, b  string
+		eq    bool
+	}{{
+		title: "same",
+		a:     `r: Path("/foo") && Or(Method("GET"), Cookie("foo", /bar/)) -> status(200) -> <shunt>`,
+		b:     `r: Path("/foo") && Or(Method("GET"), Cookie("foo", /bar/)) -> status(200) -> <shunt>`,
+		eq:    true,
+	}, {
+		title: "different position",
+		a:     `r: Path("/foo") -> <shunt>`,
+		b:     "\n\nr:\n\tPath(\"/foo\")\n\t-> <shunt>",
+		eq:    true,
+	}, {
+		title: "different id",
+		a:     `r1: * -> <shunt>`,
+		b:     `r2: * -> <shunt>`,
+	}, {
+		title: "different nested predicate",
+		a:     `r: Not(Traffic(.1)) -> <shunt>`,
+		b:     `r: Not(Traffic(.2)) -> <shunt>`,
+	}, {
+		title: "different filter args",
+		a:     `r: * -> status(200) -> <shunt>`,
+		b:     `r: * -> status(201) -> <shunt>`,
+	}, {
+		title: "different header",
+		a:     `r: Header("X-Foo", "bar") -> <shunt>`,
+		b:     `r: Header("X-Foo", "baz") -> <shunt>`,
+	}, {
+		title: "different backend",
+		a:     `r: * -> "https://www.example.org"`,
+		b:     `r: * -> <loopback>`,
+	}, {
+		title: "different annotation",
+		a:     `r: @owner("team-a") * -> <shunt>`,
+		b:     `r: @owner("team-b") * -> <shunt>`,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			a, err := ParseWithSource(test.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := ParseWithSource(test.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if Eq(a[0], b[0]) != test.eq {
+				t.Errorf("invalid result, expected: %t", test.eq)
+			}
+		})
+	}
+}
@@ -11,7 +11,12 @@ import (
 	"github.com/zalando/skipper/filters/flowid"
 )
 
-const duplicateHeaderPredicateErrorFmt = "duplicate header predicate: %s"
+const (
+	duplicateHeaderPredicateErrorFmt = "duplicate header predicate: %s"
+	duplicateAnnotationErrorFmt      = "duplicate annotation: %s"
+	invalidAnnotationArgErrorFmt     = "invalid annotation arg: %s"
+	invalidFilterArgErrorFmt         = "invalid filter arg: %s"
+)
 
 var (
 	invalidPredicateArgError        = errors.New("invalid predicate arg")
@@ -28,6 +33,9 @@ type matcher struct {
 
 	// The args of the matcher, e.g. the path to be matched.
 	args []interface{}
+
+	// The position of the matcher in the parsed document.
+	source callSpan
 }
 
 // BackendType indicates whether a route is a network backend, a shunt or a loopback.
@@ -42,12 +50,24 @@ const (
 // Route definition used during the parser processes the raw routing
 // document.
 type parsedRoute struct {
-	id       string
-	matchers []*matcher
-	filters  []*Filter
-	shunt    bool
-	loopback bool
-	backend  string
+	id          string
+	matchers    []*matcher
+	filters     []*Filter
+	shunt       bool
+	loopback    bool
+	backend     string
+	annotations []*matcher
+
+	// set when the route is defined by a route template call, stored
+	// as the only matcher, and replaced by the expanded template after
+	// parsing
+	templateCall bool
+
+	// positions in the parsed document
+	span        span
+	idSpan      span
+	backendSpan span
+	filterSpans []callSpan
 }
 
 // A Predicate object represents a parsed, in-memory, route matching predicate
@@ -60,7 +80,8 @@ type Predicate struct {
 	// The arguments of the predicate as defined in the
 	// route definition. The arguments can be of type
 	// float64 or string (string for both strings and
-	// regular expressions).
+	// regular expressions), or *Predicate in case of
+	// nested predicate expressions, e.g. Not(Host(/^www/)).
 	Args []interface{} `json:"args"`
 }
 
@@ -126,6 +147,17 @@ type Route struct {
 	// The address of a backend for a parsed route.
 	// E.g. "https://www.example.org"
 	Backend string
+
+	// Optional annotations of the route, e.g. its owner or a
+	// description, that are not used for routing. They are
+	// printed before the predicates, with a single string arg, or
+	// without args. E.g. @owner("team-x") @ticket("ABC-1")
+	Annotations map[string]string
+
+	// Optional metadata about the source of the route, set only
+	// when parsed with ParseWithSource. Used by tooling, e.g. to
+	// report the location of the invalid routes.
+	Source *RouteSource
 }
 
 type RoutePredicate func(*Route) bool
@@ -246,6 +278,52 @@ func applyPredicates(route *Route, proute *parsedRoute) error {
 	return err
 }
 
+// Checks and sets the annotations of the route. An annotation can have
+// a single string arg, or no args, meaning an empty value.
+func applyAnnotations(route *Route, proute *parsedRoute) error {
+	for _, a := range proute.annotations {
+		var value string
+		switch len(a.args) {
+		case 0:
+		case 1:
+			s, ok := a.args[0].(string)
+			if !ok {
+				return fmt.Errorf(invalidAnnotationArgErrorFmt, a.name)
+			}
+
+			value = s
+		default:
+			return fmt.Errorf(invalidAnnotationArgErrorFmt, a.name)
+		}
+
+		if route.Annotations == nil {
+			route.Annotations = make(map[string]string)
+		}
+
+		if _, ok := route.Annotations[a.name]; ok {
+			return fmt.Errorf(duplicateAnnotationErrorFmt, a.name)
+		}
+
+		route.Annotations[a.name] = value
+	}
+
+	return nil
+}
+
+// The predicate expressions are accepted only as the args of
+// predicates.
+func checkFilterArgs(filters []*Filter) error {
+	for _, f := range filters {
+		for _, a := range f.Args {
+			if _, ok := a.(*Predicate); ok {
+				return fmt.Errorf(invalidFilterArgErrorFmt, f.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Converts a parsing route objects to the exported route definition with
 // pre-processed but not validated matchers.
 func newRouteDefinition(r *parsedRoute) (*Route, error) {
@@ -263,6 +341,14 @@ func newRouteDefinition(r *parsedRoute) (*Route, error) {
 
 	rd.BackendType = bt
 
+	if err := checkFilterArgs(r.filters); err != nil {
+		return nil, err
+	}
+
+	if err := applyAnnotations(rd, r); err != nil {
+		return nil, err
+	}
+
 	err = applyPredicates(rd, r)
 
 	return rd, err
@@ -285,11 +371,22 @@ func backendType(shunt, loopback bool) (bt BackendType, err error) {
 	return
 }
 
-// executes the parser.
-func parse(code string) ([]*parsedRoute, error) {
+// executes the parser, and returns the lexer holding the results, with
+// the macros expanded.
+func parseLexer(code string) (*eskipLex, error) {
 	l := newLexer(code)
 	eskipParse(l)
-	return l.routes, l.err
+	if l.err != nil {
+		return l, l.err
+	}
+
+	return l, l.expandMacros()
+}
+
+// executes the parser.
+func parse(code string) ([]*parsedRoute, error) {
+	l, err := parseLexer(code)
+	return l.routes, err
 }
 
 func partialRouteToRoute(format, p string) string {
@@ -311,18 +408,30 @@ func predicatesToRoute(p string) string {
 	return partialRouteToRoute("%s -> <shunt>", p)
 }
 
-// Parses a route expression or a routing document to a set of route definitions.
-func Parse(code string) ([]*Route, error) {
+func parseDocument(code string, withSource bool) ([]*Route, error) {
 	parsedRoutes, err := parse(code)
 	if err != nil {
 		return nil, err
 	}
 
+	return routeDefinitions(code, parsedRoutes, withSource)
+}
+
+func routeDefinitions(code string, parsedRoutes []*parsedRoute, withSource bool) ([]*Route, error) {
+	var lines lineIndex
+	if withSource {
+		lines = newLineIndex(code)
+	}
+
 	routeDefinitions := make([]*Route, len(parsedRoutes))
 	for i, r := range parsedRoutes {
 		rd, err := newRouteDefinition(r)
 		if err != nil {
-			return nil, err
+			return nil, newRouteError(code, r, err)
+		}
+
+		if withSource {
+			rd.Source = r.source(lines)
 		}
 
 		routeDefinitions[i] = rd
@@ -331,6 +440,20 @@ func Parse(code string) ([]*Route, error) {
 	return routeDefinitions, nil
 }
 
+// Parses a route expression or a routing document to a set of route
+// definitions. When parsing fails, the returned error is of type
+// *ParseError.
+func Parse(code string) ([]*Route, error) {
+	return parseDocument(code, false)
+}
+
+// ParseWithSource parses a route expression or a routing document
+// like Parse, and sets the Source field of the routes, containing the
+// positions of the routes and their parts in the document.
+func ParseWithSource(code string) ([]*Route, error) {
+	return parseDocument(code, true)
+}
+
 func partialParse(f string, partialToRoute func(string) string) (*parsedRoute, error) {
 	rs, err := parse(partialToRoute(f))
 	if err != nil {
@@ -1,6 +1,7 @@
 package eskip
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -122,6 +123,39 @@ func TestParseRouteExpression(t *testing.T) {
 		`Method("HEAD") && Method("GET") -> "https://www.example.org"`,
 		nil,
 		true,
+	}, {
+		"annotations",
+		`@owner("team-x") @deprecated() Path("/foo") -> "https://www.example.org"`,
+		&Route{
+			Path:        "/foo",
+			Annotations: map[string]string{"owner": "team-x", "deprecated": ""},
+			Backend:     "https://www.example.org"},
+		false,
+	}, {
+		"duplicate annotations",
+		`@owner("team-x") @owner("team-y") Path("/foo") -> "https://www.example.org"`,
+		nil,
+		true,
+	}, {
+		"invalid annotation arg",
+		`@weight(42) Path("/foo") -> "https://www.example.org"`,
+		nil,
+		true,
+	}, {
+		"too many annotation args",
+		`@owner("team-x", "team-y") Path("/foo") -> "https://www.example.org"`,
+		nil,
+		true,
+	}, {
+		"nested predicate in filter args",
+		`* -> setRequestHeader(Host("www.example.org"), "bar") -> <shunt>`,
+		nil,
+		true,
+	}, {
+		"nested predicate in host args",
+		`Host(Method("GET")) -> <shunt>`,
+		nil,
+		true,
 	}, {
 		"shunt",
 		`* -> setRequestHeader("X-Foo", "bar") -> <shunt>`,
@@ -270,6 +304,10 @@ func TestParseRouteExpression(t *testing.T) {
 			if r.Backend != ti.check.Backend {
 				t.Error("backend", r.Backend, ti.check.Backend)
 			}
+
+			if !reflect.DeepEqual(r.Annotations, ti.check.Annotations) {
+				t.Error("annotations", r.Annotations, ti.check.Annotations)
+			}
 		})
 	}
 }
@@ -382,6 +420,99 @@ func TestRouteJSON(t *testing.T) {
 	}
 }
 
+func TestRouteJSONRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		title string
+		doc   string
+	}{{
+		title: "network backend",
+		doc: `route1: Method("PUT") && Path("/some/\"/path") && Host(/h-expression/) && Host(/slash\/h-expression/) &&
+			PathRegexp(/p-expression/) && Header("ap\"key", "ap\"value") &&
+			HeaderRegexp("ap\"key", /slash\/value0/) && HeaderRegexp("ap\"key", /value1/) &&
+			Test(3.14, "hello")
+			-> filter0(3.1415, "argvalue") -> filter1(42, "ap\"argvalue") -> filter2()
+			-> "https://www.example.org"`,
+	}, {
+		title: "shunt",
+		doc:   `route1: * -> <shunt>`,
+	}, {
+		title: "annotations",
+		doc:   `route1: @owner("team-x") @deprecated() Path("/foo") -> <shunt>`,
+	}, {
+		title: "nested predicates",
+		doc:   `route1: Or(Not(Host(/^www/)), And(Header("X-Foo", "bar"), Traffic(0.3))) -> <shunt>`,
+	}, {
+		title: "loopback",
+		doc:   `route1: Path("/foo") -> setPath("/bar") -> <loopback>`,
+	}, {
+		title: "multiple routes",
+		doc:   `route1: Path("/foo") -> <shunt>; route2: Path("/bar") -> "https://www.example.org"`,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			r, err := Parse(test.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := json.Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rr []*Route
+			if err := json.Unmarshal(b, &rr); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(rr, r) {
+				t.Errorf("invalid round trip, expected:\n%s\ngot:\n%s", litter.Sdump(r), litter.Sdump(rr))
+			}
+		})
+	}
+}
+
+func TestJSONObjectArgs(t *testing.T) {
+	var r Route
+	if err := json.Unmarshal([]byte(`{
+		"predicates": [
+			{"name": "Not", "args": [{"name": "Foo", "args": [{"value": 42}]}, {"args": ["bar"]}]}
+		],
+		"filters": [{"name": "foo", "args": [{"name": "bar"}]}]
+	}`), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	nested, ok := r.Predicates[0].Args[0].(*Predicate)
+	if !ok || nested.Name != "Foo" {
+		t.Fatal("failed to unmarshal nested predicate")
+	}
+
+	if _, ok := nested.Args[0].(map[string]interface{}); !ok {
+		t.Error("object without name unmarshaled as a nested predicate")
+	}
+
+	if _, ok := r.Predicates[0].Args[1].(map[string]interface{}); !ok {
+		t.Error("object without name unmarshaled as a nested predicate")
+	}
+
+	if _, ok := r.Filters[0].Args[0].(map[string]interface{}); !ok {
+		t.Error("filter arg unmarshaled as a nested predicate")
+	}
+}
+
+func TestRouteJSONUnmarshalInvalid(t *testing.T) {
+	for _, doc := range []string{
+		`{"id": 42}`,
+		`{"predicates": [{"name": "Path", "args": [42]}]}`,
+		`{"predicates": [{"name": "Method", "args": ["GET"]}, {"name": "Method", "args": ["PUT"]}]}`,
+	} {
+		var r Route
+		if err := json.Unmarshal([]byte(doc), &r); err == nil {
+			t.Errorf("failed to fail: %s", doc)
+		}
+	}
+}
+
 func TestPredicateParsing(t *testing.T) {
 	for _, test := range []struct {
 		title    string
@@ -409,6 +540,22 @@ func TestPredicateParsing(t *testing.T) {
 	}, {
 		title: "star notation",
 		input: `*`,
+	}, {
+		title: "nested predicates",
+		input: `Not(Host(/^www/)) && Or(Foo(), And(Bar(42), Baz("qux")))`,
+		expected: []*Predicate{{
+			Name: "Not",
+			Args: []interface{}{&Predicate{Name: "Host", Args: []interface{}{"^www"}}},
+		}, {
+			Name: "Or",
+			Args: []interface{}{
+				&Predicate{Name: "Foo"},
+				&Predicate{Name: "And", Args: []interface{}{
+					&Predicate{Name: "Bar", Args: []interface{}{float64(42)}},
+					&Predicate{Name: "Baz", Args: []interface{}{"qux"}},
+				}},
+			},
+		}},
 	}} {
 		t.Run(test.title, func(t *testing.T) {
 			p, err := ParsePredicates(test.input)
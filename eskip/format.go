@@ -0,0 +1,217 @@
+package eskip
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FormatOptions control the output of Format.
+type FormatOptions struct {
+
+	// Pretty printing settings of the routes.
+	PrettyPrintInfo
+
+	// SortRoutes sorts the route definitions by their id. The comments
+	// preceding a route are moved together with the route.
+	SortRoutes bool
+}
+
+// a route or a macro definition in the formatted document
+type formattedRoute struct {
+	route    *Route
+	text     string
+	span     span
+	leading  []string
+	trailing string
+}
+
+// assigns the comments to the routes and the macros. The comments inside
+// a definition are moved before the definition, and a comment following
+// a definition in the same line is kept there. The comments after the
+// last definition are returned separately.
+func assignComments(l *eskipLex, routes []*formattedRoute) []string {
+	var (
+		lines    = newLineIndex(l.doc)
+		trailing []string
+		next     int
+	)
+
+	for _, c := range l.comments {
+		text := strings.TrimRightFunc(c.text, unicode.IsSpace)
+		for next < len(routes) && routes[next].span.end <= c.start {
+			next++
+		}
+
+		if next > 0 && routes[next-1].trailing == "" &&
+			lines.position(c.start).Line == lines.position(routes[next-1].span.end).Line {
+			routes[next-1].trailing = text
+			continue
+		}
+
+		if next < len(routes) {
+			routes[next].leading = append(routes[next].leading, text)
+			continue
+		}
+
+		trailing = append(trailing, text)
+	}
+
+	return trailing
+}
+
+func prettySeparator(o PrettyPrintInfo) string {
+	if o.Pretty {
+		return "\n" + o.IndentStr + "-> "
+	}
+
+	return " -> "
+}
+
+// prints a macro definition with its body. The first filter of a filter
+// chain is stored by the parser as a matcher.
+func macroString(m *macro, o PrettyPrintInfo) (string, error) {
+	head := fmt.Sprintf("%s(%s) := ", m.name, strings.Join(m.params, ", "))
+	if m.filterChain {
+		var fs []string
+		for _, f := range m.route.matchers {
+			fs = appendFmt(fs, "%s(%s)", f.name, argsString(f.args))
+		}
+
+		for _, f := range m.route.filters {
+			fs = appendFmt(fs, "%s(%s)", f.Name, argsString(f.Args))
+		}
+
+		return head + strings.Join(fs, prettySeparator(o)), nil
+	}
+
+	r, err := newRouteDefinition(m.route)
+	if err != nil {
+		return "", err
+	}
+
+	return head + r.Print(o), nil
+}
+
+// prints a route defined by a route template call, without expanding it
+func templateCallString(r *Route) string {
+	call := r.Predicates[0]
+	s := fmt.Sprintf("%s: %s(%s)", r.Id, call.Name, argsString(call.Args))
+	if as := r.annotationString(); as != "" {
+		s = fmt.Sprintf("%s: %s %s(%s)", r.Id, as, call.Name, argsString(call.Args))
+	}
+
+	return s
+}
+
+// returns the routes and the macro definitions of a parsed document, in
+// the order of the document, without expanding the macros
+func formattedDefinitions(l *eskipLex, o PrettyPrintInfo) ([]*formattedRoute, error) {
+	var fr []*formattedRoute
+	for _, pr := range l.routes {
+		r, err := newRouteDefinition(pr)
+		if err != nil {
+			return nil, newRouteError(l.doc, pr, err)
+		}
+
+		f := &formattedRoute{route: r, span: pr.span}
+		if pr.templateCall {
+			f.text = templateCallString(r)
+		}
+
+		fr = append(fr, f)
+	}
+
+	for _, m := range l.macros {
+		text, err := macroString(m, o)
+		if err != nil {
+			return nil, newMacroError(l.doc, m, err)
+		}
+
+		fr = append(fr, &formattedRoute{text: text, span: m.span})
+	}
+
+	sort.SliceStable(fr, func(i, j int) bool { return fr[i].span.start < fr[j].span.start })
+	return fr, nil
+}
+
+// Format parses a routing document or a route expression, and returns it
+// in a canonical form: the routes are printed the same way as by Print,
+// one definition after the other, with the strings double quoted and the
+// numbers in their shortest form. The macro definitions and the macro
+// references are kept unexpanded. The comments of the document are
+// preserved. When sorting, the macro definitions are printed before the
+// routes.
+func Format(doc string, o FormatOptions) (string, error) {
+	// the macros are expanded only to check them, the document is
+	// printed with the macro definitions and references
+	if _, err := parseLexer(doc); err != nil {
+		return "", err
+	}
+
+	l := newLexer(doc)
+	eskipParse(l)
+	fr, err := formattedDefinitions(l, o.PrettyPrintInfo)
+	if err != nil {
+		return "", err
+	}
+
+	trailing := assignComments(l, fr)
+	if o.SortRoutes {
+		sort.SliceStable(fr, func(i, j int) bool {
+			if fr[i].route == nil || fr[j].route == nil {
+				return fr[i].route == nil && fr[j].route != nil
+			}
+
+			return fr[i].route.Id < fr[j].route.Id
+		})
+	}
+
+	var b bytes.Buffer
+	expression := len(fr) == 1 && fr[0].route != nil && !isDefinition(fr[0].route)
+	for i, r := range fr {
+		if i > 0 {
+			b.WriteString("\n")
+			if o.Pretty || len(r.leading) > 0 {
+				b.WriteString("\n")
+			}
+		}
+
+		for _, c := range r.leading {
+			b.WriteString(c)
+			b.WriteString("\n")
+		}
+
+		switch {
+		case r.text != "":
+			b.WriteString(r.text)
+			b.WriteString(";")
+		case expression:
+			fprintExpression(&b, r.route, o.PrettyPrintInfo)
+		default:
+			fprintDefinition(&b, r.route, o.PrettyPrintInfo)
+			b.WriteString(";")
+		}
+
+		if r.trailing != "" {
+			b.WriteString(" ")
+			b.WriteString(r.trailing)
+		}
+	}
+
+	if len(fr) > 0 {
+		b.WriteString("\n")
+		if len(trailing) > 0 {
+			b.WriteString("\n")
+		}
+	}
+
+	for _, c := range trailing {
+		b.WriteString(c)
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
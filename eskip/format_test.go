@@ -0,0 +1,155 @@
+package eskip
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	for _, test := range []struct {
+		title    string
+		doc      string
+		options  FormatOptions
+		expected string
+	}{{
+		title:    "empty",
+		doc:      "",
+		expected: "",
+	}, {
+		title:    "only comments",
+		doc:      "// foo  \n\n// bar",
+		expected: "// foo\n// bar\n",
+	}, {
+		title:    "route expression",
+		doc:      "  Path(`/foo`)->   <shunt>",
+		expected: "Path(\"/foo\") -> <shunt>\n",
+	}, {
+		title: "normalize quoting and numbers",
+		doc: `route1: Traffic(.30) && Header("X-B", "2") && Header("X-A", "1") ->
+			setRequestHeader(` + "`X-\"Foo\"`" + `, "bar") -> status(404.0) -> "https://www.example.org"`,
+		expected: `route1: Header("X-A", "1") && Header("X-B", "2") && Traffic(0.3) -> setRequestHeader("X-\"Foo\"", "bar") -> status(404) -> "https://www.example.org";` + "\n",
+	}, {
+		title: "comments",
+		doc: `// the routes
+// of the service
+
+route1: Path("/foo") -> <shunt>; // foo route
+route2: Path("/bar")
+	// the backend
+	-> "https://www.example.org";
+// end`,
+		expected: `// the routes
+// of the service
+route1: Path("/foo") -> <shunt>; // foo route
+
+// the backend
+route2: Path("/bar") -> "https://www.example.org";
+
+// end
+`,
+	}, {
+		title: "sort",
+		doc: `// the b route
+b: Path("/b") -> <shunt>;
+a: Path("/a") -> <shunt>; // a
+c: Path("/c") -> <shunt>;`,
+		options: FormatOptions{SortRoutes: true},
+		expected: `a: Path("/a") -> <shunt>; // a
+
+// the b route
+b: Path("/b") -> <shunt>;
+c: Path("/c") -> <shunt>;
+`,
+	}, {
+		title: "pretty",
+		doc:   `a: Path("/a") -> setPath("/b") -> <shunt>; b: * -> <loopback>`,
+		options: FormatOptions{
+			PrettyPrintInfo: PrettyPrintInfo{Pretty: true, IndentStr: "  "},
+		},
+		expected: `a: Path("/a")
+  -> setPath("/b")
+  -> <shunt>;
+
+b: *
+  -> <loopback>;
+`,
+	}, {
+		title: "macros",
+		doc: `// the chain
+authChain(scope) := oauthTokeninfoAnyScope(` + "`${scope}`" + `)   -> flowId("reuse");
+api(path,backend) := Path("${path}") -> authChain("read") -> "${backend}";
+users: api("/users", "https://users.example.org"); // users
+nochain() := flowId("reuse")`,
+		options: FormatOptions{SortRoutes: true},
+		expected: `// the chain
+authChain(scope) := oauthTokeninfoAnyScope("${scope}") -> flowId("reuse");
+api(path, backend) := Path("${path}") -> authChain("read") -> "${backend}";
+nochain() := flowId("reuse");
+users: api("/users", "https://users.example.org"); // users
+`,
+	}, {
+		title: "pretty macros",
+		doc:   `chain() := f1() -> f2(); r: Path("/") -> chain() -> <shunt>`,
+		options: FormatOptions{
+			PrettyPrintInfo: PrettyPrintInfo{Pretty: true, IndentStr: "  "},
+		},
+		expected: `chain() := f1()
+  -> f2();
+
+r: Path("/")
+  -> chain()
+  -> <shunt>;
+`,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			f, err := Format(test.doc, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f != test.expected {
+				t.Errorf("invalid format, expected:\n%s\ngot:\n%s", test.expected, f)
+			}
+
+			ff, err := Format(f, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ff != f {
+				t.Errorf("format is not stable, expected:\n%s\ngot:\n%s", f, ff)
+			}
+		})
+	}
+}
+
+func TestFormatInvalid(t *testing.T) {
+	if _, err := Format(`route1: Path("/foo") ->`, FormatOptions{}); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestFormatMacrosRoundTrip(t *testing.T) {
+	const doc = `
+		authChain(scope) := oauthTokeninfoAnyScope("${scope}") -> flowId("reuse");
+		api(path, backend) := Path("${path}") -> authChain("read") -> "${backend}";
+		users: api("/users", "https://users.example.org");
+		teams: Path("/teams") -> authChain("write") -> "https://teams.example.org";
+	`
+
+	expected, err := Parse(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Format(doc, FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if String(got...) != String(expected...) {
+		t.Errorf("invalid routes after formatting, expected:\n%s\ngot:\n%s", String(expected...), String(got...))
+	}
+}
@@ -92,18 +92,138 @@ func (r *Route) MarshalJSON() ([]byte, error) {
 	e.SetEscapeHTML(false)
 
 	if err := e.Encode(&struct {
-		Id         string       `json:"id"`
-		Backend    string       `json:"backend"`
-		Predicates []*Predicate `json:"predicates"`
-		Filters    []*Filter    `json:"filters"`
+		Id          string            `json:"id"`
+		Backend     string            `json:"backend"`
+		Predicates  []*Predicate      `json:"predicates"`
+		Filters     []*Filter         `json:"filters"`
+		Annotations map[string]string `json:"annotations,omitempty"`
 	}{
-		Id:         r.Id,
-		Backend:    backend,
-		Predicates: marshalJsonPredicates(r),
-		Filters:    filters,
+		Id:          r.Id,
+		Backend:     backend,
+		Predicates:  marshalJsonPredicates(r),
+		Filters:     filters,
+		Annotations: r.Annotations,
 	}); err != nil {
 		return nil, err
 	}
 
 	return buf.Bytes(), nil
 }
+
+func unmarshalNameArgs(b []byte) (string, []interface{}, error) {
+	var na struct {
+		Name string        `json:"name"`
+		Args []interface{} `json:"args"`
+	}
+
+	if err := json.Unmarshal(b, &na); err != nil {
+		return "", nil, err
+	}
+
+	// the parser returns nil for empty args
+	if len(na.Args) == 0 {
+		na.Args = nil
+	}
+
+	return na.Name, na.Args, nil
+}
+
+// converts the objects with a name in the args of a predicate to nested
+// predicate expressions, e.g. the args of Not or Or. The other objects
+// are left unchanged.
+func nestedPredicates(args []interface{}) {
+	for i, a := range args {
+		m, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _ := m["name"].(string)
+		if name == "" {
+			continue
+		}
+
+		p := &Predicate{Name: name}
+		if pargs, _ := m["args"].([]interface{}); len(pargs) > 0 {
+			nestedPredicates(pargs)
+			p.Args = pargs
+		}
+
+		args[i] = p
+	}
+}
+
+func (f *Filter) UnmarshalJSON(b []byte) error {
+	name, args, err := unmarshalNameArgs(b)
+	if err != nil {
+		return err
+	}
+
+	f.Name, f.Args = name, args
+	return nil
+}
+
+func (p *Predicate) UnmarshalJSON(b []byte) error {
+	name, args, err := unmarshalNameArgs(b)
+	if err != nil {
+		return err
+	}
+
+	nestedPredicates(args)
+	p.Name, p.Args = name, args
+	return nil
+}
+
+// UnmarshalJSON reads a route in the format produced by MarshalJSON. The
+// predicates are processed the same way as when parsing eskip, e.g. Path
+// or Header are set to the corresponding fields of the route, and the
+// backend is set according to its type.
+func (r *Route) UnmarshalJSON(b []byte) error {
+	var jr struct {
+		Id          string            `json:"id"`
+		Backend     string            `json:"backend"`
+		Predicates  []*Predicate      `json:"predicates"`
+		Filters     []*Filter         `json:"filters"`
+		Annotations map[string]string `json:"annotations,omitempty"`
+	}
+
+	if err := json.Unmarshal(b, &jr); err != nil {
+		return err
+	}
+
+	pr := &parsedRoute{id: jr.Id}
+	if len(jr.Filters) > 0 {
+		pr.filters = jr.Filters
+	}
+
+	for _, p := range jr.Predicates {
+		name := p.Name
+		if name == "HostRegexp" {
+			// marshaled with its field name, see marshalJsonPredicates
+			name = "Host"
+		}
+
+		pr.matchers = append(pr.matchers, &matcher{name: name, args: p.Args})
+	}
+
+	switch jr.Backend {
+	case "<shunt>":
+		pr.shunt = true
+	case "<loopback>":
+		pr.loopback = true
+	default:
+		pr.backend = jr.Backend
+	}
+
+	rd, err := newRouteDefinition(pr)
+	if err != nil {
+		return err
+	}
+
+	if len(jr.Annotations) > 0 {
+		rd.Annotations = jr.Annotations
+	}
+
+	*r = *rd
+	return nil
+}
@@ -16,7 +16,6 @@ package eskip
 
 import (
 	"errors"
-	"fmt"
 	"strings"
 	"unicode"
 )
@@ -24,6 +23,15 @@ import (
 type token struct {
 	id  int
 	val string
+
+	// byte offsets of the token in the document
+	start, end int
+}
+
+// line comments are retained by the lexer for formatting
+type comment struct {
+	text  string
+	start int
 }
 
 type charPredicate func(byte) bool
@@ -37,12 +45,17 @@ type scannerFunc func(string) (token, string, error)
 func (sf scannerFunc) scan(code string) (token, string, error) { return sf(code) }
 
 type eskipLex struct {
+	doc           string
 	code          string
 	lastToken     *token
+	prevToken     *token
+	tokenStart    int
 	err           error
 	initialLength int
 	routes        []*parsedRoute
 	filters       []*Filter
+	macros        []*macro
+	comments      []comment
 }
 
 type fixedScanner string
@@ -66,14 +79,42 @@ var fixedTokens = map[fixedScanner]int{
 	"&&":         and,
 	"*":          any,
 	"->":         arrow,
+	"@":          at,
 	")":          closeparen,
 	":":          colon,
 	",":          comma,
+	":=":         define,
 	"(":          openparen,
 	";":          semicolon,
 	"<shunt>":    shunt,
 	"<loopback>": loopback}
 
+// the names of the tokens in the parse errors
+var tokenNames = map[string]string{
+	"$end":          "end of input",
+	"and":           "&&",
+	"any":           "*",
+	"arrow":         "->",
+	"at":            "@",
+	"closeparen":    ")",
+	"colon":         ":",
+	"comma":         ",",
+	"define":        ":=",
+	"number":        "number",
+	"openparen":     "(",
+	"regexpliteral": "regular expression",
+	"semicolon":     ";",
+	"shunt":         "<shunt>",
+	"loopback":      "<loopback>",
+	"stringliteral": "string",
+	"symbol":        "name",
+}
+
+func init() {
+	// enables the unexpected and expected tokens in the syntax errors
+	eskipErrorVerbose = true
+}
+
 func (t token) String() string { return t.val }
 
 func (fs fixedScanner) scan(code string) (t token, rest string, err error) {
@@ -90,6 +131,7 @@ func (fs fixedScanner) scan(code string) (t token, rest string, err error) {
 
 func newLexer(code string) *eskipLex {
 	return &eskipLex{
+		doc:           code,
 		code:          code,
 		initialLength: len(code)}
 }
@@ -271,14 +313,20 @@ func scanSymbol(code string) (t token, rest string, err error) {
 	return
 }
 
+// selects the longest matching fixed token, e.g. := instead of :
 func selectFixed(code string) scanner {
+	var s fixedScanner
 	for fixed := range fixedTokens {
-		if len(code) >= len(fixed) && strings.HasPrefix(code, string(fixed)) {
-			return fixed
+		if len(fixed) > len(s) && strings.HasPrefix(code, string(fixed)) {
+			s = fixed
 		}
 	}
 
-	return nil
+	if s == "" {
+		return nil
+	}
+
+	return s
 }
 
 func selectVaryingScanner(code string) scanner {
@@ -315,13 +363,27 @@ func selectScanner(code string) scanner {
 	return selectVaryingScanner(code)
 }
 
+func (l *eskipLex) offset() int { return l.initialLength - len(l.code) }
+
 func (l *eskipLex) next() (t token, err error) {
 	l.code = scanWhitespace(l.code)
+	l.tokenStart = l.offset()
 	if len(l.code) == 0 {
 		err = eof
 		return
 	}
 
+	if strings.HasPrefix(l.code, "//") {
+		rest := scanComment(l.code)
+		l.comments = append(l.comments, comment{
+			text:  l.code[:len(l.code)-len(rest)],
+			start: l.tokenStart,
+		})
+
+		l.code = rest
+		return l.next()
+	}
+
 	s := selectScanner(l.code)
 	if s == nil {
 		err = unexpectedToken
@@ -334,6 +396,8 @@ func (l *eskipLex) next() (t token, err error) {
 	}
 
 	if err == nil {
+		t.start, t.end = l.tokenStart, l.offset()
+		l.prevToken = l.lastToken
 		l.lastToken = &t
 	}
 
@@ -347,16 +411,68 @@ func (l *eskipLex) Lex(lval *eskipSymType) int {
 	}
 
 	if err != nil {
-		l.Error(err.Error())
+		l.setError(l.lastToken, "", err.Error())
 		return -1
 	}
 
 	lval.token = token.val
+	lval.start = token.start
+	lval.end = token.end
 	return token.id
 }
 
-func (l *eskipLex) Error(err string) {
-	l.err = fmt.Errorf(
-		"parse failed after token %v, position %d: %s",
-		l.lastToken, l.initialLength-len(l.code), err)
+// keeps only the first error, because after a failing token, the
+// parser reports a syntax error at the end of the input, too
+func (l *eskipLex) setError(after *token, unexpected, msg string, expected ...string) {
+	if l.err != nil {
+		return
+	}
+
+	e := &ParseError{
+		Position:   newLineIndex(l.doc).position(l.tokenStart),
+		Unexpected: unexpected,
+		Expected:   expected,
+		Message:    msg,
+	}
+
+	if after != nil {
+		e.Token = after.val
+	}
+
+	l.err = e
+}
+
+func tokenName(name string) string {
+	if n, ok := tokenNames[name]; ok {
+		return n
+	}
+
+	return name
+}
+
+// Error is called by the parser on syntax errors. With the verbose
+// errors, the message has the format of: syntax error: unexpected
+// <token>, expecting <token> or <token>
+func (l *eskipLex) Error(msg string) {
+	after := l.prevToken
+	if l.tokenStart == l.initialLength {
+		// the unexpected token is the end of the input
+		after = l.lastToken
+	}
+
+	const unexpectedPrefix = "syntax error: unexpected "
+	if !strings.HasPrefix(msg, unexpectedPrefix) {
+		l.setError(after, "", msg)
+		return
+	}
+
+	details := strings.Split(msg[len(unexpectedPrefix):], ", expecting ")
+	var expected []string
+	if len(details) > 1 {
+		for _, e := range strings.Split(details[1], " or ") {
+			expected = append(expected, tokenName(e))
+		}
+	}
+
+	l.setError(after, tokenName(details[0]), "syntax error", expected...)
 }
@@ -0,0 +1,324 @@
+package eskip
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	duplicateMacroErrorFmt       = "duplicate macro: %s"
+	duplicateMacroParamErrorFmt  = "duplicate parameter of macro %s: %s"
+	invalidFilterChainErrorFmt   = "invalid filter chain: %s"
+	undefinedMacroErrorFmt       = "undefined macro: %s"
+	notFilterChainErrorFmt       = "macro %s is not a filter chain"
+	notRouteTemplateErrorFmt     = "macro %s is not a route template"
+	invalidMacroArgCountErrorFmt = "invalid number of args for macro %s, expected: %d, got: %d"
+	invalidMacroArgErrorFmt      = "invalid arg of macro %s: %s, expected string or number"
+	recursiveMacroErrorFmt       = "recursive macro: %s"
+)
+
+var errMissingBackend = errors.New("missing backend")
+
+// Represents a filter chain or a route template defined in a routing
+// document:
+//
+//	authChain(scope) := oauthTokeninfoAnyScope("${scope}") -> flowId("reuse");
+//	api(path, backend) := Path("${path}") -> authChain("read") -> "${backend}";
+type macro struct {
+	name   string
+	params []string
+
+	// The body of the macro. In case of filter chains, the parser
+	// parses the first filter as a matcher, and it is moved to the
+	// filters when the definitions are checked.
+	route       *parsedRoute
+	filterChain bool
+
+	// The position of the definition in the parsed document.
+	span span
+}
+
+// the values of the macro parameters during the expansion
+type macroArgs map[string]interface{}
+
+// moves the first filter of a filter chain from the matchers to the
+// filters.
+func (m *macro) initFilterChain() error {
+	if len(m.route.matchers) != 1 || m.route.matchers[0].name == "*" {
+		return fmt.Errorf(invalidFilterChainErrorFmt, m.name)
+	}
+
+	first := m.route.matchers[0]
+	m.route.filters = append([]*Filter{{Name: first.name, Args: first.args}}, m.route.filters...)
+	m.route.filterSpans = append([]callSpan{first.source}, m.route.filterSpans...)
+	m.route.matchers = nil
+	return nil
+}
+
+func (m *macro) bindArgs(args []interface{}) (macroArgs, error) {
+	if len(args) != len(m.params) {
+		return nil, fmt.Errorf(invalidMacroArgCountErrorFmt, m.name, len(m.params), len(args))
+	}
+
+	ma := make(macroArgs)
+	for i, p := range m.params {
+		switch args[i].(type) {
+		case string, float64:
+			ma[p] = args[i]
+		default:
+			return nil, fmt.Errorf(invalidMacroArgErrorFmt, m.name, p)
+		}
+	}
+
+	return ma, nil
+}
+
+// expects only the arg types accepted by bindArgs
+func macroArgString(a interface{}) string {
+	switch v := a.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Replaces the placeholders of the macro parameters in a string, using
+// the same syntax as Template: ${name}. The other placeholders are left
+// unchanged, because they may be used by the filters during processing
+// the requests. When the string contains only a single placeholder, the
+// arg is returned with its original type, e.g. as a number.
+func (ma macroArgs) apply(s string) interface{} {
+	t := NewTemplate(s)
+	if len(t.placeholders) == 1 && s == "${"+t.placeholders[0]+"}" {
+		if a, ok := ma[t.placeholders[0]]; ok {
+			return a
+		}
+	}
+
+	return t.Apply(func(name string) string {
+		if a, ok := ma[name]; ok {
+			return macroArgString(a)
+		}
+
+		return "${" + name + "}"
+	})
+}
+
+func (ma macroArgs) applyArgs(args []interface{}) []interface{} {
+	if ma == nil || len(args) == 0 {
+		return args
+	}
+
+	applied := make([]interface{}, len(args))
+	for i, a := range args {
+		switch v := a.(type) {
+		case string:
+			applied[i] = ma.apply(v)
+		case *Predicate:
+			applied[i] = &Predicate{Name: v.Name, Args: ma.applyArgs(v.Args)}
+		default:
+			applied[i] = a
+		}
+	}
+
+	return applied
+}
+
+type macroExpander map[string]*macro
+
+// checks the macro definitions and indexes them by name
+func newMacroExpander(macros []*macro) (macroExpander, *macro, error) {
+	e := make(macroExpander)
+	for _, m := range macros {
+		if _, ok := e[m.name]; ok {
+			return nil, m, fmt.Errorf(duplicateMacroErrorFmt, m.name)
+		}
+
+		params := make(map[string]bool)
+		for _, p := range m.params {
+			if params[p] {
+				return nil, m, fmt.Errorf(duplicateMacroParamErrorFmt, m.name, p)
+			}
+
+			params[p] = true
+		}
+
+		if m.filterChain {
+			if err := m.initFilterChain(); err != nil {
+				return nil, m, err
+			}
+		}
+
+		e[m.name] = m
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int)
+	var visit func(*macro, []string) error
+	visit = func(m *macro, path []string) error {
+		path = append(path, m.name)
+		switch state[m.name] {
+		case visiting:
+			return fmt.Errorf(recursiveMacroErrorFmt, strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+
+		state[m.name] = visiting
+		for _, f := range m.route.filters {
+			if fm, ok := e[f.Name]; ok && fm.filterChain {
+				if err := visit(fm, path); err != nil {
+					return err
+				}
+			}
+		}
+
+		state[m.name] = visited
+		return nil
+	}
+
+	for _, m := range macros {
+		if err := visit(m, nil); err != nil {
+			return nil, m, err
+		}
+	}
+
+	return e, nil, nil
+}
+
+// replaces the references to the filter chains with the filters of the
+// chains. The expanded filters get the position of the reference.
+func (e macroExpander) expandFilters(filters []*Filter, spans []callSpan, ma macroArgs) ([]*Filter, []callSpan, error) {
+	var (
+		expanded      []*Filter
+		expandedSpans []callSpan
+	)
+
+	for i, f := range filters {
+		var s callSpan
+		if i < len(spans) {
+			s = spans[i]
+		}
+
+		args := ma.applyArgs(f.Args)
+		m, ok := e[f.Name]
+		if !ok {
+			if ma != nil {
+				f = &Filter{Name: f.Name, Args: args}
+			}
+
+			expanded = append(expanded, f)
+			expandedSpans = append(expandedSpans, s)
+			continue
+		}
+
+		if !m.filterChain {
+			return nil, nil, fmt.Errorf(notFilterChainErrorFmt, m.name)
+		}
+
+		chainArgs, err := m.bindArgs(args)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		chain, _, err := e.expandFilters(m.route.filters, nil, chainArgs)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, cf := range chain {
+			expanded = append(expanded, cf)
+			expandedSpans = append(expandedSpans, s)
+		}
+	}
+
+	return expanded, expandedSpans, nil
+}
+
+// replaces a route template call with the expanded template, and the
+// filter chain references with the filters of the chains.
+func (e macroExpander) expandRoute(r *parsedRoute) error {
+	var ma macroArgs
+	if r.templateCall {
+		if len(r.matchers) != 1 || r.matchers[0].name == "*" {
+			return errMissingBackend
+		}
+
+		call := r.matchers[0]
+		m, ok := e[call.name]
+		if !ok {
+			return fmt.Errorf(undefinedMacroErrorFmt, call.name)
+		}
+
+		if m.filterChain {
+			return fmt.Errorf(notRouteTemplateErrorFmt, m.name)
+		}
+
+		var err error
+		if ma, err = m.bindArgs(call.args); err != nil {
+			return err
+		}
+
+		r.matchers = nil
+		for _, mi := range m.route.matchers {
+			r.matchers = append(r.matchers, &matcher{
+				name:   mi.name,
+				args:   ma.applyArgs(mi.args),
+				source: mi.source,
+			})
+		}
+
+		r.filters = m.route.filters
+		r.filterSpans = m.route.filterSpans
+		r.shunt = m.route.shunt
+		r.loopback = m.route.loopback
+		r.backend = macroArgString(ma.apply(m.route.backend))
+		r.backendSpan = m.route.backendSpan
+		r.templateCall = false
+	}
+
+	filters, spans, err := e.expandFilters(r.filters, r.filterSpans, ma)
+	if err != nil {
+		return err
+	}
+
+	r.filters = filters
+	if len(r.filterSpans) > 0 {
+		r.filterSpans = spans
+	}
+
+	return nil
+}
+
+func newMacroError(doc string, m *macro, err error) *ParseError {
+	return &ParseError{
+		Position: newLineIndex(doc).position(m.span.start),
+		Message:  err.Error(),
+		Err:      err,
+	}
+}
+
+// expands the macros defined in the parsed document
+func (l *eskipLex) expandMacros() error {
+	e, m, err := newMacroExpander(l.macros)
+	if err != nil {
+		return newMacroError(l.doc, m, err)
+	}
+
+	for _, r := range l.routes {
+		if err := e.expandRoute(r); err != nil {
+			return newRouteError(l.doc, r, err)
+		}
+	}
+
+	return nil
+}
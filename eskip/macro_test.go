@@ -0,0 +1,167 @@
+package eskip
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sanity-io/litter"
+)
+
+func TestMacros(t *testing.T) {
+	for _, test := range []struct {
+		title    string
+		doc      string
+		expected string
+		err      string
+	}{{
+		title: "filter chain without params",
+		doc: `
+			common() := flowId("reuse") -> compress();
+			route1: Path("/foo") -> common() -> status(201) -> <shunt>;
+			route2: Path("/bar") -> common() -> "https://www.example.org"`,
+		expected: `
+			route1: Path("/foo") -> flowId("reuse") -> compress() -> status(201) -> <shunt>;
+			route2: Path("/bar") -> flowId("reuse") -> compress() -> "https://www.example.org"`,
+	}, {
+		title: "filter chain with params",
+		doc: `
+			authChain(scope, code) := oauthTokeninfoAnyScope("${scope}") -> status("${code}") -> setPath("/${scope}/${id}");
+			route1: Path("/:id") -> authChain("read", 201) -> <shunt>`,
+		expected: `
+			route1: Path("/:id") -> oauthTokeninfoAnyScope("read") -> status(201) -> setPath("/read/${id}") -> <shunt>`,
+	}, {
+		title: "nested filter chains, defined after use",
+		doc: `
+			route1: * -> outer("foo") -> <shunt>;
+			outer(x) := setRequestHeader("X-Outer", "${x}") -> inner("${x}-bar");
+			inner(y) := setRequestHeader("X-Inner", "${y}")`,
+		expected: `
+			route1: * -> setRequestHeader("X-Outer", "foo") -> setRequestHeader("X-Inner", "foo-bar") -> <shunt>`,
+	}, {
+		title: "route template",
+		doc: `
+			authChain(scope) := oauthTokeninfoAnyScope("${scope}");
+			api(path, backend) := Path("${path}") && Method("GET") -> authChain("read") -> "${backend}";
+			users: api("/users", "https://users.example.org");
+			orders: @owner("team-x") api("/orders", "https://orders.example.org")`,
+		expected: `
+			users: Path("/users") && Method("GET") -> oauthTokeninfoAnyScope("read") -> "https://users.example.org";
+			orders: @owner("team-x") Path("/orders") && Method("GET") -> oauthTokeninfoAnyScope("read") -> "https://orders.example.org"`,
+	}, {
+		title: "route template with shunt",
+		doc: `
+			notFound(path) := Path("${path}") -> status(404) -> <shunt>;
+			route1: notFound("/foo")`,
+		expected: `route1: Path("/foo") -> status(404) -> <shunt>`,
+	}, {
+		title: "undefined macro",
+		doc:   `route1: api("/foo")`,
+		err:   "undefined macro: api",
+	}, {
+		title: "missing backend",
+		doc:   `route1: Path("/foo") && Method("GET")`,
+		err:   "missing backend",
+	}, {
+		title: "duplicate macro",
+		doc: `
+			chain() := status(200);
+			chain() := status(201)`,
+		err: "duplicate macro: chain",
+	}, {
+		title: "duplicate parameter",
+		doc:   `chain(a, a) := status(200)`,
+		err:   "duplicate parameter of macro chain: a",
+	}, {
+		title: "invalid filter chain",
+		doc:   `chain() := Path("/foo") && Method("GET")`,
+		err:   "invalid filter chain: chain",
+	}, {
+		title: "recursive macro",
+		doc: `
+			a() := status(200) -> b();
+			b() := c();
+			c() := a()`,
+		err: "recursive macro: a -> b -> c -> a",
+	}, {
+		title: "filter chain used as route template",
+		doc: `
+			chain() := status(200);
+			route1: chain()`,
+		err: "macro chain is not a route template",
+	}, {
+		title: "route template used as filter chain",
+		doc: `
+			api() := Path("/foo") -> <shunt>;
+			route1: * -> api() -> <shunt>`,
+		err: "macro api is not a filter chain",
+	}, {
+		title: "invalid number of args",
+		doc: `
+			chain(code) := status("${code}");
+			route1: * -> chain() -> <shunt>`,
+		err: "invalid number of args for macro chain, expected: 1, got: 0",
+	}, {
+		title: "predicate arg of filter chain",
+		doc: `
+			chain(p) := setPath("/a/${p}");
+			r: * -> chain(Host("x")) -> <shunt>`,
+		err: "invalid arg of macro chain: p, expected string or number",
+	}, {
+		title: "predicate arg of route template",
+		doc: `
+			api(b) := Path("/x") -> "${b}";
+			r: api(Host("x"))`,
+		err: "invalid arg of macro api: b, expected string or number",
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			r, err := Parse(test.doc)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("failed to fail with the right error, expected: %s, got: %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := Parse(test.expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(r, expected) {
+				t.Errorf("invalid expansion, expected:\n%s\ngot:\n%s", litter.Sdump(expected), litter.Sdump(r))
+			}
+		})
+	}
+}
+
+func TestMacroReferenceSource(t *testing.T) {
+	const doc = `chain() := status(200) -> compress();
+route1: Path("/foo") -> chain() -> <shunt>`
+
+	r, err := ParseWithSource(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r) != 1 || len(r[0].Source.Filters) != 2 {
+		t.Fatalf("invalid routes: %s", litter.Sdump(r))
+	}
+
+	for _, f := range r[0].Source.Filters {
+		if f.Span.Start.Line != 2 || f.Span.Start.Column != 25 {
+			t.Errorf("invalid position of the expanded filter: %v", f.Span.Start)
+		}
+	}
+}
+
+func TestFormatInvalidMacros(t *testing.T) {
+	if _, err := Format(`chain() := status(200); route1: * -> chain(42) -> <shunt>`, FormatOptions{}); err == nil {
+		t.Error("failed to fail")
+	}
+}
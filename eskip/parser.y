@@ -31,6 +31,9 @@ func convertNumber(s string) float64 {
 	routes []*parsedRoute
 	matchers []*matcher
 	matcher *matcher
+	annotations []*matcher
+	macro *macro
+	params []string
 	filter *Filter
 	filters []*Filter
 	args []interface{}
@@ -41,14 +44,21 @@ func convertNumber(s string) float64 {
 	numval float64
 	stringval string
 	regexpval string
+	start int
+	end int
+	argspans []span
+	callspan callSpan
+	filterspans []callSpan
 }
 
 %token and
 %token any
 %token arrow
+%token at
 %token closeparen
 %token colon
 %token comma
+%token define
 %token number
 %token openparen
 %token regexpliteral
@@ -77,11 +87,19 @@ routes:
 		$$.routes = []*parsedRoute{$1.route}
 	}
 	|
+	macrodef {
+		$$.routes = nil
+	}
+	|
 	routes semicolon routedef {
 		$$.routes = $1.routes
 		$$.routes = append($$.routes, $3.route)
 	}
 	|
+	routes semicolon macrodef {
+		$$.routes = $1.routes
+	}
+	|
 	routes semicolon {
 		$$.routes = $1.routes
 	}
@@ -90,6 +108,10 @@ routedef:
 	routeid colon route {
 		$$.route = $3.route
 		$$.route.id = $1.token
+		$$.route.idSpan = span{$1.start, $1.end}
+		$$.route.span = span{$1.start, $3.end}
+		$$.start = $1.start
+		$$.end = $3.end
 	}
 
 routeid:
@@ -97,13 +119,135 @@ routeid:
 		$$.token = $1.token
 	}
 
+macrodef:
+	macrohead define macrobody {
+		$$.macro = $1.macro
+		$$.macro.route = $3.route
+		$$.macro.filterChain = $3.macro.filterChain
+		$$.macro.span = span{$1.start, $3.end}
+		$$.end = $3.end
+		eskiplex.(*eskipLex).macros = append(eskiplex.(*eskipLex).macros, $$.macro)
+		$1.macro = nil
+		$3.macro = nil
+	}
+
+macrohead:
+	symbol openparen closeparen {
+		$$.macro = &macro{name: $1.token}
+		$$.end = $3.end
+	}
+	|
+	symbol openparen params closeparen {
+		$$.macro = &macro{name: $1.token, params: $3.params}
+		$$.end = $4.end
+		$3.params = nil
+	}
+
+params:
+	symbol {
+		$$.params = []string{$1.token}
+	}
+	|
+	params comma symbol {
+		$$.params = $1.params
+		$$.params = append($$.params, $3.token)
+	}
+
+macrobody:
+	frontend {
+		$$.macro = &macro{filterChain: true}
+		$$.route = &parsedRoute{
+			matchers: $1.matchers,
+			span: span{$1.start, $1.end}}
+		$$.start = $1.start
+		$$.end = $1.end
+		$1.matchers = nil
+	}
+	|
+	frontend arrow filters {
+		$$.macro = &macro{filterChain: true}
+		$$.route = &parsedRoute{
+			matchers: $1.matchers,
+			filters: $3.filters,
+			span: span{$1.start, $3.end},
+			filterSpans: $3.filterspans}
+		$$.start = $1.start
+		$$.end = $3.end
+		$1.matchers = nil
+		$3.filters = nil
+		$3.filterspans = nil
+	}
+	|
+	expression {
+		$$.macro = &macro{}
+		$$.route = $1.route
+		$$.start = $1.start
+		$$.end = $1.end
+	}
+
 route:
+	routebody {
+		$$.route = $1.route
+		$$.start = $1.start
+		$$.end = $1.end
+	}
+	|
+	annotations routebody {
+		$$.route = $2.route
+		$$.route.annotations = $1.annotations
+		$$.route.span = span{$1.start, $2.end}
+		$$.start = $1.start
+		$$.end = $2.end
+		$1.annotations = nil
+	}
+
+annotations:
+	annotation {
+		$$.annotations = []*matcher{$1.matcher}
+	}
+	|
+	annotations annotation {
+		$$.annotations = $1.annotations
+		$$.annotations = append($$.annotations, $2.matcher)
+		$$.end = $2.end
+	}
+
+annotation:
+	at call {
+		$$.matcher = $2.matcher
+		$$.matcher.source.span = span{$1.start, $2.end}
+		$$.end = $2.end
+		$2.matcher = nil
+	}
+
+routebody:
+	expression {
+		$$.route = $1.route
+		$$.start = $1.start
+		$$.end = $1.end
+	}
+	|
+	frontend {
+		$$.route = &parsedRoute{
+			matchers: $1.matchers,
+			templateCall: true,
+			span: span{$1.start, $1.end}}
+		$$.start = $1.start
+		$$.end = $1.end
+		$1.matchers = nil
+	}
+
+expression:
 	frontend arrow backend {
 		$$.route = &parsedRoute{
 			matchers: $1.matchers,
 			backend: $3.backend,
 			shunt: $3.shunt,
-			loopback: $3.loopback}
+			loopback: $3.loopback,
+			span: span{$1.start, $3.end},
+			backendSpan: span{$3.start, $3.end}}
+		$$.start = $1.start
+		$$.end = $3.end
 	}
 	|
 	frontend arrow filters arrow backend {
@@ -112,9 +256,15 @@ route:
 			filters: $3.filters,
 			backend: $5.backend,
 			shunt: $5.shunt,
-			loopback: $5.loopback}
+			loopback: $5.loopback,
+			span: span{$1.start, $5.end},
+			backendSpan: span{$5.start, $5.end},
+			filterSpans: $3.filterspans}
+		$$.start = $1.start
+		$$.end = $5.end
 		$1.matchers = nil
 		$3.filters = nil
+		$3.filterspans = nil
 	}
 
 frontend:
@@ -125,45 +275,84 @@ frontend:
 	frontend and matcher {
 		$$.matchers = $1.matchers
 		$$.matchers = append($$.matchers, $3.matcher)
+		$$.end = $3.end
 	}
 
 matcher:
-    any {
-        $$.matcher = &matcher{"*", nil}
-    }
-    |
-	symbol openparen args closeparen {
-        $$.matcher = &matcher{$1.token, $3.args}
-		$3.args = nil
+	any {
+		$$.matcher = &matcher{
+			name: "*",
+			source: callSpan{
+				span: span{$1.start, $1.end},
+				name: span{$1.start, $1.end}}}
+	}
+	|
+	call {
+		$$.matcher = $1.matcher
+		$$.end = $1.end
+		$1.matcher = nil
 	}
 
 filters:
 	filter {
 		$$.filters = []*Filter{$1.filter}
+		$$.filterspans = []callSpan{$1.callspan}
 	}
 	|
 	filters arrow filter {
 		$$.filters = $1.filters
 		$$.filters = append($$.filters, $3.filter)
+		$$.filterspans = $1.filterspans
+		$$.filterspans = append($$.filterspans, $3.callspan)
 	}
 
 filter:
-	symbol openparen args closeparen {
+	call {
 		$$.filter = &Filter{
-			Name: $1.token,
-			Args: $3.args}
+			Name: $1.matcher.name,
+			Args: $1.matcher.args}
+		$$.callspan = $1.matcher.source
+		$$.end = $1.end
+		$1.matcher = nil
+	}
+
+// the empty argument list is a separate rule, because in the first
+// position of a document, a call can be the head of a macro definition,
+// too, where the parameters are names instead of args
+call:
+	symbol openparen closeparen {
+		$$.matcher = &matcher{
+			name: $1.token,
+			source: callSpan{
+				span: span{$1.start, $3.end},
+				name: span{$1.start, $1.end}}}
+		$$.end = $3.end
+	}
+	|
+	symbol openparen args closeparen {
+		$$.matcher = &matcher{
+			name: $1.token,
+			args: $3.args,
+			source: callSpan{
+				span: span{$1.start, $4.end},
+				name: span{$1.start, $1.end},
+				args: $3.argspans}}
+		$$.end = $4.end
 		$3.args = nil
+		$3.argspans = nil
 	}
 
 args:
-	|
 	arg {
 		$$.args = []interface{}{$1.arg}
+		$$.argspans = []span{{$1.start, $1.end}}
 	}
 	|
 	args comma arg {
 		$$.args = $1.args
 		$$.args = append($$.args, $3.arg)
+		$$.argspans = $1.argspans
+		$$.argspans = append($$.argspans, span{$3.start, $3.end})
 	}
 
 arg:
@@ -178,6 +367,14 @@ arg:
 	regexpval {
 		$$.arg = $1.regexpval
 	}
+	|
+	call {
+		$$.arg = &Predicate{
+			Name: $1.matcher.name,
+			Args: $1.matcher.args}
+		$$.end = $1.end
+		$1.matcher = nil
+	}
 
 backend:
 	stringval {
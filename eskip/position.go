@@ -0,0 +1,209 @@
+package eskip
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// byte offsets in the parsed document, tracked by the parser
+type span struct {
+	start, end int
+}
+
+type callSpan struct {
+	span span
+	name span
+	args []span
+}
+
+// the offsets of the line starts, used to convert the byte offsets to
+// line and column positions
+type lineIndex []int
+
+// Position is a location in a parsed eskip document.
+type Position struct {
+
+	// Offset is the byte offset, starting from 0.
+	Offset int
+
+	// Line is the line number, starting from 1.
+	Line int
+
+	// Column is the byte offset in the line, starting from 1.
+	Column int
+}
+
+// Span is a section of a parsed eskip document. End points right
+// after the last character of the section.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// CallSource contains the positions of a predicate or a filter in the
+// parsed document.
+type CallSource struct {
+
+	// Name of the predicate or filter.
+	Name string
+
+	// Span of the whole expression, e.g. Path("/foo").
+	Span Span
+
+	// Span of the name.
+	NameSpan Span
+
+	// Spans of the arguments.
+	Args []Span
+}
+
+// RouteSource contains the positions of a route definition and its
+// parts in the parsed document.
+type RouteSource struct {
+
+	// Span of the whole route definition, including the id.
+	Span Span
+
+	// Span of the route id. Empty when the route has no id.
+	ID Span
+
+	// The predicates in the order of the route definition, including
+	// the ones that are not stored in the Predicates field of the
+	// route, like Path or Host, and the catch-all *.
+	Predicates []CallSource
+
+	// The filters in the same order as in the Filters field of the
+	// route.
+	Filters []CallSource
+
+	// Span of the backend.
+	Backend Span
+}
+
+// ParseError is returned by the parser when a routing document or an
+// expression cannot be parsed.
+type ParseError struct {
+
+	// Position of the error. In case of syntax errors, the position of
+	// the unexpected token, in case of invalid routes, the start of the
+	// route definition.
+	Position Position
+
+	// RouteID is the id of the invalid route, when the syntax was valid
+	// but the route definition was not, e.g. because of a duplicate
+	// Path predicate.
+	RouteID string
+
+	// Token is the last valid token before the error.
+	Token string
+
+	// Unexpected is the token where the syntax error was found.
+	Unexpected string
+
+	// Expected contains the tokens that would have been valid instead
+	// of the unexpected one, when there are only a few of them.
+	Expected []string
+
+	// Message describes the error.
+	Message string
+
+	// Err is the original error of an invalid route definition.
+	Err error
+}
+
+func newLineIndex(doc string) lineIndex {
+	li := lineIndex{0}
+	for i := 0; i < len(doc); i++ {
+		if doc[i] == newlineChar {
+			li = append(li, i+1)
+		}
+	}
+
+	return li
+}
+
+func (li lineIndex) position(offset int) Position {
+	line := sort.Search(len(li), func(i int) bool { return li[i] > offset }) - 1
+	if line < 0 {
+		line = 0
+	}
+
+	return Position{Offset: offset, Line: line + 1, Column: offset - li[line] + 1}
+}
+
+func (li lineIndex) span(s span) Span {
+	return Span{Start: li.position(s.start), End: li.position(s.end)}
+}
+
+func (li lineIndex) callSource(name string, cs callSpan) CallSource {
+	s := CallSource{
+		Name:     name,
+		Span:     li.span(cs.span),
+		NameSpan: li.span(cs.name),
+	}
+
+	for _, a := range cs.args {
+		s.Args = append(s.Args, li.span(a))
+	}
+
+	return s
+}
+
+func (r *parsedRoute) source(li lineIndex) *RouteSource {
+	s := &RouteSource{
+		Span:    li.span(r.span),
+		Backend: li.span(r.backendSpan),
+	}
+
+	if r.id != "" {
+		s.ID = li.span(r.idSpan)
+	}
+
+	for _, m := range r.matchers {
+		s.Predicates = append(s.Predicates, li.callSource(m.name, m.source))
+	}
+
+	for i, f := range r.filters {
+		if i < len(r.filterSpans) {
+			s.Filters = append(s.Filters, li.callSource(f.Name, r.filterSpans[i]))
+		}
+	}
+
+	return s
+}
+
+func newRouteError(doc string, r *parsedRoute, err error) *ParseError {
+	return &ParseError{
+		Position: newLineIndex(doc).position(r.span.start),
+		RouteID:  r.id,
+		Message:  err.Error(),
+		Err:      err,
+	}
+}
+
+func (e *ParseError) Error() string {
+	msg := e.Message
+	if e.Unexpected != "" {
+		msg += ", unexpected " + e.Unexpected
+	}
+
+	if len(e.Expected) > 0 {
+		msg += ", expected " + strings.Join(e.Expected, " or ")
+	}
+
+	p := e.Position
+	switch {
+	case e.Err != nil && e.RouteID != "":
+		return fmt.Sprintf("invalid route %s, line %d, column %d: %s", e.RouteID, p.Line, p.Column, msg)
+	case e.Err != nil:
+		return fmt.Sprintf("invalid route, line %d, column %d: %s", p.Line, p.Column, msg)
+	case e.Token != "":
+		return fmt.Sprintf(
+			"parse failed after token %s, position %d, line %d, column %d: %s",
+			e.Token, p.Offset, p.Line, p.Column, msg,
+		)
+	default:
+		return fmt.Sprintf("parse failed at position %d, line %d, column %d: %s", p.Offset, p.Line, p.Column, msg)
+	}
+}
@@ -0,0 +1,148 @@
+package eskip
+
+import (
+	"reflect"
+	"testing"
+)
+
+func spanText(doc string, s Span) string {
+	return doc[s.Start.Offset:s.End.Offset]
+}
+
+func TestParseWithSource(t *testing.T) {
+	const doc = `// routes
+route1: Path("/foo") && Header("X-Foo", "bar") -> "https://www.example.org";
+
+route2:
+  * ->
+  setPath("/bar") ->
+  responseHeader("X-Bar", 42) ->
+  <shunt>`
+
+	r, err := ParseWithSource(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r) != 2 {
+		t.Fatalf("failed to parse routes: %d", len(r))
+	}
+
+	s := r[0].Source
+	if s == nil {
+		t.Fatal("missing source")
+	}
+
+	if spanText(doc, s.Span) != `route1: Path("/foo") && Header("X-Foo", "bar") -> "https://www.example.org"` {
+		t.Error("invalid route span", spanText(doc, s.Span))
+	}
+
+	if s.Span.Start != (Position{Offset: 10, Line: 2, Column: 1}) {
+		t.Error("invalid route start", s.Span.Start)
+	}
+
+	if spanText(doc, s.ID) != "route1" || spanText(doc, s.Backend) != `"https://www.example.org"` {
+		t.Error("invalid id or backend span", spanText(doc, s.ID), spanText(doc, s.Backend))
+	}
+
+	if len(s.Predicates) != 2 ||
+		s.Predicates[0].Name != "Path" || spanText(doc, s.Predicates[0].Span) != `Path("/foo")` ||
+		s.Predicates[1].Name != "Header" || spanText(doc, s.Predicates[1].NameSpan) != "Header" ||
+		len(s.Predicates[1].Args) != 2 || spanText(doc, s.Predicates[1].Args[1]) != `"bar"` {
+		t.Error("invalid predicate spans", s.Predicates)
+	}
+
+	s = r[1].Source
+	if s.Span.Start.Line != 4 || s.Span.End != (Position{Offset: len(doc), Line: 8, Column: 10}) {
+		t.Error("invalid route span", s.Span)
+	}
+
+	if len(s.Predicates) != 1 || s.Predicates[0].Name != "*" || s.Predicates[0].Span.Start.Line != 5 {
+		t.Error("invalid catch-all predicate span", s.Predicates)
+	}
+
+	if len(s.Filters) != 2 ||
+		s.Filters[1].Name != "responseHeader" ||
+		s.Filters[1].Span.Start != (Position{Offset: 126, Line: 7, Column: 3}) ||
+		spanText(doc, s.Filters[1].Args[1]) != "42" {
+		t.Error("invalid filter spans", s.Filters)
+	}
+
+	if spanText(doc, s.Backend) != "<shunt>" {
+		t.Error("invalid backend span", spanText(doc, s.Backend))
+	}
+}
+
+func TestParseWithoutSource(t *testing.T) {
+	r, err := Parse(`route1: * -> <shunt>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r[0].Source != nil {
+		t.Error("unexpected source")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, test := range []struct {
+		title    string
+		doc      string
+		expected *ParseError
+		message  string
+	}{{
+		title: "syntax error",
+		doc:   "route1: * -> <shunt>;\nroute2: Path(\"/foo\") \"https://www.example.org\"",
+		expected: &ParseError{
+			Position:   Position{Offset: 43, Line: 2, Column: 22},
+			Token:      ")",
+			Unexpected: "string",
+			Message:    "syntax error",
+		},
+		message: "parse failed after token ), position 43, line 2, column 22: syntax error, unexpected string",
+	}, {
+		title: "unexpected end of input",
+		doc:   "route1: Path(\"/foo\") -> ",
+		expected: &ParseError{
+			Position:   Position{Offset: 24, Line: 1, Column: 25},
+			Token:      "->",
+			Unexpected: "end of input",
+			Expected:   []string{"<shunt>", "<loopback>", "string", "name"},
+			Message:    "syntax error",
+		},
+	}, {
+		title: "invalid token",
+		doc:   "route1: Path(\"/foo) -> <shunt>",
+		expected: &ParseError{
+			Position: Position{Offset: 13, Line: 1, Column: 14},
+			Token:    "(",
+			Message:  "incomplete token",
+		},
+	}, {
+		title: "invalid route",
+		doc:   "route1: * -> <shunt>;\n  route2: Path(\"/foo\") && Path(\"/bar\") -> <shunt>",
+		expected: &ParseError{
+			Position: Position{Offset: 24, Line: 2, Column: 3},
+			RouteID:  "route2",
+			Message:  duplicatePathTreePredicateError.Error(),
+			Err:      duplicatePathTreePredicateError,
+		},
+		message: "invalid route route2, line 2, column 3: duplicate path tree predicate",
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			_, err := ParseWithSource(test.doc)
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("failed to fail with parse error: %v", err)
+			}
+
+			if !reflect.DeepEqual(perr, test.expected) {
+				t.Errorf("unexpected error, expected: %#v, got: %#v", test.expected, perr)
+			}
+
+			if test.message != "" && perr.Error() != test.message {
+				t.Errorf("unexpected error message, expected: %s, got: %s", test.message, perr.Error())
+			}
+		})
+	}
+}
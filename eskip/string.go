@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"sort"
 	"strings"
 )
 
@@ -55,12 +56,34 @@ func argsString(args []interface{}) string {
 			sargs = appendFmt(sargs, f, a)
 		case string:
 			sargs = appendFmtEscape(sargs, `"%s"`, `"`, a)
+		case *Predicate:
+			sargs = appendFmt(sargs, "%s(%s)", v.Name, argsString(v.Args))
 		}
 	}
 
 	return strings.Join(sargs, ", ")
 }
 
+// prints the annotations ordered by their name
+func (r *Route) annotationString() string {
+	names := make([]string, 0, len(r.Annotations))
+	for name := range r.Annotations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	var annotations []string
+	for _, name := range names {
+		if r.Annotations[name] == "" {
+			annotations = appendFmt(annotations, "@%s()", name)
+		} else {
+			annotations = appendFmtEscape(annotations, `@%s("%s")`, `"`, name, r.Annotations[name])
+		}
+	}
+
+	return strings.Join(annotations, " ")
+}
+
 func (r *Route) predicateString() string {
 	var predicates []string
 
@@ -80,12 +103,25 @@ func (r *Route) predicateString() string {
 		predicates = appendFmtEscape(predicates, `Method("%s")`, `"`, r.Method)
 	}
 
-	for k, v := range r.Headers {
-		predicates = appendFmtEscape(predicates, `Header("%s", "%s")`, `"`, k, v)
+	// the header names are sorted to get a stable output
+	headerNames := make([]string, 0, len(r.Headers))
+	for k := range r.Headers {
+		headerNames = append(headerNames, k)
+	}
+
+	sort.Strings(headerNames)
+	for _, k := range headerNames {
+		predicates = appendFmtEscape(predicates, `Header("%s", "%s")`, `"`, k, r.Headers[k])
 	}
 
-	for k, rxs := range r.HeaderRegexps {
-		for _, rx := range rxs {
+	hrxNames := make([]string, 0, len(r.HeaderRegexps))
+	for k := range r.HeaderRegexps {
+		hrxNames = append(hrxNames, k)
+	}
+
+	sort.Strings(hrxNames)
+	for _, k := range hrxNames {
+		for _, rx := range r.HeaderRegexps[k] {
 			predicates = appendFmt(predicates, `HeaderRegexp("%s", /%s/)`, escape(k, `"`), escape(rx, "/"))
 		}
 	}
@@ -141,6 +177,9 @@ func (r *Route) String() string {
 
 func (r *Route) Print(prettyPrintInfo PrettyPrintInfo) string {
 	s := []string{r.predicateString()}
+	if as := r.annotationString(); as != "" {
+		s[0] = as + " " + s[0]
+	}
 
 	fs := r.filterString(prettyPrintInfo)
 	if fs != "" {
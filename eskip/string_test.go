@@ -95,6 +95,20 @@ func TestRouteString(t *testing.T) {
 			Filters:     []*Filter{{"static", []interface{}{"/some", "/file"}}},
 			BackendType: LoopBackend},
 		`Method("GET") -> static("/some", "/file") -> <loopback>`,
+	}, {
+		&Route{
+			Method:      "GET",
+			Annotations: map[string]string{"owner": `team-"x"`, "deprecated": "", "ticket": "ABC-1"},
+			Shunt:       true},
+		`@deprecated() @owner("team-\"x\"") @ticket("ABC-1") Method("GET") -> <shunt>`,
+	}, {
+		&Route{
+			Predicates: []*Predicate{{"Or", []interface{}{
+				&Predicate{"Not", []interface{}{&Predicate{"Header", []interface{}{"X-Foo", "bar"}}}},
+				&Predicate{"Traffic", []interface{}{0.3}},
+			}}},
+			Shunt: true},
+		`Or(Not(Header("X-Foo", "bar")), Traffic(0.3)) -> <shunt>`,
 	}} {
 		rstring := item.route.String()
 		if rstring != item.string {
@@ -116,6 +130,11 @@ func TestDocString(t *testing.T) {
 		`route2: Path("/some/path") -> "https://www.example.org";`)
 }
 
+func TestDocStringWithAnnotations(t *testing.T) {
+	testDoc(t, `route1: @owner("team-x") @ticket("ABC-1") Method("GET") -> <shunt>;`+"\n"+
+		`route2: @deprecated() Path("/some/path") -> "https://www.example.org";`)
+}
+
 func TestPrintNonPretty(t *testing.T) {
 	for i, item := range []struct {
 		route    string
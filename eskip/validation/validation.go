@@ -0,0 +1,396 @@
+/*
+Package validation implements the semantic validation of routes, checking
+them against the filter and predicate specifications known to skipper,
+without running the proxy.
+
+It is used by the check command of the eskip tool, and by the eskip
+language server, which maps the findings to the source ranges of the
+routes.
+*/
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/builtin"
+	predicates "github.com/zalando/skipper/predicates/builtin"
+	"github.com/zalando/skipper/routing"
+)
+
+// Severity of a finding.
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Element identifies the part of the route that a finding refers to.
+type Element int
+
+const (
+
+	// The route itself, e.g. for a repeating id or a shadowed route.
+	Route Element = iota
+
+	// A predicate with its args. Index is the index in the Predicates
+	// field of the route.
+	Predicate
+
+	// The name of a predicate, when it is not known. Index is the
+	// index in the Predicates field of the route.
+	PredicateName
+
+	// A filter with its args. Index is the index in the Filters field
+	// of the route.
+	Filter
+
+	// The name of a filter, when it is not known. Index is the index
+	// in the Filters field of the route.
+	FilterName
+
+	// The backend of the route.
+	Backend
+)
+
+// Finding is a single result of the validation.
+type Finding struct {
+	Severity Severity
+	Route    *eskip.Route
+	Element  Element
+	Index    int
+	Message  string
+}
+
+// Validator checks the routes against a filter registry and a set of
+// predicate specifications.
+type Validator struct {
+	filters    filters.Registry
+	predicates map[string]routing.PredicateSpec
+}
+
+// New creates a validator with the filters and the predicates that
+// skipper includes by default.
+func New() *Validator {
+	return NewWithSpecs(builtin.MakeRegistry(), predicates.Specs())
+}
+
+// NewWithSpecs creates a validator with custom filters and predicates.
+// The predicates implemented by the routing package, e.g. Path or
+// Host, don't need to be included.
+func NewWithSpecs(fr filters.Registry, ps []routing.PredicateSpec) *Validator {
+	v := &Validator{
+		filters:    fr,
+		predicates: make(map[string]routing.PredicateSpec),
+	}
+
+	for _, s := range ps {
+		v.predicates[s.Name()] = s
+	}
+
+	return v
+}
+
+type findings struct {
+	route *eskip.Route
+	list  []Finding
+}
+
+func (f *findings) add(s Severity, e Element, index int, format string, args ...interface{}) {
+	f.list = append(f.list, Finding{
+		Severity: s,
+		Route:    f.route,
+		Element:  e,
+		Index:    index,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *Validator) validateFilters(f *findings) {
+	for i, fi := range f.route.Filters {
+		spec, ok := v.filters[fi.Name]
+		if !ok {
+			f.add(Error, FilterName, i, "unknown filter: %s", fi.Name)
+			continue
+		}
+
+		if _, err := spec.CreateFilter(fi.Args); err != nil {
+			f.add(Error, Filter, i, "invalid filter %s: %v", fi.Name, err)
+		}
+	}
+}
+
+// the number of the string args of the predicates that are stored in
+// the fields of the route, and need to be validated only when nested
+var fieldPredicateArgs = map[string]int{
+	"Host":         1,
+	"PathRegexp":   1,
+	"Method":       1,
+	"Header":       2,
+	"HeaderRegexp": 2,
+}
+
+// validates a predicate with its spec, or, in case of Not, Or and And,
+// the nested predicates, recursively. The nested predicates don't have
+// their own index, so the errors are reported on the top level
+// predicate, except for the unknown names.
+func (v *Validator) validatePredicate(f *findings, index int, p *eskip.Predicate, nested bool) {
+	switch p.Name {
+	case routing.NotName, routing.OrName, routing.AndName:
+		if len(p.Args) == 0 || p.Name == routing.NotName && len(p.Args) != 1 {
+			f.add(Error, Predicate, index, "invalid predicate %s: invalid number of nested predicates", p.Name)
+			return
+		}
+
+		for _, a := range p.Args {
+			np, ok := a.(*eskip.Predicate)
+			if !ok {
+				f.add(Error, Predicate, index, "invalid predicate %s: expected predicate argument", p.Name)
+				continue
+			}
+
+			v.validatePredicate(f, index, np, true)
+		}
+
+		return
+	case routing.PathName, routing.PathSubtreeName, routing.WeightName:
+		f.add(Error, Predicate, index, "invalid predicate %s: it cannot be nested", p.Name)
+		return
+	}
+
+	if n, ok := fieldPredicateArgs[p.Name]; ok && nested {
+		if len(p.Args) != n {
+			f.add(Error, Predicate, index, "invalid predicate %s: expected %d arguments", p.Name, n)
+			return
+		}
+
+		for _, a := range p.Args {
+			if _, ok := a.(string); !ok {
+				f.add(Error, Predicate, index, "invalid predicate %s: expected string arguments", p.Name)
+				return
+			}
+		}
+
+		return
+	}
+
+	spec, ok := v.predicates[p.Name]
+	if !ok {
+		e := PredicateName
+		if nested {
+			e = Predicate
+		}
+
+		f.add(Error, e, index, "unknown predicate: %s", p.Name)
+		return
+	}
+
+	if _, err := spec.Create(p.Args); err != nil {
+		f.add(Error, Predicate, index, "invalid predicate %s: %v", p.Name, err)
+	}
+}
+
+func (v *Validator) validatePredicates(f *findings) {
+	var tree, weight bool
+	r := f.route
+	if r.Path != "" {
+		tree = true
+	}
+
+	for i, p := range r.Predicates {
+		switch p.Name {
+		case routing.PathName, routing.PathSubtreeName:
+			if tree {
+				f.add(Error, Predicate, i, "multiple tree predicates (Path, PathSubtree)")
+			}
+
+			tree = true
+			if len(p.Args) != 1 {
+				f.add(Error, Predicate, i, "invalid predicate %s: expected a single argument", p.Name)
+			} else if _, ok := p.Args[0].(string); !ok {
+				f.add(Error, Predicate, i, "invalid predicate %s: expected a string argument", p.Name)
+			}
+
+			continue
+		case routing.WeightName:
+			if weight {
+				f.add(Error, Predicate, i, "multiple Weight predicates")
+			}
+
+			weight = true
+			if _, ok := weightArg(p); !ok {
+				f.add(Error, Predicate, i, "invalid predicate %s: expected a single integer argument", p.Name)
+			}
+
+			continue
+		}
+
+		v.validatePredicate(f, i, p, false)
+	}
+
+	var rxs []string
+	rxs = append(rxs, r.HostRegexps...)
+	rxs = append(rxs, r.PathRegexps...)
+	for _, hrxs := range r.HeaderRegexps {
+		rxs = append(rxs, hrxs...)
+	}
+
+	for _, rx := range rxs {
+		if _, err := regexp.Compile(rx); err != nil {
+			f.add(Error, Route, 0, "invalid regular expression: %v", err)
+		}
+	}
+}
+
+// returns the integer argument of a Weight predicate
+func weightArg(p *eskip.Predicate) (int, bool) {
+	if len(p.Args) != 1 {
+		return 0, false
+	}
+
+	w, ok := p.Args[0].(float64)
+	if !ok || w != math.Trunc(w) {
+		return 0, false
+	}
+
+	return int(w), true
+}
+
+// returns the weight of a route set by the Weight predicate
+func routeWeight(r *eskip.Route) int {
+	for _, p := range r.Predicates {
+		if p.Name == routing.WeightName {
+			w, _ := weightArg(p)
+			return w
+		}
+	}
+
+	return 0
+}
+
+func validateBackend(f *findings) {
+	r := f.route
+	if r.Shunt || r.BackendType != eskip.NetworkBackend {
+		return
+	}
+
+	u, err := url.ParseRequestURI(r.Backend)
+	switch {
+	case err != nil:
+		f.add(Error, Backend, 0, "invalid backend address: %v", err)
+	case u.Scheme != "http" && u.Scheme != "https":
+		f.add(Error, Backend, 0, "invalid backend scheme: %s", r.Backend)
+	case u.Host == "":
+		f.add(Error, Backend, 0, "missing backend host: %s", r.Backend)
+	}
+}
+
+// returns the conditions of a route in a normalized form, using the
+// same model as the conflict detection of the routing, extended with the
+// path. It returns false when the route has a non-deterministic
+// predicate.
+func conditionsKey(r *eskip.Route) (string, bool) {
+	c, deterministic := routing.Conditions(r)
+	if !deterministic {
+		return "", false
+	}
+
+	var conditions []string
+	if r.Path != "" {
+		conditions = append(conditions, routing.PathName+" "+r.Path)
+	}
+
+	for _, p := range r.Predicates {
+		if p.Name != routing.PathName && p.Name != routing.PathSubtreeName || len(p.Args) != 1 {
+			continue
+		}
+
+		if path, ok := p.Args[0].(string); ok {
+			conditions = append(conditions, p.Name+" "+path)
+		}
+	}
+
+	for k := range c {
+		conditions = append(conditions, k)
+	}
+
+	sort.Strings(conditions)
+	key, err := json.Marshal(conditions)
+	return string(key), err == nil
+}
+
+// reports the routes that can never be matched, because another route
+// has exactly the same conditions. When the routes have different
+// weights, the one with the higher weight matches the requests,
+// otherwise which one of them matches is not defined, and the earlier
+// route is reported as the shadowing one. The routes with
+// non-deterministic predicates, e.g. Traffic, are not checked.
+func shadowedRoutes(routes []*eskip.Route) map[*eskip.Route]*eskip.Route {
+	shadowed := make(map[*eskip.Route]*eskip.Route)
+	byKey := make(map[string]*eskip.Route)
+	for _, r := range routes {
+		key, ok := conditionsKey(r)
+		if !ok {
+			continue
+		}
+
+		if first, ok := byKey[key]; ok {
+			if routeWeight(r) > routeWeight(first) {
+				for s, by := range shadowed {
+					if by == first {
+						shadowed[s] = r
+					}
+				}
+
+				shadowed[first] = r
+				byKey[key] = r
+				continue
+			}
+
+			shadowed[r] = first
+			continue
+		}
+
+		byKey[key] = r
+	}
+
+	return shadowed
+}
+
+// Validate checks the routes, and returns the findings in the order of
+// the routes. The shadowed routes are reported as warnings, after the
+// errors.
+func (v *Validator) Validate(routes []*eskip.Route) []Finding {
+	var all []Finding
+	ids := make(map[string]bool)
+	for _, r := range routes {
+		f := &findings{route: r}
+		if r.Id != "" && ids[r.Id] {
+			f.add(Error, Route, 0, "repeating route id: %s", r.Id)
+		}
+
+		ids[r.Id] = true
+		v.validatePredicates(f)
+		v.validateFilters(f)
+		validateBackend(f)
+		all = append(all, f.list...)
+	}
+
+	shadowed := shadowedRoutes(routes)
+	for _, r := range routes {
+		if by, ok := shadowed[r]; ok {
+			f := &findings{route: r}
+			f.add(Warning, Route, 0, "route is shadowed by %s, it has the same conditions", by.Id)
+			all = append(all, f.list...)
+		}
+	}
+
+	return all
+}
@@ -0,0 +1,103 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		title    string
+		doc      string
+		expected []Finding
+	}{{
+		title: "valid",
+		doc: `
+			health: Path("/health") -> status(204) -> <shunt>;
+			api: PathSubtree("/api") && Traffic(0.3) -> setRequestHeader("X-Foo", "bar") -> "https://api.example.org";
+			weighted: Path("/ready") && Weight(2) -> <shunt>;
+			beta: Or(Not(Host(/^www[.]/)), And(Header("X-Beta", "true"), Traffic(0.1))) -> <shunt>`,
+	}, {
+		title: "unknown and invalid predicates",
+		doc:   `route1: Foo() && Cookie("foo") && Not(Bar()) && Weight(1.5) -> <shunt>`,
+		expected: []Finding{
+			{Severity: Error, Element: PredicateName, Index: 0, Message: "unknown predicate: Foo"},
+			{Severity: Error, Element: Predicate, Index: 1},
+			{Severity: Error, Element: Predicate, Index: 2, Message: "unknown predicate: Bar"},
+			{Severity: Error, Element: Predicate, Index: 3, Message: "invalid predicate Weight: expected a single integer argument"},
+		},
+	}, {
+		title: "nested tree predicate",
+		doc:   `route1: Method("GET") && Not(PathSubtree("/foo")) -> <shunt>`,
+		expected: []Finding{
+			{Severity: Error, Element: Predicate, Index: 0, Message: "invalid predicate PathSubtree: it cannot be nested"},
+		},
+	}, {
+		title: "filters and backend",
+		doc:   `route1: * -> setPath() -> noSuchFilter() -> "ftp://www.example.org"`,
+		expected: []Finding{
+			{Severity: Error, Element: Filter, Index: 0},
+			{Severity: Error, Element: FilterName, Index: 1, Message: "unknown filter: noSuchFilter"},
+			{Severity: Error, Element: Backend, Message: "invalid backend scheme: ftp://www.example.org"},
+		},
+	}, {
+		title: "repeating id and shadowed route",
+		doc: `
+			route1: Path("/foo") -> <shunt>;
+			route2: Path("/foo") && Weight(2) -> <shunt>;
+			route2: Path("/bar") -> <shunt>`,
+		expected: []Finding{
+			{Severity: Error, Element: Route, Message: "repeating route id: route2"},
+			{Severity: Warning, Element: Route, Message: "route is shadowed by route2, it has the same conditions"},
+		},
+	}, {
+		title: "same conditions in a different order",
+		doc: `
+			route1: Path("/foo") && Header("X-B", "2") && Header("x-a", "1") -> <shunt>;
+			route2: Header("X-A", "1") && Path("/foo") && Header("X-B", "2") -> <shunt>`,
+		expected: []Finding{
+			{Severity: Warning, Element: Route, Message: "route is shadowed by route1, it has the same conditions"},
+		},
+	}, {
+		title: "not shadowed by non-deterministic predicates",
+		doc: `
+			route1: Path("/foo") && Traffic(0.3) -> <shunt>;
+			route2: Path("/foo") && Traffic(0.3) -> <shunt>;
+			route3: PathSubtree("/bar") && Not(Traffic(0.5)) -> <shunt>;
+			route4: PathSubtree("/bar") && Not(Traffic(0.5)) -> <shunt>`,
+	}, {
+		title: "conditions with separators in the args",
+		doc: `
+			route1: Header("X-A", "a && b") && Header("X-C", "d && c") -> <shunt>;
+			route2: Header("X-A", "a && c") && Header("X-C", "d && b") -> <shunt>`,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			routes, err := eskip.Parse(test.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			findings := New().Validate(routes)
+			if len(findings) != len(test.expected) {
+				t.Fatalf("invalid number of findings, expected: %d, got: %d: %v", len(test.expected), len(findings), findings)
+			}
+
+			for i, f := range findings {
+				if f.Route == nil {
+					t.Errorf("missing route of finding: %v", f)
+				}
+
+				f.Route = nil
+				if test.expected[i].Message == "" {
+					f.Message = ""
+				}
+
+				if !reflect.DeepEqual(f, test.expected[i]) {
+					t.Errorf("invalid finding, expected: %v, got: %v", test.expected[i], f)
+				}
+			}
+		})
+	}
+}
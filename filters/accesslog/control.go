@@ -0,0 +1,75 @@
+package accesslog
+
+import (
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/logging"
+)
+
+const (
+	// DisableName is the name of the filter that disables the access
+	// log for all or for selected response statuses.
+	DisableName = "disableAccessLog"
+
+	// EnableName is the name of the filter that enables the access
+	// log for all or only for selected response statuses.
+	EnableName = "enableAccessLog"
+)
+
+type controlSpec struct {
+	enable bool
+}
+
+type controlFilter struct {
+	filter logging.AccessLogFilter
+}
+
+// NewDisable creates a filter specification for the disableAccessLog
+// filter. Without arguments, no access log entries are written for the
+// requests matching the route. The optional arguments are status code
+// prefixes, e.g. 2 for all the 2xx statuses, 30 for 300-309, or 404,
+// and then only the entries with the matching statuses are left out.
+// Example:
+//
+//	health: Path("/health") -> disableAccessLog(2, 30) -> "https://www.example.org";
+func NewDisable() filters.Spec { return &controlSpec{} }
+
+// NewEnable creates a filter specification for the enableAccessLog
+// filter. Without arguments, the access log entries of all the
+// requests matching the route are written, regardless of the sampling
+// ratio of the access log. The optional arguments are status code
+// prefixes, and then only the entries with the matching statuses are
+// written. Example:
+//
+//	r: * -> enableAccessLog(4, 5) -> "https://www.example.org";
+func NewEnable() filters.Spec { return &controlSpec{enable: true} }
+
+func (s *controlSpec) Name() string {
+	if s.enable {
+		return EnableName
+	}
+
+	return DisableName
+}
+
+func (s *controlSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	f := logging.AccessLogFilter{Enable: s.enable}
+	for _, a := range args {
+		v, ok := a.(float64)
+		if !ok || v < 1 || v > 999 || v != float64(int(v)) {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.Prefixes = append(f.Prefixes, int(v))
+	}
+
+	return &controlFilter{filter: f}, nil
+}
+
+// Request stores the access log settings of the route in the state bag.
+// When multiple instances are used on the same route, the last one
+// wins.
+func (f *controlFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[logging.AccessLogFilterStateKey] = f.filter
+}
+
+func (f *controlFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,53 @@
+package accesslog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/logging"
+)
+
+func TestControlArgs(t *testing.T) {
+	for _, args := range [][]interface{}{
+		{"2"},
+		{0.0},
+		{1000.0},
+		{2.5},
+	} {
+		if _, err := NewDisable().CreateFilter(args); err != filters.ErrInvalidFilterParameters {
+			t.Errorf("failed to fail: %v", args)
+		}
+	}
+}
+
+func TestControl(t *testing.T) {
+	for _, ti := range []struct {
+		spec     filters.Spec
+		args     []interface{}
+		expected logging.AccessLogFilter
+	}{{
+		spec:     NewDisable(),
+		expected: logging.AccessLogFilter{},
+	}, {
+		spec:     NewDisable(),
+		args:     []interface{}{2.0, 404.0},
+		expected: logging.AccessLogFilter{Prefixes: []int{2, 404}},
+	}, {
+		spec:     NewEnable(),
+		args:     []interface{}{5.0},
+		expected: logging.AccessLogFilter{Enable: true, Prefixes: []int{5}},
+	}} {
+		f, err := ti.spec.CreateFilter(ti.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := &filtertest.Context{FStateBag: make(map[string]interface{})}
+		f.Request(ctx)
+		if got := ctx.FStateBag[logging.AccessLogFilterStateKey]; !reflect.DeepEqual(got, ti.expected) {
+			t.Errorf("%s: unexpected settings, expected: %v, got: %v", ti.spec.Name(), ti.expected, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Package accesslog provides filters to control the access log entries of
+the requests matched by a route: to mask or omit fields, or to write the
+entries only for selected response statuses.
+*/
+package accesslog
+
+import (
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/logging"
+)
+
+const (
+	// MaskFieldsName is the name of the filter that masks the values
+	// of access log fields.
+	MaskFieldsName = "maskAccessLogFields"
+
+	// OmitFieldsName is the name of the filter that leaves fields out
+	// of the access log entries.
+	OmitFieldsName = "omitAccessLogFields"
+)
+
+type fieldsSpec struct {
+	name string
+	key  string
+}
+
+type fieldsFilter struct {
+	key    string
+	fields []string
+}
+
+// NewMaskFields creates a filter specification for the
+// maskAccessLogFields filter. The arguments are the names of the access
+// log fields, whose values are replaced with logging.MaskedValue in the
+// entries of the requests matching the route. Example:
+//
+//	r: * -> maskAccessLogFields("uri", "request-header:Authorization") -> "https://www.example.org";
+func NewMaskFields() filters.Spec {
+	return &fieldsSpec{name: MaskFieldsName, key: logging.MaskedFieldsStateKey}
+}
+
+// NewOmitFields creates a filter specification for the
+// omitAccessLogFields filter. The arguments are the names of the access
+// log fields that are left out from the entries of the requests
+// matching the route. In the text format, the omitted fields are
+// printed as "-". Example:
+//
+//	r: * -> omitAccessLogFields("user-agent", "referer") -> "https://www.example.org";
+func NewOmitFields() filters.Spec {
+	return &fieldsSpec{name: OmitFieldsName, key: logging.OmittedFieldsStateKey}
+}
+
+func (s *fieldsSpec) Name() string { return s.name }
+
+func (s *fieldsSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	fields := make([]string, len(args))
+	for i, a := range args {
+		f, ok := a.(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		fields[i] = f
+	}
+
+	fields, err := logging.NormalizeFields(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fieldsFilter{key: s.key, fields: fields}, nil
+}
+
+// Request appends the fields to the ones already set in the state bag,
+// e.g. by a previous instance of the filter.
+func (f *fieldsFilter) Request(ctx filters.FilterContext) {
+	current, _ := ctx.StateBag()[f.key].([]string)
+	ctx.StateBag()[f.key] = append(current[:len(current):len(current)], f.fields...)
+}
+
+func (f *fieldsFilter) Response(filters.FilterContext) {}
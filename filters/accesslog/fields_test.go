@@ -0,0 +1,50 @@
+package accesslog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/logging"
+)
+
+func TestFieldsArgs(t *testing.T) {
+	for _, args := range [][]interface{}{
+		nil,
+		{42},
+		{"host", "foo"},
+		{"request-header:"},
+	} {
+		if _, err := NewMaskFields().CreateFilter(args); err == nil {
+			t.Errorf("failed to fail: %v", args)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	create := func(s filters.Spec, args ...interface{}) filters.Filter {
+		f, err := s.CreateFilter(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return f
+	}
+
+	ctx := &filtertest.Context{FStateBag: make(map[string]interface{})}
+	create(NewMaskFields(), "uri", "request-header:authorization").Request(ctx)
+	create(NewMaskFields(), "referer").Request(ctx)
+	create(NewOmitFields(), "user-agent").Request(ctx)
+
+	if masked := ctx.FStateBag[logging.MaskedFieldsStateKey]; !reflect.DeepEqual(
+		masked,
+		[]string{"uri", "request-header:Authorization", "referer"},
+	) {
+		t.Errorf("invalid masked fields: %v", masked)
+	}
+
+	if omitted := ctx.FStateBag[logging.OmittedFieldsStateKey]; !reflect.DeepEqual(omitted, []string{"user-agent"}) {
+		t.Errorf("invalid omitted fields: %v", omitted)
+	}
+}
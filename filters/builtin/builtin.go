@@ -5,6 +5,7 @@ package builtin
 
 import (
 	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/accesslog"
 	"github.com/zalando/skipper/filters/auth"
 	"github.com/zalando/skipper/filters/circuit"
 	"github.com/zalando/skipper/filters/cookie"
@@ -13,6 +14,7 @@ import (
 	"github.com/zalando/skipper/filters/flowid"
 	"github.com/zalando/skipper/filters/ratelimit"
 	"github.com/zalando/skipper/filters/tee"
+	"github.com/zalando/skipper/filters/tracing"
 	"github.com/zalando/skipper/loadbalancer"
 	"github.com/zalando/skipper/script"
 )
@@ -103,6 +105,11 @@ func MakeRegistry() filters.Registry {
 		loadbalancer.NewDecide(),
 		script.NewLuaScript(),
 		cors.NewOrigin(),
+		tracing.NewSampling(),
+		accesslog.NewMaskFields(),
+		accesslog.NewOmitFields(),
+		accesslog.NewDisable(),
+		accesslog.NewEnable(),
 	} {
 		r.Register(s)
 	}
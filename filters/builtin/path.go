@@ -2,6 +2,7 @@ package builtin
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/filters"
@@ -19,11 +20,20 @@ type modPath struct {
 	rx          *regexp.Regexp
 	replacement string
 	template    *eskip.Template
+	groups      map[string]bool
 }
 
 // Returns a new modpath filter Spec, whose instances execute
 // regexp.ReplaceAllString on the request path. Instances expect two
 // parameters: the expression to match and the replacement string.
+//
+// The ${param} placeholders of the replacement, matching the name of a
+// path parameter, are replaced with the value of the parameter before
+// the replacement is applied, taken literally. The other placeholders,
+// and the ones matching the name of a group of the expression, are left
+// for regexp.ReplaceAllString, referring to the groups of the
+// expression.
+//
 // Name: "modpath".
 func NewModPath() filters.Spec { return &modPath{behavior: regexpReplace} }
 
@@ -34,7 +44,8 @@ func NewModPath() filters.Spec { return &modPath{behavior: regexpReplace} }
 // to apply template operations. The current solution supports templates
 // with placeholders of the format: ${param1}, and the placeholders will
 // be replaced with the values of the same name from the wildcards in the
-// Path() predicate.
+// Path() predicate, or from the named capture groups of the PathRegexp()
+// and Host() predicates.
 //
 // See: https://godoc.org/github.com/zalando/skipper/routing#hdr-Wildcards
 //
@@ -81,7 +92,18 @@ func createModPath(config []interface{}) (filters.Filter, error) {
 		return nil, err
 	}
 
-	return &modPath{behavior: regexpReplace, rx: rx, replacement: replacement}, nil
+	f := &modPath{behavior: regexpReplace, rx: rx, replacement: replacement}
+	if strings.Contains(replacement, "${") {
+		f.template = eskip.NewTemplate(replacement)
+		f.groups = make(map[string]bool)
+		for _, name := range rx.SubexpNames() {
+			if name != "" {
+				f.groups[name] = true
+			}
+		}
+	}
+
+	return f, nil
 }
 
 func createSetPath(config []interface{}) (filters.Filter, error) {
@@ -114,7 +136,23 @@ func (f *modPath) Request(ctx filters.FilterContext) {
 	req := ctx.Request()
 	switch f.behavior {
 	case regexpReplace:
-		req.URL.Path = f.rx.ReplaceAllString(req.URL.Path, f.replacement)
+		replacement := f.replacement
+		if f.template != nil {
+			replacement = f.template.Apply(func(name string) string {
+				if f.groups[name] {
+					return "${" + name + "}"
+				}
+
+				// the value must not be expanded by ReplaceAllString
+				if v := ctx.PathParam(name); v != "" {
+					return strings.Replace(v, "$", "$$", -1)
+				}
+
+				return "${" + name + "}"
+			})
+		}
+
+		req.URL.Path = f.rx.ReplaceAllString(req.URL.Path, replacement)
 	case fullReplace:
 		req.URL.Path = f.template.Apply(ctx.PathParam)
 	default:
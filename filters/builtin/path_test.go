@@ -39,6 +39,44 @@ func TestModifyPathWithInvalidExpression(t *testing.T) {
 	}
 }
 
+func TestModifyPathWithTemplate(t *testing.T) {
+	spec := NewModPath()
+	f, err := spec.CreateFilter([]interface{}{"^/api/([^/]+)/(.*)", "/${version}/$1/${2}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org/api/users/42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &filtertest.Context{FRequest: req, FParams: map[string]string{"version": "v2"}}
+	f.Request(ctx)
+	if req.URL.Path != "/v2/users/42" {
+		t.Error("failed to transform path", req.URL.Path)
+	}
+}
+
+func TestModifyPathWithTemplateLiteralValues(t *testing.T) {
+	spec := NewModPath()
+	f, err := spec.CreateFilter([]interface{}{"^/api/(?P<resource>[^/]+)/(.*)", "/${version}/${resource}/$2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org/api/users/42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &filtertest.Context{FRequest: req, FParams: map[string]string{"version": "v$1", "resource": "orders"}}
+	f.Request(ctx)
+	if req.URL.Path != "/v$1/users/42" {
+		t.Error("failed to transform path", req.URL.Path)
+	}
+}
+
 func TestSetPath(t *testing.T) {
 	spec := NewSetPath()
 	f, err := spec.CreateFilter([]interface{}{"/baz/qux"})
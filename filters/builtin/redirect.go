@@ -19,6 +19,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/filters"
 )
 
@@ -35,6 +36,7 @@ type redirect struct {
 	typ      redirectType
 	code     int
 	location *url.URL
+	template *eskip.Template
 }
 
 // NewRedirect returns a new filter Spec, whose instances create an HTTP redirect
@@ -49,7 +51,10 @@ func NewRedirect() filters.Spec { return &redirect{typ: redDeprecated} }
 // response. It shunts the request flow, meaning that the filter chain on
 // the request path is not continued. The request is not forwarded to the
 // backend. Instances expect two parameters: the redirect status code and
-// the redirect location.
+// the redirect location. The location can contain ${param} placeholders,
+// that are replaced with the values of the path parameters, e.g. the
+// wildcards of the Path() predicate, or the named capture groups of the
+// PathRegexp() and Host() predicates.
 // Name: "redirectTo".
 func NewRedirectTo() filters.Spec { return &redirect{typ: redTo} }
 
@@ -91,12 +96,35 @@ func (spec *redirect) CreateFilter(config []interface{}) (filters.Filter, error)
 		return invalidArgs()
 	}
 
+	// the location with placeholders is validated with empty values,
+	// and it is used this way when the parameters give an invalid url
+	var template *eskip.Template
+	if strings.Contains(location, "${") {
+		template = eskip.NewTemplate(location)
+		location = template.Apply(func(string) string { return "" })
+	}
+
 	u, err := url.Parse(location)
 	if err != nil {
 		return invalidArgs()
 	}
 
-	return &redirect{spec.typ, int(code), u}, nil
+	return &redirect{typ: spec.typ, code: int(code), location: u, template: template}, nil
+}
+
+// returns the location with the placeholders replaced by the path
+// parameters
+func (spec *redirect) locationURL(ctx filters.FilterContext) *url.URL {
+	if spec.template == nil {
+		return spec.location
+	}
+
+	u, err := url.Parse(spec.template.Apply(ctx.PathParam))
+	if err != nil {
+		return spec.location
+	}
+
+	return u
 }
 
 func getRequestHost(r *http.Request) string {
@@ -167,7 +195,7 @@ func (spec *redirect) Request(ctx filters.FilterContext) {
 		return
 	}
 
-	redirectWithType(ctx, spec.code, spec.location, spec.typ)
+	redirectWithType(ctx, spec.code, spec.locationURL(ctx), spec.typ)
 }
 
 // Sets the status code and the location header of the response. Marks the
@@ -177,7 +205,7 @@ func (spec *redirect) Response(ctx filters.FilterContext) {
 		return
 	}
 
-	u := getLocation(ctx, spec.location, spec.typ)
+	u := getLocation(ctx, spec.locationURL(ctx), spec.typ)
 	w := ctx.ResponseWriter()
 	w.Header().Set("Location", u)
 	w.WriteHeader(spec.code)
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/filtertest"
 	"github.com/zalando/skipper/logging/loggingtest"
 	"github.com/zalando/skipper/proxy"
 	"github.com/zalando/skipper/routing"
@@ -225,3 +226,20 @@ func TestRedirectLower(t *testing.T) {
 		}
 	}
 }
+
+func TestRedirectWithTemplate(t *testing.T) {
+	f, err := NewRedirectTo().CreateFilter([]interface{}{float64(http.StatusFound), "https://${tenant}.example.org/users/${id}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &filtertest.Context{
+		FRequest: &http.Request{URL: &url.URL{Path: "/u/42"}, Host: "www.example.org"},
+		FParams:  map[string]string{"tenant": "acme", "id": "42"},
+	}
+
+	f.Request(ctx)
+	if l := ctx.FResponse.Header.Get("Location"); l != "https://acme.example.org/users/42" {
+		t.Error("invalid location", l)
+	}
+}
@@ -53,5 +53,18 @@ generating the default response.
 
 If a filter replaces the response body, it is the filter's responsibility to
 close the original body.
+
+
+Releasing Filter Resources
+
+Filter instances holding resources of their own implement FilterCloser, and
+they are closed by the routing when no routing table uses them anymore. Of
+the built-in filters, only the lua script filter needs it, for its pool of
+interpreter states. The goroutines started by the other built-in filters,
+e.g. tee, serve or the diagnostic filters, belong to a single request, and
+they finish with it. The shared resources, like the HTTP client of tee, the
+encoder pools of compress, or the rate limit and circuit breaker registries,
+belong to the filter specifications or to the proxy, and they are released
+together with those.
 */
 package filters
@@ -109,6 +109,19 @@ type Filter interface {
 	Response(FilterContext)
 }
 
+// FilterCloser is an optional interface of the filters that hold
+// resources, e.g. goroutines, connection pools or caches, that need to
+// be released when they are not used anymore. Close is called by the
+// routing once, when no routing table uses the filter instance anymore,
+// including the tables kept in the history for rollback, and the
+// in-flight requests had time to finish. This means that the instance of
+// a removed or replaced route is closed only after the table containing
+// it dropped out of the history.
+type FilterCloser interface {
+	Filter
+	Close()
+}
+
 // Spec objects are specifications for filters. When initializing the routes,
 // the Filter instances are created using the Spec objects found in the
 // registry.
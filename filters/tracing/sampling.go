@@ -0,0 +1,72 @@
+/*
+Package tracing provides filters to control the tracing of the
+requests.
+*/
+package tracing
+
+import (
+	"math/rand"
+
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/zalando/skipper/filters"
+)
+
+// SamplingName is the name of the filter that overrides the head-based
+// sampling decision of the tracer for the requests of a route.
+const SamplingName = "tracingSampling"
+
+type samplingSpec struct{}
+
+type sampling struct {
+	ratio float64
+}
+
+// NewSampling creates a filter specification for the tracingSampling
+// filter. The filter expects a single argument: the ratio of the sampled
+// requests, between 0 and 1. It sets the standard sampling.priority tag
+// on the ingress span of the request, which the tracers supporting it
+// use to sample or to drop the trace. Example:
+//
+//	r: Path("/api") -> tracingSampling(0.05) -> "https://api.example.org";
+func NewSampling() filters.Spec { return samplingSpec{} }
+
+func (samplingSpec) Name() string { return SamplingName }
+
+func (samplingSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var ratio float64
+	switch v := args[0].(type) {
+	case float64:
+		ratio = v
+	case int:
+		ratio = float64(v)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if ratio < 0 || ratio > 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return sampling{ratio: ratio}, nil
+}
+
+func (s sampling) Request(ctx filters.FilterContext) {
+	span := ot.SpanFromContext(ctx.Request().Context())
+	if span == nil {
+		return
+	}
+
+	var priority uint16
+	if s.ratio >= 1 || rand.Float64() < s.ratio {
+		priority = 1
+	}
+
+	ext.SamplingPriority.Set(span, priority)
+}
+
+func (sampling) Response(filters.FilterContext) {}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestSamplingArgs(t *testing.T) {
+	for _, args := range [][]interface{}{
+		nil,
+		{"0.5"},
+		{-0.1},
+		{1.5},
+		{0.5, 0.5},
+	} {
+		if _, err := NewSampling().CreateFilter(args); err != filters.ErrInvalidFilterParameters {
+			t.Errorf("failed to fail: %v", args)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	for _, ti := range []struct {
+		ratio    float64
+		expected bool
+	}{
+		{0, false},
+		{1, true},
+	} {
+		f, err := NewSampling().CreateFilter([]interface{}{ti.ratio})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mt := mocktracer.New()
+		span := mt.StartSpan("ingress")
+		req := httptest.NewRequest("GET", "https://www.example.org", nil)
+		req = req.WithContext(ot.ContextWithSpan(req.Context(), span))
+
+		f.Request(&filtertest.Context{FRequest: req})
+		span.Finish()
+
+		// the mock tracer applies the sampling.priority tag to the span context
+		if s := mt.FinishedSpans()[0].SpanContext.Sampled; s != ti.expected {
+			t.Errorf("invalid sampling decision for %v, expected: %v, got: %v", ti.ratio, ti.expected, s)
+		}
+	}
+}
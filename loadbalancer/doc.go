@@ -21,6 +21,16 @@ Eskip example:
 	        -> "http://127.0.0.1:12347";
 
 
+Package loadbalancer also implements session affinity for the load
+balanced groups (see Sticky). When enabled in the proxy, the first
+response of a group sets a cookie, signed with a secret, identifying the
+backend of the member that served the request. The subsequent requests
+carrying the cookie skip the round-robin decision and are routed to the
+same member. When the pinned member is not in the routing table anymore,
+e.g. because it was removed or filtered out as unhealthy, the request is
+routed to the next member in the round-robin order of lbDecide, and the
+cookie is updated.
+
 Package loadbalancer also implements health checking of pool members for
 a group of routes, if backend calls are reported to the loadbalancer.
 
@@ -10,6 +10,10 @@ const DecideFilterName = "lbDecide"
 
 const decisionHeader = "X-Load-Balancer-Member"
 
+// state bag key of the round-robin steps skipped for the pinned groups,
+// stored as a map of group names to functions advancing the counter
+const decisionStateKey = "loadbalancer:decision"
+
 type counter chan int
 
 type decideSpec struct{}
@@ -77,7 +81,24 @@ func (s *decideSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
 }
 
 func (f *decideFilter) Request(ctx filters.FilterContext) {
-	current := f.counter.inc(f.size)
+	// when the request is pinned to a member of the group, the proxy
+	// overrides the decision, so we don't move the round-robin counter.
+	// When the pinned member is gone, the proxy takes the skipped step
+	// instead, see Sticky.Fallback.
+	var current int
+	if pins, ok := ctx.StateBag()[StickyStateKey].(map[string]string); ok && pins[f.group] != "" {
+		current = f.counter.value() % f.size
+		decisions, ok := ctx.StateBag()[decisionStateKey].(map[string]func() int)
+		if !ok {
+			decisions = make(map[string]func() int)
+			ctx.StateBag()[decisionStateKey] = decisions
+		}
+
+		decisions[f.group] = func() int { return f.counter.inc(f.size) }
+	} else {
+		current = f.counter.inc(f.size)
+	}
+
 	ctx.Request().Header.Set(decisionHeader, fmt.Sprintf("%s=%d", f.group, current))
 }
 
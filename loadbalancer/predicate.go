@@ -42,6 +42,24 @@ func getGroupDecision(h http.Header, group string) (string, bool) {
 	return "", false
 }
 
+// returns the index of a member route, as set by its LBMember predicate
+func memberIndex(r *routing.Route) (int, bool) {
+	for _, p := range r.Route.Predicates {
+		if p.Name != MemberPredicateName || len(p.Args) != 2 {
+			continue
+		}
+
+		switch index := p.Args[1].(type) {
+		case int:
+			return index, true
+		case float64:
+			return int(index), true
+		}
+	}
+
+	return 0, false
+}
+
 // NewGroup creates a predicate spec identifying the entry route
 // of a load balanced route group. E.g. eskip: LBGroup("my-group")
 // where the single mandatory string argument is the name of the
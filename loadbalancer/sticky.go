@@ -0,0 +1,223 @@
+package loadbalancer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	// DefaultStickyCookieName is the prefix of the session affinity
+	// cookies, when no custom name is set.
+	DefaultStickyCookieName = "skipper-lb"
+
+	// StickyStateKey is the state bag key where the proxy stores the
+	// verified session affinity pins of the incoming request, as a
+	// map of group names to backend addresses. The decision filter
+	// uses it to skip the round-robin step for pinned groups.
+	StickyStateKey = "loadbalancer:sticky"
+)
+
+// StickyOptions configure session affinity for the load balanced
+// routes.
+type StickyOptions struct {
+
+	// CookieName is used as the prefix of the name of the cookies set
+	// by the proxy. The name of the group is hashed and appended to
+	// it, so that each load balanced group has its own cookie.
+	// Defaults to DefaultStickyCookieName.
+	CookieName string
+
+	// Secret is used to sign the cookie values with HMAC-SHA256. It
+	// is mandatory, and it needs to be the same on all the instances
+	// sharing the clients.
+	Secret []byte
+
+	// MaxAge of the cookies. When not set, session cookies are used.
+	MaxAge time.Duration
+
+	// Secure sets the Secure attribute of the cookies.
+	Secure bool
+}
+
+// Sticky implements session affinity for load balanced route groups.
+// Once a client was served by a member of a group, the proxy sets a
+// signed cookie identifying the backend of the member, and the
+// subsequent requests carrying the cookie are routed to the same
+// member, as long as it is present in the routing table. When the
+// pinned member disappears, e.g. because it was removed or it was
+// filtered out as unhealthy, the request is routed to the next member
+// in the round-robin order, and the cookie is updated.
+type Sticky struct {
+	cookieName string
+	secret     []byte
+	maxAge     time.Duration
+	secure     bool
+}
+
+// NewSticky creates a session affinity implementation, to be used by
+// the proxy. It returns nil when no secret is provided.
+func NewSticky(o StickyOptions) *Sticky {
+	if len(o.Secret) == 0 {
+		return nil
+	}
+
+	if o.CookieName == "" {
+		o.CookieName = DefaultStickyCookieName
+	}
+
+	return &Sticky{
+		cookieName: o.CookieName,
+		secret:     o.Secret,
+		maxAge:     o.MaxAge,
+		secure:     o.Secure,
+	}
+}
+
+func (s *Sticky) name(group string) string {
+	h := fnv.New32a()
+	h.Write([]byte(group))
+	return fmt.Sprintf("%s-%08x", s.cookieName, h.Sum32())
+}
+
+func (s *Sticky) sign(v string) string {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte(v))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func (s *Sticky) encode(group, backend string) string {
+	v := base64.RawURLEncoding.EncodeToString([]byte(group + "\n" + backend))
+	return v + "." + s.sign(v)
+}
+
+func (s *Sticky) decode(value string) (group, backend string, ok bool) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 2 {
+		return
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(s.sign(parts[0]))) {
+		return
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return
+	}
+
+	gb := strings.SplitN(string(b), "\n", 2)
+	if len(gb) != 2 {
+		return
+	}
+
+	return gb[0], gb[1], true
+}
+
+// Pins returns the groups and the backends that the request is pinned
+// to by valid session affinity cookies. Cookies with an invalid
+// signature are ignored.
+func (s *Sticky) Pins(req *http.Request) map[string]string {
+	var pins map[string]string
+	for _, c := range req.Cookies() {
+		if !strings.HasPrefix(c.Name, s.cookieName+"-") {
+			continue
+		}
+
+		group, backend, ok := s.decode(c.Value)
+		if !ok || c.Name != s.name(group) {
+			continue
+		}
+
+		if pins == nil {
+			pins = make(map[string]string)
+		}
+
+		pins[group] = backend
+	}
+
+	return pins
+}
+
+// Select returns the member of the load balanced group of the
+// current route that the request is pinned to. It returns nil, if
+// the route is not load balanced, the request is not pinned, or the
+// pinned member is not in the group anymore.
+func (s *Sticky) Select(pins map[string]string, r *routing.Route) *routing.Route {
+	if !r.IsLoadBalanced || r.Head == nil {
+		return nil
+	}
+
+	backend, ok := pins[r.Group]
+	if !ok {
+		return nil
+	}
+
+	for m := r.Head; m != nil; m = m.Next {
+		if m.Backend == backend {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// Fallback returns the member of the load balanced group of the
+// current route that the request is routed to, when the request is
+// pinned to a member that is not in the group anymore. The decision
+// filter skips the round-robin step for the pinned requests, and
+// Fallback takes it instead, so that these requests are distributed
+// the same way as the ones without a pin. It returns the current
+// route, if the decision was not skipped, or when the selected member
+// is not in the group.
+func (s *Sticky) Fallback(state map[string]interface{}, r *routing.Route) *routing.Route {
+	if !r.IsLoadBalanced || r.Head == nil {
+		return r
+	}
+
+	decisions, _ := state[decisionStateKey].(map[string]func() int)
+	next, ok := decisions[r.Group]
+	if !ok {
+		return r
+	}
+
+	delete(decisions, r.Group)
+	index := next()
+	for m := r.Head; m != nil; m = m.Next {
+		if i, ok := memberIndex(m); ok && i == index {
+			return m
+		}
+	}
+
+	return r
+}
+
+// SetCookie sets the session affinity cookie in the response header,
+// pinning the client to the backend of the current route, unless it
+// is already pinned to it.
+func (s *Sticky) SetCookie(h http.Header, pins map[string]string, r *routing.Route) {
+	if !r.IsLoadBalanced || pins[r.Group] == r.Backend {
+		return
+	}
+
+	c := &http.Cookie{
+		Name:     s.name(r.Group),
+		Value:    s.encode(r.Group, r.Backend),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   s.secure,
+	}
+
+	if s.maxAge > 0 {
+		c.MaxAge = int(s.maxAge / time.Second)
+	}
+
+	h.Add("Set-Cookie", c.String())
+}
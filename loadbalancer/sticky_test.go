@@ -0,0 +1,168 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+func stickyGroup(group string, backends ...string) []*routing.Route {
+	var routes []*routing.Route
+	for _, b := range backends {
+		r := &routing.Route{Route: eskip.Route{Backend: b}}
+		r.Me = r
+		r.Group = group
+		r.IsLoadBalanced = true
+		routes = append(routes, r)
+	}
+
+	for i := range routes {
+		routes[i].Head = routes[0]
+		if i < len(routes)-1 {
+			routes[i].Next = routes[i+1]
+		}
+	}
+
+	return routes
+}
+
+func stickyRequest(t *testing.T, h http.Header) *http.Request {
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range (&http.Response{Header: h}).Cookies() {
+		req.AddCookie(c)
+	}
+
+	return req
+}
+
+func TestStickyRequiresSecret(t *testing.T) {
+	if NewSticky(StickyOptions{}) != nil {
+		t.Error("failed to disable session affinity without a secret")
+	}
+}
+
+func TestStickyPinsMember(t *testing.T) {
+	s := NewSticky(StickyOptions{Secret: []byte("secret")})
+	group := stickyGroup("foo", "https://be1.example.org", "https://be2.example.org")
+
+	h := make(http.Header)
+	s.SetCookie(h, nil, group[1])
+	if len(h["Set-Cookie"]) != 1 {
+		t.Fatal("failed to set cookie")
+	}
+
+	pins := s.Pins(stickyRequest(t, h))
+	if pins["foo"] != "https://be2.example.org" {
+		t.Fatalf("failed to read pins: %v", pins)
+	}
+
+	if r := s.Select(pins, group[0]); r != group[1] {
+		t.Error("failed to select the pinned member")
+	}
+
+	h = make(http.Header)
+	s.SetCookie(h, pins, group[1])
+	if len(h["Set-Cookie"]) != 0 {
+		t.Error("unexpected cookie for an already pinned member")
+	}
+}
+
+func TestStickyFallback(t *testing.T) {
+	s := NewSticky(StickyOptions{Secret: []byte("secret")})
+	group := stickyGroup("foo", "https://be1.example.org", "https://be2.example.org")
+
+	pins := map[string]string{"foo": "https://be3.example.org"}
+	if r := s.Select(pins, group[0]); r != nil {
+		t.Error("failed to fall back when the pinned member is missing")
+	}
+
+	pins = map[string]string{"bar": "https://be1.example.org"}
+	if r := s.Select(pins, group[1]); r != nil {
+		t.Error("unexpected selection from a different group")
+	}
+
+	h := make(http.Header)
+	s.SetCookie(h, map[string]string{"foo": "https://be3.example.org"}, group[0])
+	if len(h["Set-Cookie"]) != 1 {
+		t.Error("failed to re-pin the client")
+	}
+}
+
+func TestStickyIgnoresTamperedCookie(t *testing.T) {
+	s := NewSticky(StickyOptions{Secret: []byte("secret")})
+	other := NewSticky(StickyOptions{Secret: []byte("other")})
+	group := stickyGroup("foo", "https://be1.example.org")
+
+	h := make(http.Header)
+	other.SetCookie(h, nil, group[0])
+	if pins := s.Pins(stickyRequest(t, h)); len(pins) != 0 {
+		t.Error("failed to ignore cookie with invalid signature")
+	}
+
+	h = make(http.Header)
+	s.SetCookie(h, nil, group[0])
+	h.Set("Set-Cookie", strings.Replace(h.Get("Set-Cookie"), ".", "x.", 1))
+	if pins := s.Pins(stickyRequest(t, h)); len(pins) != 0 {
+		t.Error("failed to ignore tampered cookie")
+	}
+}
+
+func TestStickyUnhealthyMember(t *testing.T) {
+	const (
+		be1 = "https://be1.example.org"
+		be2 = "https://be2.example.org"
+	)
+
+	routes, err := eskip.Parse(`
+		member0: LBMember("foo", 0) -> "` + be1 + `";
+		member1: LBMember("foo", 1) -> "` + be2 + `";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lb := &LB{routeState: map[string]state{be2: unhealthy}}
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := routing.New(routing.Options{
+		DataClients:     []routing.DataClient{testdataclient.New(routes)},
+		Predicates:      []routing.PredicateSpec{NewMember()},
+		PostProcessors:  []routing.PostProcessor{HealthcheckPostProcessor{LB: lb}},
+		Log:             l,
+		SignalFirstLoad: true,
+	})
+	defer rt.Close()
+
+	select {
+	case <-rt.FirstLoad():
+	case <-time.After(120 * time.Millisecond):
+		t.Fatal("timeout waiting for the first load")
+	}
+
+	req := stickyRequest(t, nil)
+	req.Header.Set(decisionHeader, "foo=0")
+	r, _ := rt.Route(req)
+	if r == nil || r.Id != "member0" {
+		t.Fatal("failed to route to the healthy member")
+	}
+
+	s := NewSticky(StickyOptions{Secret: []byte("secret")})
+	if m := s.Select(map[string]string{"foo": be2}, r); m != nil {
+		t.Error("failed to fall back from the unhealthy pinned member")
+	}
+
+	if m := s.Select(map[string]string{"foo": be1}, r); m != r {
+		t.Error("failed to select the healthy pinned member")
+	}
+}
@@ -1,9 +1,12 @@
 package logging
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -12,17 +15,50 @@ import (
 )
 
 const (
-	dateFormat      = "02/Jan/2006:15:04:05 -0700"
-	commonLogFormat = `%s - - [%s] "%s %s %s" %d %d`
-	// format:
-	// remote_host - - [date] "method uri protocol" status response_size "referer" "user_agent"
-	combinedLogFormat = commonLogFormat + ` "%s" "%s"`
-	// We add the duration in ms, a requested host and a flow id
-	accessLogFormat = combinedLogFormat + " %d %s %s\n"
+	dateFormat = "02/Jan/2006:15:04:05 -0700"
+
+	// DefaultAccessLogFormat is the Apache combined log format, with a
+	// minor customization: the duration in ms, the requested host and
+	// the flow id are appended.
+	DefaultAccessLogFormat = `${host} - - [${timestamp}] "${method} ${uri} ${proto}" ${status} ${response-size} "${referer}" "${user-agent}" ${duration} ${requested-host} ${flow-id}`
+
+	// RequestHeaderFieldPrefix is the prefix of the access log fields
+	// containing a request header, e.g. request-header:Accept.
+	RequestHeaderFieldPrefix = "request-header:"
+
+	// ResponseHeaderFieldPrefix is the prefix of the access log fields
+	// containing a response header, e.g. response-header:Content-Type.
+	ResponseHeaderFieldPrefix = "response-header:"
+
+	// RouteAnnotationFieldPrefix is the prefix of the access log fields
+	// containing an annotation of the route, e.g. route-annotation:owner.
+	RouteAnnotationFieldPrefix = "route-annotation:"
+
+	// MaskedValue replaces the value of the masked access log fields.
+	MaskedValue = "***"
+
+	// the value printed in the text format for omitted fields
+	omittedValue = "-"
 )
 
+// DefaultAccessLogJSONFields are the fields of the JSON access log
+// entries, when no custom fields are set.
+var DefaultAccessLogJSONFields = []string{
+	"host", "timestamp", "method", "uri", "proto",
+	"status", "response-size", "referer", "user-agent",
+	"duration", "requested-host", "flow-id",
+}
+
+type accessLogSegment struct {
+	literal string
+	field   string
+}
+
+// the access log format parsed to literals and field references
+type accessLogTemplate []accessLogSegment
+
 type accessLogFormatter struct {
-	format string
+	template accessLogTemplate
 }
 
 // Access log entry.
@@ -42,9 +78,209 @@ type AccessEntry struct {
 
 	// The time that the request was received.
 	RequestTime time.Time
+
+	// The ID of the route that handled the request.
+	RouteID string
+
+	// The annotations of the route that handled the request.
+	RouteAnnotations map[string]string
+
+	// The host of the backend that the request was forwarded to.
+	BackendHost string
+
+	// The time spent waiting for the backend responses.
+	UpstreamDuration time.Duration
+
+	// The size of the request body received from the client in bytes.
+	RequestSize int64
+
+	// The header of the response.
+	ResponseHeader http.Header
+
+	// Fields whose values are replaced with MaskedValue.
+	MaskedFields []string
+
+	// Fields left out from the entry.
+	OmittedFields []string
+}
+
+var (
+	accessLog       *logrus.Logger
+	accessLogFields []string
+)
+
+var accessLogFieldValues = map[string]func(*AccessEntry) interface{}{
+	"host": func(e *AccessEntry) interface{} {
+		if e.Request == nil {
+			return "-"
+		}
+
+		return remoteHost(e.Request)
+	},
+	"timestamp": func(e *AccessEntry) interface{} { return e.RequestTime.Format(dateFormat) },
+	"method":    requestValue(func(r *http.Request) string { return r.Method }),
+	"uri":       requestValue(func(r *http.Request) string { return r.RequestURI }),
+	"proto":     requestValue(func(r *http.Request) string { return r.Proto }),
+	"referer":   requestValue(func(r *http.Request) string { return r.Referer() }),
+	"user-agent": requestValue(func(r *http.Request) string {
+		return r.UserAgent()
+	}),
+	"requested-host": requestValue(func(r *http.Request) string { return r.Host }),
+	"flow-id": requestValue(func(r *http.Request) string {
+		return r.Header.Get(flowidFilter.HeaderName)
+	}),
+	"tls-version": requestValue(func(r *http.Request) string {
+		if r.TLS == nil {
+			return ""
+		}
+
+		return tlsVersion(r.TLS.Version)
+	}),
+	"status":            func(e *AccessEntry) interface{} { return e.StatusCode },
+	"response-size":     func(e *AccessEntry) interface{} { return e.ResponseSize },
+	"request-size":      func(e *AccessEntry) interface{} { return e.RequestSize },
+	"duration":          func(e *AccessEntry) interface{} { return int64(e.Duration / time.Millisecond) },
+	"upstream-duration": func(e *AccessEntry) interface{} { return int64(e.UpstreamDuration / time.Millisecond) },
+	"route-id":          func(e *AccessEntry) interface{} { return e.RouteID },
+	"backend-host":      func(e *AccessEntry) interface{} { return e.BackendHost },
+}
+
+func requestValue(f func(*http.Request) string) func(*AccessEntry) interface{} {
+	return func(e *AccessEntry) interface{} {
+		if e.Request == nil {
+			return ""
+		}
+
+		return f(e.Request)
+	}
+}
+
+func tlsVersion(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS1.0"
+	case tls.VersionTLS11:
+		return "TLS1.1"
+	case tls.VersionTLS12:
+		return "TLS1.2"
+	case tls.VersionTLS13:
+		return "TLS1.3"
+	default:
+		return fmt.Sprintf("0x%04x", v)
+	}
+}
+
+// normalizes the header names in the header fields, and checks if the
+// field is known
+func normalizeField(f string) (string, error) {
+	for _, prefix := range []string{RequestHeaderFieldPrefix, ResponseHeaderFieldPrefix} {
+		if strings.HasPrefix(f, prefix) {
+			name := f[len(prefix):]
+			if name == "" {
+				return "", fmt.Errorf("missing header name in access log field: %s", f)
+			}
+
+			return prefix + http.CanonicalHeaderKey(name), nil
+		}
+	}
+
+	if strings.HasPrefix(f, RouteAnnotationFieldPrefix) {
+		if f == RouteAnnotationFieldPrefix {
+			return "", fmt.Errorf("missing annotation name in access log field: %s", f)
+		}
+
+		return f, nil
+	}
+
+	if _, ok := accessLogFieldValues[f]; !ok {
+		return "", fmt.Errorf("unknown access log field: %s", f)
+	}
+
+	return f, nil
+}
+
+// NormalizeFields checks the access log field names, and returns them
+// with canonical header names.
+func NormalizeFields(fields []string) ([]string, error) {
+	n := make([]string, len(fields))
+	for i, f := range fields {
+		var err error
+		if n[i], err = normalizeField(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// parses an access log format, where the fields are referenced as
+// ${field-name}
+func parseAccessLogFormat(format string) (accessLogTemplate, error) {
+	var t accessLogTemplate
+	for {
+		i := strings.Index(format, "${")
+		if i < 0 {
+			break
+		}
+
+		j := strings.Index(format[i:], "}")
+		if j < 0 {
+			return nil, fmt.Errorf("unclosed field reference in access log format: %s", format[i:])
+		}
+
+		field, err := normalizeField(format[i+2 : i+j])
+		if err != nil {
+			return nil, err
+		}
+
+		t = append(t, accessLogSegment{literal: format[:i], field: field})
+		format = format[i+j+1:]
+	}
+
+	if format != "" {
+		t = append(t, accessLogSegment{literal: format})
+	}
+
+	return t, nil
 }
 
-var accessLog *logrus.Logger
+func (t accessLogTemplate) fields() []string {
+	var f []string
+	for _, s := range t {
+		if s.field != "" {
+			f = append(f, s.field)
+		}
+	}
+
+	return f
+}
+
+func fieldValue(e *AccessEntry, f string) interface{} {
+	switch {
+	case strings.HasPrefix(f, RequestHeaderFieldPrefix):
+		if e.Request == nil {
+			return ""
+		}
+
+		return e.Request.Header.Get(f[len(RequestHeaderFieldPrefix):])
+	case strings.HasPrefix(f, ResponseHeaderFieldPrefix):
+		return e.ResponseHeader.Get(f[len(ResponseHeaderFieldPrefix):])
+	case strings.HasPrefix(f, RouteAnnotationFieldPrefix):
+		return e.RouteAnnotations[f[len(RouteAnnotationFieldPrefix):]]
+	default:
+		return accessLogFieldValues[f](e)
+	}
+}
+
+func containsField(fields []string, f string) bool {
+	for _, fi := range fields {
+		if fi == f {
+			return true
+		}
+	}
+
+	return false
+}
 
 // strip port from addresses with hostname, ipv4 or ipv6
 func stripPort(address string) string {
@@ -77,63 +313,41 @@ func remoteHost(r *http.Request) string {
 }
 
 func (f *accessLogFormatter) Format(e *logrus.Entry) ([]byte, error) {
-	keys := []string{
-		"host", "timestamp", "method", "uri", "proto",
-		"status", "response-size", "referer", "user-agent",
-		"duration", "requested-host", "flow-id"}
+	var b bytes.Buffer
+	for _, s := range f.template {
+		b.WriteString(s.literal)
+		if s.field == "" {
+			continue
+		}
 
-	values := make([]interface{}, len(keys))
-	for i, key := range keys {
-		values[i] = e.Data[key]
+		if v, ok := e.Data[s.field]; ok {
+			fmt.Fprint(&b, v)
+		} else {
+			b.WriteString(omittedValue)
+		}
 	}
 
-	return []byte(fmt.Sprintf(f.format, values...)), nil
+	b.WriteByte('\n')
+	return b.Bytes(), nil
 }
 
-// Logs an access event in Apache combined log format (with a minor customization with the duration).
+// Logs an access event in Apache combined log format (with a minor
+// customization with the duration), or in the configured custom format.
 func LogAccess(entry *AccessEntry) {
 	if accessLog == nil || entry == nil {
 		return
 	}
 
-	ts := entry.RequestTime.Format(dateFormat)
-
-	host := "-"
-	method := ""
-	uri := ""
-	proto := ""
-	referer := ""
-	userAgent := ""
-	requestedHost := ""
-	flowId := ""
-
-	status := entry.StatusCode
-	responseSize := entry.ResponseSize
-	duration := int64(entry.Duration / time.Millisecond)
-
-	if entry.Request != nil {
-		host = remoteHost(entry.Request)
-		method = entry.Request.Method
-		uri = entry.Request.RequestURI
-		proto = entry.Request.Proto
-		referer = entry.Request.Referer()
-		userAgent = entry.Request.UserAgent()
-		requestedHost = entry.Request.Host
-		flowId = entry.Request.Header.Get(flowidFilter.HeaderName)
-	}
-
-	accessLog.WithFields(logrus.Fields{
-		"timestamp":      ts,
-		"host":           host,
-		"method":         method,
-		"uri":            uri,
-		"proto":          proto,
-		"referer":        referer,
-		"user-agent":     userAgent,
-		"status":         status,
-		"response-size":  responseSize,
-		"requested-host": requestedHost,
-		"duration":       duration,
-		"flow-id":        flowId,
-	}).Infoln()
+	fields := make(logrus.Fields, len(accessLogFields))
+	for _, f := range accessLogFields {
+		switch {
+		case containsField(entry.OmittedFields, f):
+		case containsField(entry.MaskedFields, f):
+			fields[f] = MaskedValue
+		default:
+			fields[f] = fieldValue(entry, f)
+		}
+	}
+
+	accessLog.WithFields(fields).Infoln()
 }
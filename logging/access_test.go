@@ -2,7 +2,9 @@ package logging
 
 import (
 	"bytes"
+	"crypto/tls"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -118,3 +120,90 @@ func TestPresentFlowIdJSON(t *testing.T) {
 	entry.Request.Header.Set("X-Flow-Id", "sometestflowid")
 	testAccessLog(t, entry, `{"duration":42,"flow-id":"sometestflowid","host":"127.0.0.1","level":"info","method":"GET","msg":"","proto":"HTTP/1.1","referer":"","requested-host":"example.com","response-size":2326,"status":418,"timestamp":"10/Oct/2000:13:55:36 -0700","uri":"/apache_pb.gif","user-agent":""}`, true)
 }
+
+func testCustomAccessLog(t *testing.T, o Options, entry *AccessEntry, expectedOutput string) {
+	var buf bytes.Buffer
+	o.AccessLogOutput = &buf
+	if err := Init(o); err != nil {
+		t.Fatal(err)
+	}
+
+	LogAccess(entry)
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != expectedOutput {
+		t.Error("got wrong access log.")
+		t.Log("expected:", expectedOutput)
+		t.Log("got     :", got)
+	}
+}
+
+func testDetailedAccessEntry() *AccessEntry {
+	entry := testAccessEntry()
+	entry.Request.Header.Set("User-Agent", "test-agent")
+	entry.Request.Header.Set("Authorization", "Bearer secret")
+	entry.Request.TLS = &tls.ConnectionState{Version: tls.VersionTLS12}
+	entry.RouteID = "route1"
+	entry.RouteAnnotations = map[string]string{"owner": "team-x"}
+	entry.BackendHost = "backend.example.org"
+	entry.UpstreamDuration = 36 * time.Millisecond
+	entry.RequestSize = 15
+	entry.ResponseHeader = http.Header{"Content-Type": []string{"text/plain"}}
+	return entry
+}
+
+func TestAccessLogCustomFormat(t *testing.T) {
+	testCustomAccessLog(
+		t,
+		Options{AccessLogFormat: `${route-id} ${route-annotation:owner} ${backend-host} ${upstream-duration} ${request-size} ${tls-version} "${user-agent}" ${request-header:authorization} ${response-header:content-type} $ {}`},
+		testDetailedAccessEntry(),
+		`route1 team-x backend.example.org 36 15 TLS1.2 "test-agent" Bearer secret text/plain $ {}`,
+	)
+}
+
+func TestAccessLogJSONFields(t *testing.T) {
+	testCustomAccessLog(
+		t,
+		Options{
+			AccessLogJSONEnabled: true,
+			AccessLogJSONFields:  []string{"route-id", "status", "upstream-duration", "response-header:Content-Type", "route-annotation:owner"},
+		},
+		testDetailedAccessEntry(),
+		`{"level":"info","msg":"","response-header:Content-Type":"text/plain","route-annotation:owner":"team-x","route-id":"route1","status":418,"upstream-duration":36}`,
+	)
+}
+
+func TestAccessLogMaskAndOmit(t *testing.T) {
+	entry := testDetailedAccessEntry()
+	entry.MaskedFields = []string{"request-header:Authorization", "uri"}
+	entry.OmittedFields = []string{"user-agent"}
+
+	testCustomAccessLog(
+		t,
+		Options{AccessLogFormat: `${uri} ${request-header:Authorization} ${user-agent} ${route-id}`},
+		entry,
+		`*** *** - route1`,
+	)
+
+	testCustomAccessLog(
+		t,
+		Options{
+			AccessLogJSONEnabled: true,
+			AccessLogJSONFields:  []string{"uri", "user-agent", "route-id"},
+		},
+		entry,
+		`{"level":"info","msg":"","route-id":"route1","uri":"***"}`,
+	)
+}
+
+func TestAccessLogInvalidFormat(t *testing.T) {
+	for _, o := range []Options{
+		{AccessLogFormat: "${foo}"},
+		{AccessLogFormat: "${host"},
+		{AccessLogFormat: "${request-header:}"},
+		{AccessLogFormat: "${route-annotation:}"},
+		{AccessLogJSONEnabled: true, AccessLogJSONFields: []string{"host", "bar"}},
+	} {
+		if err := Init(o); err == nil {
+			t.Errorf("failed to fail: %v", o)
+		}
+	}
+}
@@ -34,6 +34,36 @@ During initialization, it is possible to redirect the access log output
 from the default /dev/stderr to another file, or completely disable the
 access log.
 
+The format of the entries can be customized with a template, where the
+fields are referenced as ${field-name}. E.g:
+
+    ${host} [${timestamp}] "${method} ${uri}" ${status} ${route-id} ${request-header:User-Agent}
+
+When the JSON format is enabled, the fields of the entries can be
+selected instead. The available fields are: host, timestamp, method,
+uri, proto, status, response-size, request-size, referer, user-agent,
+duration, upstream-duration, requested-host, flow-id, route-id,
+backend-host, tls-version, any request or response header as
+request-header:Name and response-header:Name, and any annotation of the
+matching route as route-annotation:name, e.g. route-annotation:owner.
+The durations are measured in milliseconds.
+
+The route ID, the route annotations, the backend host and the upstream
+duration are passed by the proxy to the access log handler in the state
+bag of the request.
+The filters maskAccessLogFields and omitAccessLogFields can be used to
+mask or omit fields in the entries of the requests matched by a route.
+
+To reduce the volume of the access log, sampling can be enabled with a
+ratio, and then only the selected fraction of the entries are written.
+With the ratio 0, only the entries of the routes with the
+enableAccessLog filter are written. The filters
+disableAccessLog and enableAccessLog control the access log per route,
+optionally only for selected response status classes, e.g.
+disableAccessLog(2) leaves out the entries with 2xx statuses, while
+enableAccessLog(4, 5) writes only the ones with 4xx and 5xx statuses.
+The routes with these filters are not sampled.
+
 Output Files
 
 To set a custom file output for the application log or the access log is
@@ -1,35 +1,167 @@
 package logging
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"time"
 )
 
+// State bag keys, used by the proxy and the filters to pass
+// information about the request to the access log.
+const (
+	// RouteIDStateKey is the state bag key of the ID of the route that
+	// handled the request.
+	RouteIDStateKey = "#accesslogrouteid"
+
+	// RouteAnnotationsStateKey is the state bag key of the annotations
+	// of the route that handled the request, as map[string]string.
+	RouteAnnotationsStateKey = "#accesslogrouteannotations"
+
+	// BackendHostStateKey is the state bag key of the host of the
+	// backend that the request was forwarded to.
+	BackendHostStateKey = "#accesslogbackendhost"
+
+	// UpstreamDurationStateKey is the state bag key of the total time
+	// spent waiting for the backend responses, as time.Duration.
+	UpstreamDurationStateKey = "#accesslogupstreamduration"
+
+	// MaskedFieldsStateKey is the state bag key of the access log
+	// fields to be masked, as []string.
+	MaskedFieldsStateKey = "#accesslogmaskedfields"
+
+	// OmittedFieldsStateKey is the state bag key of the access log
+	// fields to be omitted, as []string.
+	OmittedFieldsStateKey = "#accesslogomittedfields"
+
+	// AccessLogFilterStateKey is the state bag key of the
+	// AccessLogFilter set for the route that handled the request.
+	AccessLogFilterStateKey = "#accesslogfilter"
+)
+
+// AccessLogFilter decides whether the access log entry of a request is
+// written, based on the response status. When set for a request, the
+// sampling ratio of the access log is not applied.
+type AccessLogFilter struct {
+
+	// Enable tells whether the entries of the matching statuses are
+	// written, or the entries of all the other statuses.
+	Enable bool
+
+	// Prefixes of the status codes, e.g. 4 matches all the 4xx
+	// statuses, 40 matches 400-409, and 404 matches only 404. When
+	// empty, every status matches.
+	Prefixes []int
+}
+
+type stateBagKey struct{}
+
+func matchStatusPrefix(status, prefix int) bool {
+	switch {
+	case prefix < 10:
+		return status/100 == prefix
+	case prefix < 100:
+		return status/10 == prefix
+	default:
+		return status == prefix
+	}
+}
+
+// Logs tells whether the access log entry of a request with the given
+// response status needs to be written.
+func (f AccessLogFilter) Logs(status int) bool {
+	if len(f.Prefixes) == 0 {
+		return f.Enable
+	}
+
+	for _, p := range f.Prefixes {
+		if matchStatusPrefix(status, p) {
+			return f.Enable
+		}
+	}
+
+	return !f.Enable
+}
+
 // The logging handler wraps the proxy handler to produce an access log compatible to Apache's
 type loggingHandler struct {
 	proxy http.Handler
 }
 
+type countingBody struct {
+	io.ReadCloser
+	bytes int64
+}
+
 // NewHandler creates an http.Handler that provides access log
 // for the underlying handler.
 func NewHandler(next http.Handler) http.Handler {
 	return &loggingHandler{proxy: next}
 }
 
+// WithStateBag returns a context that carries the state bag of a
+// request.
+func WithStateBag(ctx context.Context, bag map[string]interface{}) context.Context {
+	return context.WithValue(ctx, stateBagKey{}, bag)
+}
+
+// StateBagFromContext returns the state bag of a request, created by
+// the logging handler, or nil, when there is none.
+func StateBagFromContext(ctx context.Context) map[string]interface{} {
+	bag, _ := ctx.Value(stateBagKey{}).(map[string]interface{})
+	return bag
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	return n, err
+}
+
 func (lh *loggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 
+	bag := make(map[string]interface{})
+	r = r.WithContext(WithStateBag(r.Context(), bag))
+
+	var body *countingBody
+	if r.Body != nil {
+		body = &countingBody{ReadCloser: r.Body}
+		r.Body = body
+	}
+
 	lw := &loggingWriter{writer: w}
 	lh.proxy.ServeHTTP(lw, r)
 
 	dur := time.Since(now)
 
 	entry := &AccessEntry{
-		Request:      r,
-		ResponseSize: lw.bytes,
-		StatusCode:   lw.code,
-		RequestTime:  now,
-		Duration:     dur,
+		Request:        r,
+		ResponseSize:   lw.bytes,
+		StatusCode:     lw.code,
+		RequestTime:    now,
+		Duration:       dur,
+		ResponseHeader: w.Header(),
+	}
+
+	if body != nil {
+		entry.RequestSize = body.bytes
 	}
+
+	entry.RouteID, _ = bag[RouteIDStateKey].(string)
+	entry.RouteAnnotations, _ = bag[RouteAnnotationsStateKey].(map[string]string)
+	entry.BackendHost, _ = bag[BackendHostStateKey].(string)
+	entry.UpstreamDuration, _ = bag[UpstreamDurationStateKey].(time.Duration)
+	entry.MaskedFields, _ = bag[MaskedFieldsStateKey].([]string)
+	entry.OmittedFields, _ = bag[OmittedFieldsStateKey].([]string)
+
+	if f, ok := bag[AccessLogFilterStateKey].(AccessLogFilter); ok {
+		if !f.Logs(entry.StatusCode) {
+			return
+		}
+	} else if !sampleAccessLog() {
+		return
+	}
+
 	LogAccess(entry)
 }
@@ -3,6 +3,7 @@ package logging
 import (
 	"bytes"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -48,3 +49,102 @@ func TestLogsAccess(t *testing.T) {
 		t.Error("failed to log access")
 	}
 }
+
+func TestLogsStateBagDetails(t *testing.T) {
+	var accessLog bytes.Buffer
+	if err := Init(Options{
+		AccessLogOutput: &accessLog,
+		AccessLogFormat: "${route-id} ${backend-host} ${request-size} ${uri} ${response-header:X-Foo}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	innerHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		bag := StateBagFromContext(r.Context())
+		bag[RouteIDStateKey] = "route1"
+		bag[BackendHostStateKey] = "backend.example.org"
+		bag[MaskedFieldsStateKey] = []string{"uri"}
+		w.Header().Set("X-Foo", "bar")
+	})
+
+	h := NewHandler(innerHandler)
+	r := httptest.NewRequest("POST", "http://www.example.org/secret", bytes.NewBufferString("Hello, world!"))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if output := accessLog.String(); output != "route1 backend.example.org 13 *** bar\n" {
+		t.Errorf("failed to log the details, got: %s", output)
+	}
+}
+
+func TestAccessLogFilter(t *testing.T) {
+	for _, ti := range []struct {
+		filter   AccessLogFilter
+		status   int
+		expected bool
+	}{
+		{AccessLogFilter{}, 200, false},
+		{AccessLogFilter{Enable: true}, 500, true},
+		{AccessLogFilter{Prefixes: []int{2}}, 204, false},
+		{AccessLogFilter{Prefixes: []int{2}}, 404, true},
+		{AccessLogFilter{Prefixes: []int{30, 404}}, 301, false},
+		{AccessLogFilter{Prefixes: []int{30, 404}}, 404, false},
+		{AccessLogFilter{Prefixes: []int{30, 404}}, 410, true},
+		{AccessLogFilter{Enable: true, Prefixes: []int{5}}, 503, true},
+		{AccessLogFilter{Enable: true, Prefixes: []int{5}}, 200, false},
+	} {
+		if got := ti.filter.Logs(ti.status); got != ti.expected {
+			t.Errorf("unexpected result for %v and %d, expected: %v, got: %v", ti.filter, ti.status, ti.expected, got)
+		}
+	}
+}
+
+func TestAccessLogFilterAndSampling(t *testing.T) {
+	var accessLog bytes.Buffer
+	if err := Init(Options{
+		AccessLogOutput:   &accessLog,
+		AccessLogFormat:   "${status}",
+		AccessLogSampling: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	defer Init(Options{AccessLogOutput: &accessLog})
+
+	var (
+		status int
+		filter *AccessLogFilter
+	)
+
+	h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if filter != nil {
+			StateBagFromContext(r.Context())[AccessLogFilterStateKey] = *filter
+		}
+
+		w.WriteHeader(status)
+	}))
+
+	serve := func(s int, f *AccessLogFilter) {
+		status, filter = s, f
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://www.example.org", nil))
+	}
+
+	for i := 0; i < 10; i++ {
+		serve(200, nil)
+	}
+
+	serve(200, &AccessLogFilter{Enable: true})
+	serve(404, &AccessLogFilter{Enable: true, Prefixes: []int{5}})
+	serve(503, &AccessLogFilter{Enable: true, Prefixes: []int{5}})
+	serve(500, &AccessLogFilter{Prefixes: []int{5}})
+
+	if output := accessLog.String(); output != "200\n503\n" {
+		t.Errorf("unexpected access log output: %q", output)
+	}
+}
+
+func TestInvalidSampleRatio(t *testing.T) {
+	if err := Init(Options{AccessLogSampleRatio: 1.5}); err == nil {
+		t.Error("failed to fail")
+	}
+}
@@ -1,9 +1,17 @@
 package logging
 
 import (
-	"github.com/sirupsen/logrus"
+	"fmt"
 	"io"
+	"math/rand"
 	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	accessLogSampling    bool
+	accessLogSampleRatio float64
 )
 
 type prefixFormatter struct {
@@ -32,6 +40,30 @@ type Options struct {
 
 	// When set, log in JSON format is used
 	AccessLogJSONEnabled bool
+
+	// AccessLogFormat is the format of the access log entries, when
+	// the JSON format is not enabled. The fields are referenced as
+	// ${field-name}, the request and response headers as
+	// ${request-header:Name} and ${response-header:Name}, and the
+	// annotations of the route as ${route-annotation:name}. Defaults
+	// to DefaultAccessLogFormat.
+	AccessLogFormat string
+
+	// AccessLogJSONFields are the fields of the access log entries,
+	// when the JSON format is enabled. Defaults to
+	// DefaultAccessLogJSONFields.
+	AccessLogJSONFields []string
+
+	// When set, the access log entries are sampled with
+	// AccessLogSampleRatio. Otherwise all the entries are written.
+	AccessLogSampling bool
+
+	// AccessLogSampleRatio is the ratio of the requests, between 0
+	// and 1, whose access log entries are written by the logging
+	// handler, when AccessLogSampling is set. When 0, no sampled
+	// entries are written. The routes with the enableAccessLog or
+	// disableAccessLog filters are not sampled.
+	AccessLogSampleRatio float64
 }
 
 func (f *prefixFormatter) Format(e *logrus.Entry) ([]byte, error) {
@@ -54,20 +86,53 @@ func initApplicationLog(prefix string, output io.Writer) {
 	}
 }
 
-func initAccessLog(output io.Writer, accessLogJSONEnabled bool) {
+func initAccessLog(o Options) error {
+	if o.AccessLogSampleRatio < 0 || o.AccessLogSampleRatio > 1 {
+		return fmt.Errorf("invalid access log sample ratio: %v", o.AccessLogSampleRatio)
+	}
+
 	l := logrus.New()
-	if accessLogJSONEnabled {
+	var fields []string
+	if o.AccessLogJSONEnabled {
+		if len(o.AccessLogJSONFields) == 0 {
+			o.AccessLogJSONFields = DefaultAccessLogJSONFields
+		}
+
+		var err error
+		if fields, err = NormalizeFields(o.AccessLogJSONFields); err != nil {
+			return err
+		}
+
 		l.Formatter = &logrus.JSONFormatter{TimestampFormat: dateFormat, DisableTimestamp: true}
 	} else {
-		l.Formatter = &accessLogFormatter{accessLogFormat}
+		if o.AccessLogFormat == "" {
+			o.AccessLogFormat = DefaultAccessLogFormat
+		}
+
+		t, err := parseAccessLogFormat(o.AccessLogFormat)
+		if err != nil {
+			return err
+		}
+
+		fields = t.fields()
+		l.Formatter = &accessLogFormatter{t}
 	}
-	l.Out = output
+	l.Out = o.AccessLogOutput
 	l.Level = logrus.InfoLevel
 	accessLog = l
+	accessLogFields = fields
+	accessLogSampling = o.AccessLogSampling
+	accessLogSampleRatio = o.AccessLogSampleRatio
+	return nil
+}
+
+func sampleAccessLog() bool {
+	return !accessLogSampling || accessLogSampleRatio >= 1 || rand.Float64() < accessLogSampleRatio
 }
 
-// Initializes logging.
-func Init(o Options) {
+// Initializes logging. It returns an error when the access log format
+// or the access log fields are invalid.
+func Init(o Options) error {
 	if o.ApplicationLogPrefix != "" || o.ApplicationLogOutput != nil {
 		initApplicationLog(o.ApplicationLogPrefix, o.ApplicationLogOutput)
 	}
@@ -77,6 +142,8 @@ func Init(o Options) {
 			o.AccessLogOutput = os.Stderr
 		}
 
-		initAccessLog(o.AccessLogOutput, o.AccessLogJSONEnabled)
+		return initAccessLog(o)
 	}
+
+	return nil
 }
@@ -82,6 +82,25 @@ func (a *All) IncErrorsStreaming(routeId string) {
 	a.codaHale.IncErrorsStreaming(routeId)
 
 }
+func (a *All) MeasureRequest(labels RequestLabels, start time.Time) {
+	a.prometheus.MeasureRequest(labels, start)
+	a.codaHale.MeasureRequest(labels, start)
+}
+func (a *All) MeasureBackendRequest(labels RequestLabels, start time.Time) {
+	a.prometheus.MeasureBackendRequest(labels, start)
+	a.codaHale.MeasureBackendRequest(labels, start)
+}
+func (a *All) SetRouteHosts(hostRegexps, backendHosts []string) {
+	a.prometheus.SetRouteHosts(hostRegexps, backendHosts)
+}
+func (a *All) MeasureFilterSince(filterName, key string, start time.Time) {
+	a.prometheus.MeasureFilterSince(filterName, key, start)
+	a.codaHale.MeasureFilterSince(filterName, key, start)
+}
+func (a *All) IncFilterCounter(filterName, key string) {
+	a.prometheus.IncFilterCounter(filterName, key)
+	a.codaHale.IncFilterCounter(filterName, key)
+}
 func (a *All) RegisterHandler(path string, handler *http.ServeMux) {
 	a.prometheusHandler = a.prometheus.getHandler()
 	a.codaHaleHandler = a.codaHale.getHandler(path)
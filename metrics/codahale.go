@@ -32,6 +32,8 @@ const (
 	KeyErrorsBackend   = "errors.backend.%s"
 	KeyErrorsStreaming = "errors.streaming.%s"
 
+	KeyFilterCustom = "%s.custom.%s"
+
 	statsRefreshDuration = time.Duration(5 * time.Second)
 
 	defaultUniformReservoirSize  = 1024
@@ -204,6 +206,23 @@ func (c *CodaHale) IncErrorsStreaming(routeId string) {
 	}
 }
 
+// MeasureRequest is a no-op, because the CodaHale metrics don't support
+// labels. The serve times are measured by MeasureServe.
+func (c *CodaHale) MeasureRequest(RequestLabels, time.Time) {}
+
+// MeasureBackendRequest is a no-op, because the CodaHale metrics don't
+// support labels. The backend times are measured by MeasureBackend and
+// MeasureBackendHost.
+func (c *CodaHale) MeasureBackendRequest(RequestLabels, time.Time) {}
+
+func (c *CodaHale) MeasureFilterSince(filterName, key string, start time.Time) {
+	c.measureSince(fmt.Sprintf(KeyFilterCustom, filterName, key), start)
+}
+
+func (c *CodaHale) IncFilterCounter(filterName, key string) {
+	c.incCounter(fmt.Sprintf(KeyFilterCustom, filterName, key))
+}
+
 func (c *CodaHale) RegisterHandler(path string, handler *http.ServeMux) {
 	h := c.getHandler(path)
 	handler.Handle(path, h)
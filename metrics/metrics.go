@@ -50,6 +50,31 @@ func ParseMetricsKind(t string) Kind {
 	}
 }
 
+// RequestLabels identify a proxied request in the labelled request
+// metrics. Backends without label support, like CodaHale, may ignore
+// them.
+type RequestLabels struct {
+
+	// Route is the ID of the route that the request was matched to.
+	Route string
+
+	// Host is the value of the Host header of the incoming request.
+	Host string
+
+	// Method is the HTTP method of the incoming request.
+	Method string
+
+	// Code is the status code of the response.
+	Code int
+
+	// Backend is the network address of the backend, or empty for
+	// shunt and loopback routes.
+	Backend string
+
+	// Annotations of the route that the request was matched to.
+	Annotations map[string]string
+}
+
 // Metrics is the generic interface that all the required backends
 // should implement to be an skipper metrics compatible backend.
 type Metrics interface {
@@ -68,9 +93,20 @@ type Metrics interface {
 	IncErrorsBackend(routeId string)
 	MeasureBackend5xx(t time.Time)
 	IncErrorsStreaming(routeId string)
+	MeasureRequest(labels RequestLabels, start time.Time)
+	MeasureBackendRequest(labels RequestLabels, start time.Time)
+	MeasureFilterSince(filterName, key string, start time.Time)
+	IncFilterCounter(filterName, key string)
 	RegisterHandler(path string, handler *http.ServeMux)
 }
 
+// RouteHostsSetter is implemented by the metrics backends that limit
+// the number of the distinct host label values, and use the hosts of the
+// current routes to decide which values to keep.
+type RouteHostsSetter interface {
+	SetRouteHosts(hostRegexps, backendHosts []string)
+}
+
 // Options for initializing metrics collection.
 type Options struct {
 	// the metrics exposing format.
@@ -143,6 +179,34 @@ type Options struct {
 	// EnableProfile exposes profiling information on /pprof of the
 	// metrics listener.
 	EnableProfile bool
+
+	// EnableLabelledRequestMetrics enables collecting the serve and
+	// the backend response times labelled with the route, host,
+	// method, status code and backend of the requests. It is supported
+	// only by the Prometheus metrics.
+	EnableLabelledRequestMetrics bool
+
+	// HistogramBuckets sets the upper bounds of the buckets of the
+	// Prometheus histograms, in seconds. When not set, the Prometheus
+	// default buckets are used.
+	HistogramBuckets []float64
+
+	// HostLabelLimit limits the number of distinct values of the host
+	// label in the Prometheus metrics. The hosts seen after the limit
+	// was reached are collapsed into a single value, _unknownhost_.
+	// The incoming and the backend hosts are limited separately. The
+	// hosts known from the routes, see RouteHostsSetter, are always
+	// kept, and the hosts matching the host regular expressions of the
+	// routes have a separate limit from the other ones. When not set,
+	// DefaultHostLabelLimit is used. Negative values disable the limit.
+	HostLabelLimit int
+
+	// RouteAnnotationLabels sets the route annotations whose values are
+	// added as labels to the labelled request metrics, e.g. the owner
+	// of the routes. The label names are prefixed with annotation_,
+	// and the characters not allowed in the label names are replaced
+	// with _.
+	RouteAnnotationLabels []string
 }
 
 var (
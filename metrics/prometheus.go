@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -20,6 +23,13 @@ const (
 	promResponseSubsystem  = "response"
 	promServeSubsystem     = "serve"
 	promCustomSubsystem    = "custom"
+	promHTTPSubsystem      = "http"
+
+	// DefaultHostLabelLimit is the default maximum number of distinct
+	// values of the host label in the Prometheus metrics.
+	DefaultHostLabelLimit = 1024
+
+	unknownHostLabel = "_unknownhost_"
 )
 
 // Prometheus implements the prometheus metrics backend.
@@ -43,10 +53,16 @@ type Prometheus struct {
 	proxyStreamingErrorsM      *prometheus.CounterVec
 	customHistogramM           *prometheus.HistogramVec
 	customCounterM             *prometheus.CounterVec
-
-	opts     Options
-	registry *prometheus.Registry
-	handler  http.Handler
+	filterCustomHistogramM     *prometheus.HistogramVec
+	filterCustomCounterM       *prometheus.CounterVec
+	requestM                   *prometheus.HistogramVec
+	backendRequestM            *prometheus.HistogramVec
+
+	hosts        *labelGuard
+	backendHosts *labelGuard
+	opts         Options
+	registry     *prometheus.Registry
+	handler      http.Handler
 }
 
 // NewPrometheus returns a new Prometheus metric backend.
@@ -56,10 +72,21 @@ func NewPrometheus(opts Options) *Prometheus {
 		namespace = strings.TrimSuffix(opts.Prefix, ".")
 	}
 
+	buckets := prometheus.DefBuckets
+	if len(opts.HistogramBuckets) > 0 {
+		buckets = opts.HistogramBuckets
+	}
+
+	hostLabelLimit := opts.HostLabelLimit
+	if hostLabelLimit == 0 {
+		hostLabelLimit = DefaultHostLabelLimit
+	}
+
 	routeLookup := prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: namespace,
 		Subsystem: promRouteSubsystem,
 		Name:      "lookup_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a route lookup.",
 	}, []string{})
 
@@ -74,6 +101,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promResponseSubsystem,
 		Name:      "duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a response.",
 	}, []string{"code", "method", "route"})
 
@@ -81,6 +109,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promFilterSubsystem,
 		Name:      "request_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a filter request.",
 	}, []string{"filter"})
 
@@ -88,6 +117,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promFilterSubsystem,
 		Name:      "all_request_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a filter request by all filters.",
 	}, []string{"route"})
 
@@ -95,6 +125,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promFilterSubsystem,
 		Name:      "all_combined_request_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a filter request combined by all filters.",
 	}, []string{})
 
@@ -102,6 +133,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promProxySubsystem,
 		Name:      "duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a proxy backend.",
 	}, []string{"route", "host"})
 
@@ -109,6 +141,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promProxySubsystem,
 		Name:      "combined_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a proxy backend combined.",
 	}, []string{})
 
@@ -116,6 +149,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promFilterSubsystem,
 		Name:      "response_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a filter request.",
 	}, []string{"filter"})
 
@@ -123,6 +157,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promFilterSubsystem,
 		Name:      "all_response_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a filter response by all filters.",
 	}, []string{"route"})
 
@@ -130,6 +165,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promFilterSubsystem,
 		Name:      "all_combined_response_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of a filter response combined by all filters.",
 	}, []string{})
 
@@ -137,6 +173,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promServeSubsystem,
 		Name:      "route_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of serving a route.",
 	}, []string{"code", "method", "route"})
 
@@ -144,6 +181,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promServeSubsystem,
 		Name:      "host_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of serving a host.",
 	}, []string{"code", "method", "host"})
 
@@ -151,6 +189,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promProxySubsystem,
 		Name:      "5xx_duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of backend 5xx.",
 	}, []string{})
 	proxyBackendErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -176,9 +215,44 @@ func NewPrometheus(opts Options) *Prometheus {
 		Namespace: namespace,
 		Subsystem: promCustomSubsystem,
 		Name:      "duration_seconds",
+		Buckets:   buckets,
 		Help:      "Duration in seconds of custom metrics.",
 	}, []string{"key"})
 
+	filterCustomCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: promFilterSubsystem,
+		Name:      "custom_total",
+		Help:      "Total number of custom filter metrics.",
+	}, []string{"filter", "key"})
+	filterCustomHistogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: promFilterSubsystem,
+		Name:      "custom_duration_seconds",
+		Buckets:   buckets,
+		Help:      "Duration in seconds of custom filter metrics.",
+	}, []string{"filter", "key"})
+
+	requestLabels := []string{"route", "host", "method", "code", "backend"}
+	for _, a := range opts.RouteAnnotationLabels {
+		requestLabels = append(requestLabels, annotationLabel(a))
+	}
+
+	request := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: promHTTPSubsystem,
+		Name:      "request_duration_seconds",
+		Buckets:   buckets,
+		Help:      "Duration in seconds of serving a request.",
+	}, requestLabels)
+	backendRequest := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: promHTTPSubsystem,
+		Name:      "backend_duration_seconds",
+		Buckets:   buckets,
+		Help:      "Duration in seconds of a backend request.",
+	}, requestLabels)
+
 	p := &Prometheus{
 		routeLookupM:               routeLookup,
 		routeErrorsM:               routeErrors,
@@ -198,9 +272,15 @@ func NewPrometheus(opts Options) *Prometheus {
 		proxyStreamingErrorsM:      proxyStreamingErrors,
 		customCounterM:             customCounter,
 		customHistogramM:           customHistogram,
-
-		opts:     opts,
-		registry: prometheus.NewRegistry(),
+		filterCustomCounterM:       filterCustomCounter,
+		filterCustomHistogramM:     filterCustomHistogram,
+		requestM:                   request,
+		backendRequestM:            backendRequest,
+
+		hosts:        newLabelGuard(hostLabelLimit, unknownHostLabel),
+		backendHosts: newLabelGuard(hostLabelLimit, unknownHostLabel),
+		opts:         opts,
+		registry:     prometheus.NewRegistry(),
 	}
 
 	// Register all metrics.
@@ -232,6 +312,13 @@ func (p *Prometheus) registerMetrics() {
 	p.registry.MustRegister(p.proxyStreamingErrorsM)
 	p.registry.MustRegister(p.customCounterM)
 	p.registry.MustRegister(p.customHistogramM)
+	p.registry.MustRegister(p.filterCustomCounterM)
+	p.registry.MustRegister(p.filterCustomHistogramM)
+
+	if p.opts.EnableLabelledRequestMetrics {
+		p.registry.MustRegister(p.requestM)
+		p.registry.MustRegister(p.backendRequestM)
+	}
 
 	// Register prometheus runtime collectors if required.
 	if p.opts.EnableRuntimeMetrics {
@@ -304,7 +391,7 @@ func (p *Prometheus) MeasureBackend(routeID string, start time.Time) {
 func (p *Prometheus) MeasureBackendHost(routeBackendHost string, start time.Time) {
 	t := p.sinceS(start)
 	if p.opts.EnableBackendHostMetrics {
-		p.proxyBackendM.WithLabelValues("", routeBackendHost).Observe(t)
+		p.proxyBackendM.WithLabelValues("", p.backendHosts.get(routeBackendHost)).Observe(t)
 	}
 }
 
@@ -345,7 +432,7 @@ func (p *Prometheus) MeasureServe(routeID, host, method string, code int, start
 	}
 
 	if p.opts.EnableServeHostMetrics {
-		p.serveHostM.WithLabelValues(fmt.Sprintf("%d", code), method, hostForKey(host)).Observe(t)
+		p.serveHostM.WithLabelValues(fmt.Sprintf("%d", code), method, hostForKey(p.hosts.get(host))).Observe(t)
 	}
 }
 
@@ -369,3 +456,167 @@ func (p *Prometheus) MeasureBackend5xx(start time.Time) {
 func (p *Prometheus) IncErrorsStreaming(routeID string) {
 	p.proxyStreamingErrorsM.WithLabelValues(routeID).Inc()
 }
+
+func (p *Prometheus) requestLabelValues(l RequestLabels) []string {
+	values := []string{
+		l.Route,
+		p.hosts.get(l.Host),
+		measuredMethod(l.Method),
+		strconv.Itoa(l.Code),
+		p.backendHosts.get(l.Backend),
+	}
+
+	for _, a := range p.opts.RouteAnnotationLabels {
+		values = append(values, l.Annotations[a])
+	}
+
+	return values
+}
+
+// MeasureRequest satisfies Metrics interface.
+func (p *Prometheus) MeasureRequest(l RequestLabels, start time.Time) {
+	if p.opts.EnableLabelledRequestMetrics {
+		p.requestM.WithLabelValues(p.requestLabelValues(l)...).Observe(p.sinceS(start))
+	}
+}
+
+// SetRouteHosts satisfies RouteHostsSetter interface. The host
+// regular expressions are used for the host label of the incoming
+// requests, and the backend hosts for the host label of the backend
+// requests.
+func (p *Prometheus) SetRouteHosts(hostRegexps, backendHosts []string) {
+	p.hosts.seed(nil, hostRegexps)
+	p.backendHosts.seed(backendHosts, nil)
+}
+
+// MeasureBackendRequest satisfies Metrics interface.
+func (p *Prometheus) MeasureBackendRequest(l RequestLabels, start time.Time) {
+	if p.opts.EnableLabelledRequestMetrics {
+		p.backendRequestM.WithLabelValues(p.requestLabelValues(l)...).Observe(p.sinceS(start))
+	}
+}
+
+// MeasureFilterSince satisfies Metrics interface.
+func (p *Prometheus) MeasureFilterSince(filterName, key string, start time.Time) {
+	p.filterCustomHistogramM.WithLabelValues(filterName, key).Observe(p.sinceS(start))
+}
+
+// IncFilterCounter satisfies Metrics interface.
+func (p *Prometheus) IncFilterCounter(filterName, key string) {
+	p.filterCustomCounterM.WithLabelValues(filterName, key).Inc()
+}
+
+// labelGuard limits the cardinality of a label by collapsing the
+// values seen after the limit was reached into a single value. The
+// values known from the routes are always kept, and the values matching
+// the patterns of the routes have their own limit, so that arbitrary
+// values, e.g. set by the clients in the Host header, cannot push them
+// out.
+type labelGuard struct {
+	mu       sync.RWMutex
+	limit    int
+	fallback string
+	known    map[string]struct{}
+	patterns []*regexp.Regexp
+	matched  map[string]struct{}
+	values   map[string]struct{}
+}
+
+func newLabelGuard(limit int, fallback string) *labelGuard {
+	return &labelGuard{
+		limit:    limit,
+		fallback: fallback,
+		known:    make(map[string]struct{}),
+		matched:  make(map[string]struct{}),
+		values:   make(map[string]struct{}),
+	}
+}
+
+// returns the literal value of an anchored regular expression, e.g.
+// ^www[.]example[.]org$, or false when it can match different values
+func literalRegexp(rx string) (string, bool) {
+	if !strings.HasPrefix(rx, "^") || !strings.HasSuffix(rx, "$") {
+		return "", false
+	}
+
+	lrx, err := regexp.Compile(rx[1 : len(rx)-1])
+	if err != nil {
+		return "", false
+	}
+
+	return lrx.LiteralPrefix()
+}
+
+// sets the values known from the routes, and the patterns, typically the
+// host regular expressions of the routes. The literal patterns are
+// stored as known values. Invalid patterns are ignored.
+func (g *labelGuard) seed(values, patterns []string) {
+	known := make(map[string]struct{})
+	for _, v := range values {
+		known[v] = struct{}{}
+	}
+
+	var rxs []*regexp.Regexp
+	for _, p := range patterns {
+		if v, ok := literalRegexp(p); ok {
+			known[v] = struct{}{}
+			continue
+		}
+
+		if rx, err := regexp.Compile(p); err == nil {
+			rxs = append(rxs, rx)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.known = known
+	g.patterns = rxs
+}
+
+func (g *labelGuard) has(v string) bool {
+	if _, ok := g.known[v]; ok {
+		return true
+	}
+
+	if _, ok := g.matched[v]; ok {
+		return true
+	}
+
+	_, ok := g.values[v]
+	return ok
+}
+
+func (g *labelGuard) get(v string) string {
+	if g.limit < 0 || v == "" {
+		return v
+	}
+
+	g.mu.RLock()
+	known := g.has(v)
+	g.mu.RUnlock()
+	if known {
+		return v
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.has(v) {
+		return v
+	}
+
+	values := g.values
+	for _, rx := range g.patterns {
+		if rx.MatchString(v) {
+			values = g.matched
+			break
+		}
+	}
+
+	if len(values) >= g.limit {
+		return g.fallback
+	}
+
+	values[v] = struct{}{}
+	return v
+}
@@ -691,6 +691,127 @@ func TestPrometheusMetrics(t *testing.T) {
 			},
 			expCode: http.StatusOK,
 		},
+		{
+			name: "Incrementing the custom filter counters should get labelled totals.",
+			addMetrics: func(pm *metrics.Prometheus) {
+				pm.IncFilterCounter("filter1", "key1")
+				pm.IncFilterCounter("filter1", "key1")
+				pm.IncFilterCounter("filter2", "key1")
+			},
+			expMetrics: []string{
+				`skipper_filter_custom_total{filter="filter1",key="key1"} 2`,
+				`skipper_filter_custom_total{filter="filter2",key="key1"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Measuring custom filter metrics with custom buckets, should measure labelled latency.",
+			opts: metrics.Options{HistogramBuckets: []float64{0.01, 0.1}},
+			addMetrics: func(pm *metrics.Prometheus) {
+				pm.MeasureFilterSince("filter1", "key1", time.Now().Add(-15*time.Millisecond))
+			},
+			expMetrics: []string{
+				`skipper_filter_custom_duration_seconds_bucket{filter="filter1",key="key1",le="0.01"} 0`,
+				`skipper_filter_custom_duration_seconds_bucket{filter="filter1",key="key1",le="0.1"} 1`,
+				`skipper_filter_custom_duration_seconds_bucket{filter="filter1",key="key1",le="+Inf"} 1`,
+				`skipper_filter_custom_duration_seconds_count{filter="filter1",key="key1"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Measuring labelled requests, should measure the latency with all the labels.",
+			opts: metrics.Options{
+				EnableLabelledRequestMetrics: true,
+				HistogramBuckets:             []float64{0.01, 0.1},
+			},
+			addMetrics: func(pm *metrics.Prometheus) {
+				pm.MeasureRequest(metrics.RequestLabels{
+					Route:   "route1",
+					Host:    "www.example.org",
+					Method:  "GET",
+					Code:    200,
+					Backend: "10.0.0.1:8080",
+				}, time.Now().Add(-15*time.Millisecond))
+				pm.MeasureBackendRequest(metrics.RequestLabels{
+					Route:   "route1",
+					Host:    "www.example.org",
+					Method:  "FOO",
+					Code:    502,
+					Backend: "10.0.0.1:8080",
+				}, time.Now().Add(-3*time.Millisecond))
+			},
+			expMetrics: []string{
+				`skipper_http_request_duration_seconds_bucket{backend="10.0.0.1:8080",code="200",host="www.example.org",method="GET",route="route1",le="0.01"} 0`,
+				`skipper_http_request_duration_seconds_bucket{backend="10.0.0.1:8080",code="200",host="www.example.org",method="GET",route="route1",le="0.1"} 1`,
+				`skipper_http_request_duration_seconds_count{backend="10.0.0.1:8080",code="200",host="www.example.org",method="GET",route="route1"} 1`,
+				`skipper_http_backend_duration_seconds_bucket{backend="10.0.0.1:8080",code="502",host="www.example.org",method="_unknownmethod_",route="route1",le="0.01"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Measuring labelled requests with route annotations, should add the annotation labels.",
+			opts: metrics.Options{
+				EnableLabelledRequestMetrics: true,
+				RouteAnnotationLabels:        []string{"owner", "cost-center"},
+			},
+			addMetrics: func(pm *metrics.Prometheus) {
+				pm.MeasureRequest(metrics.RequestLabels{
+					Route:       "route1",
+					Host:        "www.example.org",
+					Method:      "GET",
+					Code:        200,
+					Annotations: map[string]string{"owner": "team-x", "ticket": "ABC-1"},
+				}, time.Now())
+			},
+			expMetrics: []string{
+				`skipper_http_request_duration_seconds_count{annotation_cost_center="",annotation_owner="team-x",backend="",code="200",host="www.example.org",method="GET",route="route1"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Measuring more hosts than the limit, should collapse the additional hosts.",
+			opts: metrics.Options{
+				EnableServeHostMetrics: true,
+				HostLabelLimit:         2,
+			},
+			addMetrics: func(pm *metrics.Prometheus) {
+				for _, h := range []string{"foo.example.org", "bar.example.org", "baz.example.org", "qux.example.org", "foo.example.org"} {
+					pm.MeasureServe("route1", h, "GET", 200, time.Now())
+				}
+			},
+			expMetrics: []string{
+				`skipper_serve_host_duration_seconds_count{code="200",host="foo_example_org",method="GET"} 2`,
+				`skipper_serve_host_duration_seconds_count{code="200",host="bar_example_org",method="GET"} 1`,
+				`skipper_serve_host_duration_seconds_count{code="200",host="_unknownhost_",method="GET"} 2`,
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name: "Measuring more hosts than the limit, should keep the hosts of the routes.",
+			opts: metrics.Options{
+				EnableServeHostMetrics:   true,
+				EnableBackendHostMetrics: true,
+				HostLabelLimit:           1,
+			},
+			addMetrics: func(pm *metrics.Prometheus) {
+				pm.SetRouteHosts([]string{"^www[.]example[.]org$", "[.]example[.]com$"}, []string{"10.0.0.1:8080"})
+				for _, h := range []string{"junk1", "junk2", "www.example.org", "api.example.com", "app.example.com"} {
+					pm.MeasureServe("route1", h, "GET", 200, time.Now())
+				}
+
+				pm.MeasureBackendHost("10.0.0.2:8080", time.Now())
+				pm.MeasureBackendHost("10.0.0.1:8080", time.Now())
+			},
+			expMetrics: []string{
+				`skipper_serve_host_duration_seconds_count{code="200",host="junk1",method="GET"} 1`,
+				`skipper_serve_host_duration_seconds_count{code="200",host="www_example_org",method="GET"} 1`,
+				`skipper_serve_host_duration_seconds_count{code="200",host="api_example_com",method="GET"} 1`,
+				`skipper_serve_host_duration_seconds_count{code="200",host="_unknownhost_",method="GET"} 2`,
+				`skipper_backend_duration_seconds_count{host="10.0.0.2:8080",route=""} 1`,
+				`skipper_backend_duration_seconds_count{host="10.0.0.1:8080",route=""} 1`,
+			},
+			expCode: http.StatusOK,
+		},
 	}
 
 	for _, test := range tests {
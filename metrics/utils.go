@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"regexp"
 	"strings"
 
 	metrics "github.com/rcrowley/go-metrics"
@@ -24,6 +25,13 @@ func hostForKey(h string) string {
 	return h
 }
 
+var invalidLabelChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// the label name of a route annotation in the labelled request metrics
+func annotationLabel(name string) string {
+	return "annotation_" + invalidLabelChars.ReplaceAllString(name, "_")
+}
+
 func measuredMethod(m string) string {
 	switch m {
 	case "OPTIONS",
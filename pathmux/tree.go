@@ -359,3 +359,26 @@ func (t *Tree) LookupMatcher(path string, m Matcher) (interface{}, map[string]st
 
 	return node.leafValue, paramMap, value
 }
+
+func (n *node) walk(f func(interface{})) {
+	if n == nil {
+		return
+	}
+
+	if n.leafValue != nil {
+		f(n.leafValue)
+	}
+
+	for _, c := range n.staticChild {
+		c.walk(f)
+	}
+
+	n.wildcardChild.walk(f)
+	n.catchAllChild.walk(f)
+}
+
+// Walk calls f with every value stored in the tree. The same value is
+// passed to f as many times as it was added to the tree.
+func (t *Tree) Walk(f func(value interface{})) {
+	(*node)(t).walk(f)
+}
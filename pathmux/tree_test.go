@@ -367,3 +367,18 @@ func BenchmarkTreeOneParam(b *testing.B) {
 		tree.search("abc", tm)
 	}
 }
+
+func TestWalk(t *testing.T) {
+	tree := &Tree{}
+	for _, p := range []string{"/", "/foo", "/foo/:bar", "/foo/bar/baz", "/qux/*rest"} {
+		if err := tree.Add(p, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found := make(map[string]bool)
+	tree.Walk(func(v interface{}) { found[v.(string)] = true })
+	if len(found) != 5 {
+		t.Errorf("failed to walk all the values: %v", found)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Package builtin provides the set of custom predicates that skipper
+includes by default, in addition to the ones implemented by the routing
+package, e.g. Path or Host.
+*/
+package builtin
+
+import (
+	"github.com/zalando/skipper/loadbalancer"
+	"github.com/zalando/skipper/predicates/cookie"
+	"github.com/zalando/skipper/predicates/interval"
+	"github.com/zalando/skipper/predicates/query"
+	"github.com/zalando/skipper/predicates/source"
+	"github.com/zalando/skipper/predicates/traffic"
+	"github.com/zalando/skipper/predicates/value"
+	"github.com/zalando/skipper/routing"
+)
+
+// Specs returns the predicate specifications bundled with skipper. It
+// is used by the proxy, and by the tools validating the routes without
+// running it.
+func Specs() []routing.PredicateSpec {
+	return []routing.PredicateSpec{
+		source.New(),
+		source.NewFromLast(),
+		interval.NewBetween(),
+		interval.NewBefore(),
+		interval.NewAfter(),
+		cookie.New(),
+		query.New(),
+		traffic.New(),
+		traffic.NewHash(),
+		value.NewHeaderAbsent(),
+		value.NewHeaderAll(),
+		value.NewHeaderEqualFold(),
+		value.NewHeaderNumber(),
+		value.NewHeaderVersion(),
+		value.NewQueryParamAbsent(),
+		value.NewQueryParamAll(),
+		value.NewQueryParamEqualFold(),
+		value.NewQueryParamNumber(),
+		value.NewQueryParamVersion(),
+		loadbalancer.NewGroup(),
+		loadbalancer.NewMember(),
+	}
+}
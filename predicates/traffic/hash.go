@@ -0,0 +1,160 @@
+package traffic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	snet "github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	// The eskip name of the hash based traffic predicate.
+	HashPredicateName = "TrafficHash"
+
+	// The sources of the hashed value.
+	HashSourceHeader = "header"
+	HashSourceCookie = "cookie"
+	HashSourceIP     = "source"
+	HashSourceJWT    = "jwt"
+
+	defaultJWTClaim = "sub"
+)
+
+type hashSpec struct{}
+
+type hashPredicate struct {
+	from, to float64
+	source   string
+	name     string
+}
+
+// NewHash creates a new traffic control predicate specification, whose
+// instances match a fixed share of the requests, based on the hash of a
+// request attribute, so that the requests with the same value always
+// match the same route. See the package documentation for the details.
+func NewHash() routing.PredicateSpec { return &hashSpec{} }
+
+func (s *hashSpec) Name() string { return HashPredicateName }
+
+func (s *hashSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) < 3 || len(args) > 4 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	from, ok := args[0].(float64)
+	if !ok || from < 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	to, ok := args[1].(float64)
+	if !ok || to <= from || to > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	source, ok := args[2].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	var name string
+	if len(args) == 4 {
+		if name, ok = args[3].(string); !ok || name == "" {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	}
+
+	switch source {
+	case HashSourceHeader, HashSourceCookie:
+		if name == "" {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	case HashSourceIP:
+		if name != "" {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	case HashSourceJWT:
+		if name == "" {
+			name = defaultJWTClaim
+		}
+	default:
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &hashPredicate{from: from, to: to, source: source, name: name}, nil
+}
+
+// returns a claim of the JWT bearer token of a request, without verifying
+// the token. The verification is left to the filters or the backends.
+func jwtClaim(r *http.Request, claim string) string {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) {
+		return ""
+	}
+
+	parts := strings.Split(h[len(prefix):], ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	if v, ok := claims[claim].(string); ok {
+		return v
+	}
+
+	return ""
+}
+
+func (p *hashPredicate) value(r *http.Request) string {
+	switch p.source {
+	case HashSourceHeader:
+		return r.Header.Get(p.name)
+	case HashSourceCookie:
+		if c, err := r.Cookie(p.name); err == nil {
+			return c.Value
+		}
+
+		return ""
+	case HashSourceIP:
+		if ip := snet.RemoteHost(r); ip != nil {
+			return ip.String()
+		}
+
+		return ""
+	default:
+		return jwtClaim(r, p.name)
+	}
+}
+
+// maps a value to [0, 1)
+func bucket(v string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(v))
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
+
+// Match returns true when the hash of the request attribute falls into
+// [from, to). Requests without the attribute don't match.
+func (p *hashPredicate) Match(r *http.Request) bool {
+	v := p.value(r)
+	if v == "" {
+		return false
+	}
+
+	b := bucket(v)
+	return p.from <= b && b < p.to
+}
@@ -0,0 +1,158 @@
+package traffic
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateHash(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		"no args",
+		nil,
+		true,
+	}, {
+		"header without name",
+		[]interface{}{0.0, .5, "header"},
+		true,
+	}, {
+		"invalid range",
+		[]interface{}{.5, .2, "header", "X-User-Id"},
+		true,
+	}, {
+		"upper bound bigger than 1",
+		[]interface{}{.5, 1.2, "header", "X-User-Id"},
+		true,
+	}, {
+		"unknown source",
+		[]interface{}{0.0, .5, "query", "user"},
+		true,
+	}, {
+		"source ip with name",
+		[]interface{}{0.0, .5, "source", "foo"},
+		true,
+	}, {
+		"header",
+		[]interface{}{0.0, .5, "header", "X-User-Id"},
+		false,
+	}, {
+		"source ip",
+		[]interface{}{.5, 1.0, "source"},
+		false,
+	}, {
+		"jwt with default claim",
+		[]interface{}{.5, 1.0, "jwt"},
+		false,
+	}} {
+		_, err := NewHash().Create(ti.args)
+		if ti.err != (err != nil) {
+			t.Error(ti.msg, "unexpected error result", err)
+		}
+	}
+}
+
+func jwtToken(sub string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub": %q}`, sub)))
+	return "Bearer header." + payload + ".signature"
+}
+
+func TestHashSplit(t *testing.T) {
+	for _, ti := range []struct {
+		msg     string
+		source  string
+		name    string
+		request func(user string) *http.Request
+	}{{
+		"header",
+		HashSourceHeader,
+		"X-User-Id",
+		func(user string) *http.Request {
+			return &http.Request{Header: http.Header{"X-User-Id": []string{user}}}
+		},
+	}, {
+		"cookie",
+		HashSourceCookie,
+		"user",
+		func(user string) *http.Request {
+			return &http.Request{Header: http.Header{"Cookie": []string{"user=" + user}}}
+		},
+	}, {
+		"jwt",
+		HashSourceJWT,
+		"",
+		func(user string) *http.Request {
+			return &http.Request{Header: http.Header{"Authorization": []string{jwtToken(user)}}}
+		},
+	}, {
+		"source ip",
+		HashSourceIP,
+		"",
+		func(user string) *http.Request {
+			return &http.Request{Header: http.Header{"X-Forwarded-For": []string{"10.0.0." + user}}}
+		},
+	}} {
+		var routes []*hashPredicate
+		for _, r := range [][]float64{{0, .2}, {.2, .5}, {.5, 1}} {
+			args := []interface{}{r[0], r[1], ti.source}
+			if ti.name != "" {
+				args = append(args, ti.name)
+			}
+
+			p, err := NewHash().Create(args)
+			if err != nil {
+				t.Fatal(ti.msg, err)
+			}
+
+			routes = append(routes, p.(*hashPredicate))
+		}
+
+		counts := make([]int, len(routes))
+		for i := 0; i < 250; i++ {
+			req := ti.request(fmt.Sprint(i))
+
+			matched := -1
+			for j, p := range routes {
+				if p.Match(req) {
+					if matched >= 0 {
+						t.Fatal(ti.msg, "multiple routes matched")
+					}
+
+					matched = j
+				}
+			}
+
+			if matched < 0 {
+				t.Fatal(ti.msg, "no route matched")
+			}
+
+			// the same request always matches the same route
+			if !routes[matched].Match(ti.request(fmt.Sprint(i))) {
+				t.Fatal(ti.msg, "not deterministic")
+			}
+
+			counts[matched]++
+		}
+
+		for i, c := range counts {
+			if c == 0 {
+				t.Error(ti.msg, "no requests matched route", i)
+			}
+		}
+	}
+}
+
+func TestHashMissingAttribute(t *testing.T) {
+	p, err := NewHash().Create([]interface{}{0.0, 1.0, "header", "X-User-Id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Match(&http.Request{Header: http.Header{}}) {
+		t.Error("request without the header matched")
+	}
+}
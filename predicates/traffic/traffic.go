@@ -66,6 +66,45 @@ independent traffic controlled route sets, which uses session stickiness:
         responseCookie("catalog-test", "default") ->
         "https://catalog";
 
+The TrafficHash predicate provides deterministic, stateless traffic
+splitting. It hashes a request attribute into a number between 0 and 1,
+and matches when the number falls into the range set by its first two
+arguments, the lower bound included and the upper excluded. This way, the
+requests with the same attribute value always match the same route,
+without requiring the backend to set a cookie.
+
+The third argument sets the source of the hashed value:
+
+    - "header": the value of the request header set by the fourth argument
+    - "cookie": the value of the request cookie set by the fourth argument
+    - "source": the source IP of the request, or the first address of the
+      X-Forwarded-For header
+    - "jwt": a claim of the JWT bearer token in the Authorization header,
+      by default "sub", or the claim set by the fourth argument. The token
+      is not verified by the predicate.
+
+Requests without the attribute don't match. The ranges of the sibling
+routes should not overlap, and they should sum up to at most 1, while the
+remaining share of the requests and the requests without the attribute
+are matched by a route without the predicate:
+
+    // 10% of the users
+    v3:
+        TrafficHash(0, .1, "header", "X-User-Id") ->
+        "https://api-v3";
+
+    // 20% of the users
+    v2:
+        TrafficHash(.1, .3, "header", "X-User-Id") ->
+        "https://api-v2";
+
+    // the remaining 70% of the users, and the anonymous requests
+    v1:
+        "https://api-v1";
+
+The routes using the same source and name share the same hash of a
+request, so routes with the same attribute and overlapping ranges match
+the same users.
 */
 package traffic
 
@@ -0,0 +1,92 @@
+package value
+
+import (
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version. The build metadata is ignored.
+type semver struct {
+	numbers    [3]int
+	prerelease []string
+}
+
+// parses a semantic version. The minor and the patch numbers are
+// optional, and the "v" prefix is ignored.
+func parseSemver(s string) (semver, bool) {
+	var v semver
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if i == len(s)-1 {
+			return v, false
+		}
+
+		v.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > len(v.numbers) {
+		return v, false
+	}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+
+		v.numbers[i] = n
+	}
+
+	return v, true
+}
+
+// compares the identifiers of the pre-release versions. Numeric
+// identifiers are lower than the alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	na, erra := strconv.Atoi(a)
+	nb, errb := strconv.Atoi(b)
+	switch {
+	case erra == nil && errb == nil:
+		return compareNumbers(float64(na), float64(nb))
+	case erra == nil:
+		return -1
+	case errb == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// returns -1, 0 or 1, when v is lower, equal or higher than the other
+// version
+func (v semver) compare(other semver) int {
+	for i := range v.numbers {
+		if c := compareNumbers(float64(v.numbers[i]), float64(other.numbers[i])); c != 0 {
+			return c
+		}
+	}
+
+	// a pre-release version is lower than the release
+	switch {
+	case len(v.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.prerelease) && i < len(other.prerelease); i++ {
+		if c := comparePrerelease(v.prerelease[i], other.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareNumbers(float64(len(v.prerelease)), float64(len(other.prerelease)))
+}
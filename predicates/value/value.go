@@ -0,0 +1,322 @@
+/*
+Package value implements predicates to match routes based on the values
+of the request headers and query parameters, extending the Header,
+HeaderRegexp and QueryParam predicates.
+
+The Absent predicates match when the request doesn't contain the header
+or the query parameter:
+
+    // the requests without a version header
+    legacy: HeaderAbsent("X-Client-Version") -> "https://legacy.example.org";
+
+    // the requests without a debug query parameter
+    cached: QueryParamAbsent("debug") -> "https://cache.example.org";
+
+The All predicates match when the request contains the header or the
+query parameter, and all of its values match the regular expression.
+(The HeaderRegexp and QueryParam predicates match, when any of the values
+matches.)
+
+    numeric: QueryParamAll("id", /^[0-9]+$/) -> "https://ids.example.org";
+
+The EqualFold predicates match when any of the values is equal to the
+argument, compared case-insensitively:
+
+    beta: HeaderEqualFold("X-Beta", "true") -> "https://beta.example.org";
+
+The Number and the Version predicates compare any of the values to the
+argument, with one of the operators: "==", "!=", "<", "<=", ">" or ">=".
+The Number predicates compare decimal numbers, while the Version
+predicates compare semantic versions, like 2.3.0, where the missing minor
+or patch numbers count as zero, an optional "v" prefix is ignored, and a
+pre-release version, like 2.3.0-beta.1, is lower than the release. The
+values that cannot be parsed don't match:
+
+    // route the old app versions to a compatibility backend
+    compat:
+        HeaderVersion("X-Client-Version", "<", "2.3.0") ->
+        "https://compat.example.org";
+
+    // large pages
+    large: QueryParamNumber("limit", ">", 100) -> "https://batch.example.org";
+*/
+package value
+
+import (
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	HeaderAbsentName        = "HeaderAbsent"
+	HeaderAllName           = "HeaderAll"
+	HeaderEqualFoldName     = "HeaderEqualFold"
+	HeaderNumberName        = "HeaderNumber"
+	HeaderVersionName       = "HeaderVersion"
+	QueryParamAbsentName    = "QueryParamAbsent"
+	QueryParamAllName       = "QueryParamAll"
+	QueryParamEqualFoldName = "QueryParamEqualFold"
+	QueryParamNumberName    = "QueryParamNumber"
+	QueryParamVersionName   = "QueryParamVersion"
+)
+
+type source int
+
+const (
+	header source = iota + 1
+	query
+)
+
+type kind int
+
+const (
+	absent kind = iota + 1
+	all
+	equalFold
+	number
+	version
+)
+
+type spec struct {
+	source source
+	kind   kind
+}
+
+var names = map[spec]string{
+	{header, absent}:    HeaderAbsentName,
+	{header, all}:       HeaderAllName,
+	{header, equalFold}: HeaderEqualFoldName,
+	{header, number}:    HeaderNumberName,
+	{header, version}:   HeaderVersionName,
+	{query, absent}:     QueryParamAbsentName,
+	{query, all}:        QueryParamAllName,
+	{query, equalFold}:  QueryParamEqualFoldName,
+	{query, number}:     QueryParamNumberName,
+	{query, version}:    QueryParamVersionName,
+}
+
+type predicate struct {
+	source  source
+	kind    kind
+	name    string
+	rx      *regexp.Regexp
+	value   string
+	compare func(int) bool
+	number  float64
+	version semver
+}
+
+// NewHeaderAbsent creates a predicate specification, whose instances
+// match the requests without the header.
+func NewHeaderAbsent() routing.PredicateSpec { return &spec{header, absent} }
+
+// NewHeaderAll creates a predicate specification, whose instances match
+// the requests where all the values of the header match a regular
+// expression.
+func NewHeaderAll() routing.PredicateSpec { return &spec{header, all} }
+
+// NewHeaderEqualFold creates a predicate specification, whose instances
+// match the requests where a value of the header is equal to the
+// argument, compared case-insensitively.
+func NewHeaderEqualFold() routing.PredicateSpec { return &spec{header, equalFold} }
+
+// NewHeaderNumber creates a predicate specification, whose instances
+// compare the values of the header as numbers.
+func NewHeaderNumber() routing.PredicateSpec { return &spec{header, number} }
+
+// NewHeaderVersion creates a predicate specification, whose instances
+// compare the values of the header as semantic versions.
+func NewHeaderVersion() routing.PredicateSpec { return &spec{header, version} }
+
+// NewQueryParamAbsent creates a predicate specification, whose instances
+// match the requests without the query parameter.
+func NewQueryParamAbsent() routing.PredicateSpec { return &spec{query, absent} }
+
+// NewQueryParamAll creates a predicate specification, whose instances
+// match the requests where all the values of the query parameter match a
+// regular expression.
+func NewQueryParamAll() routing.PredicateSpec { return &spec{query, all} }
+
+// NewQueryParamEqualFold creates a predicate specification, whose
+// instances match the requests where a value of the query parameter is
+// equal to the argument, compared case-insensitively.
+func NewQueryParamEqualFold() routing.PredicateSpec { return &spec{query, equalFold} }
+
+// NewQueryParamNumber creates a predicate specification, whose instances
+// compare the values of the query parameter as numbers.
+func NewQueryParamNumber() routing.PredicateSpec { return &spec{query, number} }
+
+// NewQueryParamVersion creates a predicate specification, whose instances
+// compare the values of the query parameter as semantic versions.
+func NewQueryParamVersion() routing.PredicateSpec { return &spec{query, version} }
+
+func (s *spec) Name() string { return names[*s] }
+
+// returns the function checking the result of a comparison for an
+// operator
+func operator(op string) (func(int) bool, bool) {
+	switch op {
+	case "==":
+		return func(c int) bool { return c == 0 }, true
+	case "!=":
+		return func(c int) bool { return c != 0 }, true
+	case "<":
+		return func(c int) bool { return c < 0 }, true
+	case "<=":
+		return func(c int) bool { return c <= 0 }, true
+	case ">":
+		return func(c int) bool { return c > 0 }, true
+	case ">=":
+		return func(c int) bool { return c >= 0 }, true
+	default:
+		return nil, false
+	}
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (s *spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) == 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok || name == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if s.source == header {
+		name = http.CanonicalHeaderKey(name)
+	}
+
+	p := &predicate{source: s.source, kind: s.kind, name: name}
+	switch s.kind {
+	case absent:
+		if len(args) != 1 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	case all:
+		if len(args) != 2 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		exp, ok := args[1].(string)
+		if !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		rx, err := regexp.Compile(exp)
+		if err != nil {
+			return nil, err
+		}
+
+		p.rx = rx
+	case equalFold:
+		if len(args) != 2 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		if p.value, ok = args[1].(string); !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	default:
+		if len(args) != 3 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		op, ok := args[1].(string)
+		if !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		if p.compare, ok = operator(op); !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		if s.kind == number {
+			if p.number, ok = args[2].(float64); !ok {
+				return nil, predicates.ErrInvalidPredicateParameters
+			}
+		} else {
+			v, ok := args[2].(string)
+			if !ok {
+				return nil, predicates.ErrInvalidPredicateParameters
+			}
+
+			if p.version, ok = parseSemver(v); !ok {
+				return nil, predicates.ErrInvalidPredicateParameters
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func (p *predicate) values(r *http.Request) ([]string, bool) {
+	if p.source == header {
+		vals, ok := r.Header[p.name]
+		return vals, ok
+	}
+
+	vals, ok := r.URL.Query()[p.name]
+	return vals, ok
+}
+
+// checks a single value, for the predicates matching any of the values
+func (p *predicate) matchValue(v string) bool {
+	switch p.kind {
+	case equalFold:
+		return strings.EqualFold(v, p.value)
+	case number:
+		// NaN and Inf are accepted by ParseFloat, but they are not
+		// numbers that can be compared
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) && p.compare(compareNumbers(n, p.number))
+	default:
+		sv, ok := parseSemver(v)
+		return ok && p.compare(sv.compare(p.version))
+	}
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	vals, ok := p.values(r)
+	switch p.kind {
+	case absent:
+		return !ok
+	case all:
+		if !ok || len(vals) == 0 {
+			return false
+		}
+
+		for _, v := range vals {
+			if !p.rx.MatchString(v) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		for _, v := range vals {
+			if p.matchValue(v) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,258 @@
+package value
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/routing"
+)
+
+var specs = []routing.PredicateSpec{
+	NewHeaderAbsent(),
+	NewHeaderAll(),
+	NewHeaderEqualFold(),
+	NewHeaderNumber(),
+	NewHeaderVersion(),
+	NewQueryParamAbsent(),
+	NewQueryParamAll(),
+	NewQueryParamEqualFold(),
+	NewQueryParamNumber(),
+	NewQueryParamVersion(),
+}
+
+func createPredicate(t *testing.T, def string) (routing.Predicate, error) {
+	ps, err := eskip.ParsePredicates(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range specs {
+		if s.Name() == ps[0].Name {
+			return s.Create(ps[0].Args)
+		}
+	}
+
+	t.Fatal("spec not found", ps[0].Name)
+	return nil, nil
+}
+
+func TestCreate(t *testing.T) {
+	for _, ti := range []struct {
+		msg string
+		def string
+		err bool
+	}{{
+		"absent",
+		`HeaderAbsent("X-Foo")`,
+		false,
+	}, {
+		"absent with value",
+		`QueryParamAbsent("foo", "bar")`,
+		true,
+	}, {
+		"all with invalid regexp",
+		`HeaderAll("X-Foo", "(")`,
+		true,
+	}, {
+		"equal fold without value",
+		`QueryParamEqualFold("foo")`,
+		true,
+	}, {
+		"number",
+		`HeaderNumber("X-Count", "<=", 3)`,
+		false,
+	}, {
+		"number with string",
+		`HeaderNumber("X-Count", "<=", "3")`,
+		true,
+	}, {
+		"unknown operator",
+		`QueryParamNumber("count", "=~", 3)`,
+		true,
+	}, {
+		"version",
+		`HeaderVersion("X-Client-Version", ">=", "2.3.0")`,
+		false,
+	}, {
+		"invalid version",
+		`QueryParamVersion("v", ">=", "2.x")`,
+		true,
+	}} {
+		_, err := createPredicate(t, ti.def)
+		if ti.err != (err != nil) {
+			t.Error(ti.msg, "unexpected error result", err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, ti := range []struct {
+		msg    string
+		def    string
+		header http.Header
+		query  string
+		match  bool
+	}{{
+		"header absent",
+		`HeaderAbsent("x-foo")`,
+		http.Header{"X-Bar": []string{"bar"}},
+		"",
+		true,
+	}, {
+		"header present",
+		`HeaderAbsent("x-foo")`,
+		http.Header{"X-Foo": []string{""}},
+		"",
+		false,
+	}, {
+		"query absent",
+		`QueryParamAbsent("debug")`,
+		nil,
+		"foo=bar",
+		true,
+	}, {
+		"query present without value",
+		`QueryParamAbsent("debug")`,
+		nil,
+		"debug",
+		false,
+	}, {
+		"all values match",
+		`QueryParamAll("id", /^[0-9]+$/)`,
+		nil,
+		"id=1&id=42",
+		true,
+	}, {
+		"not all values match",
+		`QueryParamAll("id", /^[0-9]+$/)`,
+		nil,
+		"id=1&id=foo",
+		false,
+	}, {
+		"all without values",
+		`HeaderAll("X-Foo", /bar/)`,
+		nil,
+		"",
+		false,
+	}, {
+		"equal fold",
+		`HeaderEqualFold("X-Beta", "true")`,
+		http.Header{"X-Beta": []string{"TRUE"}},
+		"",
+		true,
+	}, {
+		"equal fold any value",
+		`QueryParamEqualFold("mode", "Beta")`,
+		nil,
+		"mode=alpha&mode=beta",
+		true,
+	}, {
+		"number",
+		`QueryParamNumber("limit", ">", 100)`,
+		nil,
+		"limit=100.5",
+		true,
+	}, {
+		"number not matching",
+		`QueryParamNumber("limit", ">", 100)`,
+		nil,
+		"limit=100",
+		false,
+	}, {
+		"not a number",
+		`HeaderNumber("X-Count", "!=", 3)`,
+		http.Header{"X-Count": []string{"three"}},
+		"",
+		false,
+	}, {
+		"NaN",
+		`HeaderNumber("X-Count", "!=", 3)`,
+		http.Header{"X-Count": []string{"NaN"}},
+		"",
+		false,
+	}, {
+		"infinity",
+		`QueryParamNumber("limit", ">", 100)`,
+		nil,
+		"limit=%2BInf",
+		false,
+	}, {
+		"older version",
+		`HeaderVersion("X-Client-Version", "<", "2.3.0")`,
+		http.Header{"X-Client-Version": []string{"2.2.14"}},
+		"",
+		true,
+	}, {
+		"newer version",
+		`HeaderVersion("X-Client-Version", "<", "2.3.0")`,
+		http.Header{"X-Client-Version": []string{"v2.10"}},
+		"",
+		false,
+	}, {
+		"pre-release version",
+		`HeaderVersion("X-Client-Version", "<", "2.3.0")`,
+		http.Header{"X-Client-Version": []string{"2.3.0-beta.1"}},
+		"",
+		true,
+	}, {
+		"equal version",
+		`QueryParamVersion("v", "==", "2.3")`,
+		nil,
+		"v=2.3.0%2Bbuild.5",
+		true,
+	}, {
+		"invalid version",
+		`HeaderVersion("X-Client-Version", "!=", "2.3.0")`,
+		http.Header{"X-Client-Version": []string{"latest"}},
+		"",
+		false,
+	}} {
+		p, err := createPredicate(t, ti.def)
+		if err != nil {
+			t.Error(ti.msg, err)
+			continue
+		}
+
+		r := &http.Request{Header: ti.header, URL: &url.URL{RawQuery: ti.query}}
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+
+		if p.Match(r) != ti.match {
+			t.Error(ti.msg, "unexpected match result, expected:", ti.match)
+		}
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	for _, ti := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1", "1.0.0", 0},
+		{"1.0.0", "2.0.0", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+	} {
+		a, ok := parseSemver(ti.a)
+		if !ok {
+			t.Fatal("failed to parse", ti.a)
+		}
+
+		b, ok := parseSemver(ti.b)
+		if !ok {
+			t.Fatal("failed to parse", ti.b)
+		}
+
+		if c := a.compare(b); c != ti.expected {
+			t.Errorf("invalid comparison of %s and %s, expected: %d, got: %d", ti.a, ti.b, ti.expected, c)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/zalando/skipper/logging"
+)
+
+func TestAccessLogState(t *testing.T) {
+	s := startTestServer(nil, 0, func(*http.Request) {})
+	defer s.Close()
+
+	doc := fmt.Sprintf(`hello: Path("/hello") -> omitAccessLogFields("uri") -> "%s"`, s.URL)
+	tp, err := newTestProxy(doc, FlagsNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	var buf bytes.Buffer
+	if err := logging.Init(logging.Options{
+		AccessLogOutput: &buf,
+		AccessLogFormat: "${route-id} ${backend-host} ${uri}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "https://www.example.org/hello", nil)
+	logging.NewHandler(tp.proxy).ServeHTTP(httptest.NewRecorder(), r)
+
+	u, _ := url.Parse(s.URL)
+	if expected := fmt.Sprintf("hello %s -\n", u.Host); buf.String() != expected {
+		t.Errorf("invalid access log entry, expected: %s, got: %s", expected, buf.String())
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/loadbalancer"
+	"github.com/zalando/skipper/logging"
 	"github.com/zalando/skipper/metrics"
 	"github.com/zalando/skipper/routing"
 )
@@ -32,6 +34,8 @@ type context struct {
 	outgoingDebugRequest  *http.Request
 	incomingDebugResponse *http.Response
 	loopCounter           int
+	backendHost           string
+	upstreamDuration      time.Duration
 	startServe            time.Time
 	metrics               *filterMetrics
 	tracer                opentracing.Tracer
@@ -40,7 +44,7 @@ type context struct {
 }
 
 type filterMetrics struct {
-	prefix string
+	filter string
 	impl   metrics.Metrics
 }
 
@@ -217,6 +221,11 @@ func (c *context) metricsHost() string {
 	return c.request.Host
 }
 
+func (c *context) stickyPins() map[string]string {
+	pins, _ := c.stateBag[loadbalancer.StickyStateKey].(map[string]string)
+	return pins
+}
+
 func (c *context) clone() *context {
 	cc := *c
 
@@ -226,14 +235,64 @@ func (c *context) clone() *context {
 	return &cc
 }
 
-func (c *context) setMetricsPrefix(prefix string) {
-	c.metrics.prefix = prefix + ".custom."
+func (c *context) setMetricsFilter(filterName string) {
+	c.metrics.filter = filterName
+}
+
+func (c *context) requestLabels() metrics.RequestLabels {
+	l := metrics.RequestLabels{
+		Host:   c.metricsHost(),
+		Method: c.request.Method,
+	}
+
+	if c.route != nil {
+		l.Route = c.route.Id
+		l.Backend = c.route.Host
+		l.Annotations = c.route.Annotations
+	}
+
+	if c.response != nil {
+		l.Code = c.response.StatusCode
+	}
+
+	return l
 }
 
 func (m *filterMetrics) IncCounter(key string) {
-	m.impl.IncCounter(m.prefix + key)
+	m.impl.IncFilterCounter(m.filter, key)
 }
 
 func (m *filterMetrics) MeasureSince(key string, start time.Time) {
-	m.impl.MeasureSince(m.prefix+key, start)
+	m.impl.MeasureFilterSince(m.filter, key, start)
+}
+
+// passes the details of the request to the access log, when the state
+// bag of the access log is available, including the settings of the
+// access log filters
+func (c *context) setAccessLogState(bag map[string]interface{}) {
+	if bag == nil {
+		return
+	}
+
+	if c.route != nil {
+		bag[logging.RouteIDStateKey] = c.route.Id
+		if len(c.route.Annotations) > 0 {
+			bag[logging.RouteAnnotationsStateKey] = c.route.Annotations
+		}
+	}
+
+	if c.backendHost != "" {
+		bag[logging.BackendHostStateKey] = c.backendHost
+		bag[logging.UpstreamDurationStateKey] = c.upstreamDuration
+	}
+
+	for _, key := range []string{
+		logging.MaskedFieldsStateKey,
+		logging.OmittedFieldsStateKey,
+		logging.AccessLogFilterStateKey,
+	} {
+		if v, ok := c.stateBag[key]; ok {
+			bag[key] = v
+		}
+	}
 }
@@ -0,0 +1,143 @@
+package proxy_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/loadbalancer"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/proxy/proxytest"
+	"github.com/zalando/skipper/routing"
+)
+
+func TestStickySessions(t *testing.T) {
+	be1 := testBackend("BE-1", 200)
+	defer be1.Close()
+	be2 := testBackend("BE-2", 200)
+	defer be2.Close()
+
+	routes, err := eskip.Parse(fmt.Sprintf(`
+		group: LBGroup("group") -> lbDecide("group", 2) -> <loopback>;
+		member0: LBMember("group", 0) -> "%s";
+		member1: LBMember("group", 1) -> "%s";
+	`, be1.URL, be2.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{
+		CloseIdleConnsPeriod: -time.Second,
+		StickySessions:       loadbalancer.NewSticky(loadbalancer.StickyOptions{Secret: []byte("secret")}),
+	}, routes...)
+	defer p.Close()
+
+	get := func(c *http.Client) string {
+		rsp, err := c.Get(p.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer rsp.Body.Close()
+		b, err := ioutil.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(b)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Jar: jar}
+	first := get(client)
+	for i := 0; i < 6; i++ {
+		if rsp := get(client); rsp != first {
+			t.Fatalf("failed to stick to the same member, expected: %s, got: %s", first, rsp)
+		}
+	}
+
+	responses := make(map[string]bool)
+	for i := 0; i < 6; i++ {
+		responses[get(&http.Client{})] = true
+	}
+
+	if len(responses) != 2 {
+		t.Error("failed to balance clients without affinity cookie")
+	}
+}
+
+func TestStickySessionsRemovedMember(t *testing.T) {
+	be1 := testBackend("BE-1", 200)
+	defer be1.Close()
+	be2 := testBackend("BE-2", 200)
+	defer be2.Close()
+
+	routes, err := eskip.Parse(fmt.Sprintf(`
+		group: LBGroup("group") -> lbDecide("group", 2) -> <loopback>;
+		member0: LBMember("group", 0) -> "%s";
+		member1: LBMember("group", 1) -> "%s";
+	`, be1.URL, be2.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sticky := loadbalancer.NewSticky(loadbalancer.StickyOptions{Secret: []byte("secret")})
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{
+		CloseIdleConnsPeriod: -time.Second,
+		StickySessions:       sticky,
+	}, routes...)
+	defer p.Close()
+
+	// pin the client to a member that is not in the group
+	h := make(http.Header)
+	sticky.SetCookie(h, nil, &routing.Route{
+		Route:          eskip.Route{Backend: "https://removed.example.org"},
+		Group:          "group",
+		IsLoadBalanced: true,
+	})
+
+	cookies := (&http.Response{Header: h}).Cookies()
+	if len(cookies) != 1 {
+		t.Fatal("failed to create the affinity cookie")
+	}
+
+	get := func() string {
+		req, err := http.NewRequest("GET", p.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.AddCookie(cookies[0])
+		rsp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer rsp.Body.Close()
+		b, err := ioutil.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(b)
+	}
+
+	previous := get()
+	for i := 0; i < 6; i++ {
+		rsp := get()
+		if rsp == previous {
+			t.Fatalf("failed to round-robin the requests pinned to a removed member, got %s twice", rsp)
+		}
+
+		previous = rsp
+	}
+}
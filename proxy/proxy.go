@@ -159,6 +159,11 @@ type Params struct {
 
 	// MaxIdleConns limits the number of idle connections to all backends, 0 means no limit
 	MaxIdleConns int
+
+	// StickySessions enables session affinity for the load balanced
+	// routes. When set, the clients are pinned to the selected group
+	// members with a signed cookie.
+	StickySessions *loadbalancer.Sticky
 }
 
 var (
@@ -233,6 +238,7 @@ type Proxy struct {
 	defaultHTTPStatus   int
 	openTracer          ot.Tracer
 	lb                  *loadbalancer.LB
+	sticky              *loadbalancer.Sticky
 }
 
 // proxyError is used to wrap errors during proxying and to indicate
@@ -498,6 +504,7 @@ func WithParams(p Params) *Proxy {
 		defaultHTTPStatus:   defaultHTTPStatus,
 		openTracer:          p.OpenTracer,
 		lb:                  p.LoadBalancer,
+		sticky:              p.StickySessions,
 	}
 }
 
@@ -511,6 +518,19 @@ func tryCatch(p func(), onErr func(err interface{})) {
 	p()
 }
 
+// starts a span for the execution of a single filter, as the child of
+// the ingress span
+func (p *Proxy) startFilterSpan(ctx *context, operation, filterName string) ot.Span {
+	parent := ot.SpanFromContext(ctx.request.Context())
+	if parent == nil {
+		return (&ot.NoopTracer{}).StartSpan(operation)
+	}
+
+	span := p.openTracer.StartSpan(operation, ot.ChildOf(parent.Context()))
+	span.SetTag("skipper.filter", filterName)
+	return span
+}
+
 // applies filters to a request
 func (p *Proxy) applyFiltersToRequest(f []*routing.RouteFilter, ctx *context) []*routing.RouteFilter {
 	filtersStart := time.Now()
@@ -518,11 +538,14 @@ func (p *Proxy) applyFiltersToRequest(f []*routing.RouteFilter, ctx *context) []
 	var filters = make([]*routing.RouteFilter, 0, len(f))
 	for _, fi := range f {
 		start := time.Now()
+		span := p.startFilterSpan(ctx, "filter_request", fi.Name)
 		tryCatch(func() {
-			ctx.setMetricsPrefix(fi.Name)
+			ctx.setMetricsFilter(fi.Name)
 			fi.Request(ctx)
 			p.metrics.MeasureFilterRequest(fi.Name, start)
 		}, func(err interface{}) {
+			ext.Error.Set(span, true)
+			span.LogKV("error", fmt.Sprint(err))
 			if p.flags.Debug() {
 				// these errors are collected for the debug mode to be able
 				// to report in the response which filters failed.
@@ -532,6 +555,7 @@ func (p *Proxy) applyFiltersToRequest(f []*routing.RouteFilter, ctx *context) []
 
 			p.log.Errorf("error while processing filter during request: %s: %v", fi.Name, err)
 		})
+		span.Finish()
 
 		filters = append(filters, fi)
 		if ctx.deprecatedShunted() || ctx.shunted() {
@@ -551,11 +575,14 @@ func (p *Proxy) applyFiltersToResponse(filters []*routing.RouteFilter, ctx *cont
 	for i := range filters {
 		fi := filters[count-1-i]
 		start := time.Now()
+		span := p.startFilterSpan(ctx, "filter_response", fi.Name)
 		tryCatch(func() {
-			ctx.setMetricsPrefix(fi.Name)
+			ctx.setMetricsFilter(fi.Name)
 			fi.Response(ctx)
 			p.metrics.MeasureFilterResponse(fi.Name, start)
 		}, func(err interface{}) {
+			ext.Error.Set(span, true)
+			span.LogKV("error", fmt.Sprint(err))
 			if p.flags.Debug() {
 				// these errors are collected for the debug mode to be able
 				// to report in the response which filters failed.
@@ -565,6 +592,7 @@ func (p *Proxy) applyFiltersToResponse(filters []*routing.RouteFilter, ctx *cont
 
 			p.log.Errorf("error while processing filters during response: %s: %v", fi.Name, err)
 		})
+		span.Finish()
 	}
 
 	p.metrics.MeasureAllFiltersResponse(ctx.route.Id, filtersStart)
@@ -599,6 +627,11 @@ func (p *Proxy) sendError(c *context, id string, code int) {
 		code,
 		c.startServe,
 	)
+
+	labels := c.requestLabels()
+	labels.Route = id
+	labels.Code = code
+	p.metrics.MeasureRequest(labels, c.startServe)
 }
 
 func (p *Proxy) makeUpgradeRequest(ctx *context, route *routing.Route, req *http.Request) error {
@@ -662,7 +695,10 @@ func (p *Proxy) makeBackendRequest(ctx *context) (*http.Response, *proxyError) {
 
 	req = req.WithContext(ot.ContextWithSpan(req.Context(), proxySpan))
 
+	ctx.backendHost = req.URL.Host
+	upstreamStart := time.Now()
 	response, err := p.roundTripper.RoundTrip(req)
+	ctx.upstreamDuration += time.Since(upstreamStart)
 	if err != nil {
 		ext.Error.Set(proxySpan, true)
 		proxySpan.LogKV(`error`, err.Error())
@@ -786,6 +822,20 @@ func (p *Proxy) do(ctx *context) error {
 		return errRouteLookupFailed
 	}
 
+	if p.sticky != nil {
+		if ctx.loopCounter == 1 {
+			if pins := p.sticky.Pins(ctx.request); len(pins) > 0 {
+				ctx.stateBag[loadbalancer.StickyStateKey] = pins
+			}
+		}
+
+		if pinned := p.sticky.Select(ctx.stickyPins(), route); pinned != nil {
+			route = pinned
+		} else {
+			route = p.sticky.Fallback(ctx.stateBag, route)
+		}
+	}
+
 	ctx.applyRoute(route, params, p.flags.PreserveHost())
 
 	processedFilters := p.applyFiltersToRequest(ctx.route.Filters, ctx)
@@ -870,6 +920,11 @@ func (p *Proxy) do(ctx *context) error {
 		ctx.setResponse(rsp, p.flags.PreserveOriginal())
 		p.metrics.MeasureBackend(ctx.route.Id, backendStart)
 		p.metrics.MeasureBackendHost(ctx.route.Host, backendStart)
+		p.metrics.MeasureBackendRequest(ctx.requestLabels(), backendStart)
+
+		if p.sticky != nil {
+			p.sticky.SetCookie(ctx.response.Header, ctx.stickyPins(), ctx.route)
+		}
 	}
 
 	addBranding(ctx.response.Header)
@@ -976,6 +1031,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := newContext(w, r, p.flags.PreserveOriginal(), p.metrics, p.routing.Get())
 	ctx.startServe = time.Now()
 	ctx.tracer = p.openTracer
+	defer ctx.setAccessLogState(logging.StateBagFromContext(r.Context()))
 
 	defer func() {
 		if ctx.response != nil && ctx.response.Body != nil {
@@ -1000,6 +1056,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ctx.response.StatusCode,
 		ctx.startServe,
 	)
+	p.metrics.MeasureRequest(ctx.requestLabels(), ctx.startServe)
 }
 
 // Close causes the proxy to stop closing idle
@@ -11,6 +11,7 @@ import (
 
 	ot "github.com/opentracing/opentracing-go"
 	log "github.com/opentracing/opentracing-go/log"
+	"github.com/opentracing/opentracing-go/mocktracer"
 )
 
 var recordedSpan *span
@@ -118,6 +119,58 @@ func TestTracingRoot(t *testing.T) {
 	}
 }
 
+func TestTracingFilterSpans(t *testing.T) {
+	s := startTestServer(nil, 0, func(*http.Request) {})
+	defer s.Close()
+
+	doc := fmt.Sprintf(`hello: Path("/hello")
+		-> setRequestHeader("X-Foo", "bar")
+		-> setResponseHeader("X-Bar", "baz")
+		-> "%s"`, s.URL)
+
+	mt := mocktracer.New()
+	tp, err := newTestProxyWithParams(doc, Params{OpenTracer: mt, Flags: FlagsNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tp.close()
+
+	r := httptest.NewRequest("GET", "https://www.example.org/hello", nil)
+	tp.proxy.ServeHTTP(httptest.NewRecorder(), r)
+
+	var ingress *mocktracer.MockSpan
+	filterSpans := make(map[string]*mocktracer.MockSpan)
+	for _, s := range mt.FinishedSpans() {
+		switch s.OperationName {
+		case "ingress":
+			ingress = s
+		case "filter_request", "filter_response":
+			filterSpans[s.OperationName+":"+s.Tag("skipper.filter").(string)] = s
+		}
+	}
+
+	if ingress == nil {
+		t.Fatal("ingress span not found")
+	}
+
+	for _, name := range []string{
+		"filter_request:setRequestHeader",
+		"filter_request:setResponseHeader",
+		"filter_response:setRequestHeader",
+		"filter_response:setResponseHeader",
+	} {
+		fs, ok := filterSpans[name]
+		if !ok {
+			t.Errorf("filter span not found: %s", name)
+			continue
+		}
+
+		if fs.ParentID != ingress.SpanContext.SpanID {
+			t.Errorf("filter span is not the child of the ingress span: %s", name)
+		}
+	}
+}
+
 type tracer struct {
 }
 
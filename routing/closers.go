@@ -0,0 +1,103 @@
+package routing
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const defaultFilterCloseDelay = time.Minute
+
+// returns the filter instances of the routes
+func routeFilters(routes []*Route) []filters.Filter {
+	var fs []filters.Filter
+	for _, r := range routes {
+		for _, f := range r.Filters {
+			fs = append(fs, f.Filter)
+		}
+	}
+
+	return fs
+}
+
+// returns the filter instances of the cached routes. The post-processors
+// may drop routes from the routing table, e.g. the unhealthy members of a
+// load balanced group, while they stay in the cache, and their filter
+// instances are reused when they come back, so these need to be kept
+// open, too.
+func cacheFilters(cache routeCache) []filters.Filter {
+	var fs []filters.Filter
+	for _, c := range cache {
+		for _, f := range c.route.Filters {
+			fs = append(fs, f.Filter)
+		}
+	}
+
+	return fs
+}
+
+// closes the filter instances created for a route that turned out to be
+// invalid, and therefore never got into a routing table
+func closeFilters(fs []*RouteFilter) {
+	for _, f := range fs {
+		if fc, ok := f.Filter.(filters.FilterCloser); ok {
+			fc.Close()
+		}
+	}
+}
+
+// collects the filter instances of the routing tables that implement
+// filters.FilterCloser. The instances that cannot be used as map keys
+// are ignored.
+func collectClosers(closers map[filters.FilterCloser]bool, tables ...*routeTable) {
+	for _, rt := range tables {
+		if rt == nil {
+			continue
+		}
+
+		for _, f := range rt.filters {
+			if fc, ok := f.(filters.FilterCloser); ok && reflect.TypeOf(fc).Comparable() {
+				closers[fc] = true
+			}
+		}
+	}
+}
+
+// closes the filter instances of the discarded routing tables, that are
+// not used anymore by the current, the pending, the rejected routing
+// table or by the ones in the history. Unchanged routes share their
+// filter instances between the routing tables. The last received table
+// also keeps open the filters of the routes cached for the next update,
+// see cacheFilters. The filters are closed after a delay, to let the
+// in-flight requests finish. The lock needs to be held by the caller.
+func (r *Routing) discard(tables ...*routeTable) {
+	dropped := make(map[filters.FilterCloser]bool)
+	collectClosers(dropped, tables...)
+	if len(dropped) == 0 {
+		return
+	}
+
+	live := make(map[filters.FilterCloser]bool)
+	collectClosers(live, r.history...)
+	collectClosers(live, r.routeTable.Load().(*routeTable), r.pending, r.rejected)
+
+	var closers []filters.FilterCloser
+	for fc := range dropped {
+		if !live[fc] {
+			closers = append(closers, fc)
+		}
+	}
+
+	if len(closers) == 0 {
+		return
+	}
+
+	time.AfterFunc(r.filterCloseDelay, func() {
+		for _, fc := range closers {
+			fc.Close()
+		}
+
+		r.log.Infof("filters closed: %d", len(closers))
+	})
+}
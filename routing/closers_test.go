@@ -0,0 +1,240 @@
+package routing_test
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+type closingSpec struct {
+	mu      sync.Mutex
+	created []string
+	closed  []string
+}
+
+type closingFilter struct {
+	spec *closingSpec
+	name string
+}
+
+func (s *closingSpec) Name() string { return "closing" }
+
+func (s *closingSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.created = append(s.created, args[0].(string))
+	return &closingFilter{spec: s, name: args[0].(string)}, nil
+}
+
+// returns the created filters that were not closed
+func (s *closingSpec) openFilters() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	closed := make(map[string]int)
+	for _, c := range s.closed {
+		closed[c]++
+	}
+
+	var open []string
+	for _, c := range s.created {
+		if closed[c] > 0 {
+			closed[c]--
+			continue
+		}
+
+		open = append(open, c)
+	}
+
+	sort.Strings(open)
+	return open
+}
+
+func (s *closingSpec) closedFilters() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	closed := append([]string(nil), s.closed...)
+	sort.Strings(closed)
+	return closed
+}
+
+func (f *closingFilter) Request(filters.FilterContext)  {}
+func (f *closingFilter) Response(filters.FilterContext) {}
+
+func (f *closingFilter) Close() {
+	f.spec.mu.Lock()
+	defer f.spec.mu.Unlock()
+	f.spec.closed = append(f.spec.closed, f.name)
+}
+
+func TestCloseFilters(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`
+		foo: Path("/foo") -> closing("foo1") -> <shunt>;
+		bar: Path("/bar") -> closing("bar") -> <shunt>;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &closingSpec{}
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := routing.New(routing.Options{
+		FilterRegistry:   filters.Registry{"closing": spec},
+		DataClients:      []routing.DataClient{dc},
+		PollTimeout:      pollTimeout,
+		Log:              l,
+		RouteHistory:     1,
+		FilterCloseDelay: time.Millisecond,
+	})
+	defer rt.Close()
+
+	waitFor := func(msg string) {
+		if err := l.WaitFor(msg, 12*pollTimeout); err != nil {
+			t.Fatal(err)
+		}
+
+		l.Reset()
+	}
+
+	waitFor("route settings applied")
+
+	// the unchanged route keeps its filter instance
+	if err := dc.UpdateDoc(`foo: Path("/foo") -> closing("foo2") -> <shunt>`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor("filters closed: 1")
+	if closed := spec.closedFilters(); !reflect.DeepEqual(closed, []string{"foo1"}) {
+		t.Fatal("invalid closed filters", closed)
+	}
+
+	if err := dc.UpdateDoc("", []string{"bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor("filters closed: 1")
+	if closed := spec.closedFilters(); !reflect.DeepEqual(closed, []string{"bar", "foo1"}) {
+		t.Error("invalid closed filters", closed)
+	}
+}
+
+func TestCloseFiltersOfInvalidRoutes(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`
+		valid: Path("/valid") -> closing("valid") -> <shunt>;
+		invalidPredicate: Path("/foo") && NoSuchPredicate() -> closing("invalidPredicate") -> <shunt>;
+		invalidFilter: Path("/bar") -> closing("invalidFilter") -> noSuchFilter() -> <shunt>;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &closingSpec{}
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := routing.New(routing.Options{
+		FilterRegistry: filters.Registry{"closing": spec},
+		DataClients:    []routing.DataClient{dc},
+		PollTimeout:    pollTimeout,
+		Log:            l,
+	})
+	defer rt.Close()
+
+	if err := l.WaitFor("route settings applied", 12*pollTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	if open := spec.openFilters(); !reflect.DeepEqual(open, []string{"valid"}) {
+		t.Error("invalid open filters", open)
+	}
+}
+
+type dropRoutes struct {
+	mu   sync.Mutex
+	drop string
+}
+
+func (d *dropRoutes) set(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drop = id
+}
+
+func (d *dropRoutes) Do(routes []*routing.Route) []*routing.Route {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var kept []*routing.Route
+	for _, r := range routes {
+		if r.Id != d.drop {
+			kept = append(kept, r)
+		}
+	}
+
+	return kept
+}
+
+func TestKeepCachedFiltersOpen(t *testing.T) {
+	dc, err := testdataclient.NewDoc(`
+		foo: Path("/foo") -> closing("foo1") -> <shunt>;
+		bar: Path("/bar") -> closing("bar") -> <shunt>;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &closingSpec{}
+	pp := &dropRoutes{}
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := routing.New(routing.Options{
+		FilterRegistry:   filters.Registry{"closing": spec},
+		DataClients:      []routing.DataClient{dc},
+		PollTimeout:      pollTimeout,
+		Log:              l,
+		RouteHistory:     1,
+		FilterCloseDelay: time.Millisecond,
+		PostProcessors:   []routing.PostProcessor{pp},
+	})
+	defer rt.Close()
+
+	waitFor := func(msg string) {
+		if err := l.WaitFor(msg, 12*pollTimeout); err != nil {
+			t.Fatal(err)
+		}
+
+		l.Reset()
+	}
+
+	waitFor("route settings applied")
+
+	// the dropped route stays in the cache, and its filter is reused
+	// when it comes back
+	pp.set("bar")
+	if err := dc.UpdateDoc(`foo: Path("/foo") -> closing("foo2") -> <shunt>`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor("filters closed: 1")
+	if closed := spec.closedFilters(); !reflect.DeepEqual(closed, []string{"foo1"}) {
+		t.Fatal("invalid closed filters", closed)
+	}
+
+	pp.set("")
+	if err := dc.UpdateDoc(`foo: Path("/foo") -> closing("foo3") -> <shunt>`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor("filters closed: 1")
+	if closed := spec.closedFilters(); !reflect.DeepEqual(closed, []string{"foo1", "foo2"}) {
+		t.Error("invalid closed filters", closed)
+	}
+}
@@ -0,0 +1,300 @@
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+const (
+	conflictShadowed  = "shadowed"
+	conflictAmbiguous = "ambiguous"
+)
+
+// the predicates that don't depend only on the request, and therefore a
+// route containing them doesn't shadow other routes
+var nonDeterministicPredicates = map[string]bool{"Traffic": true}
+
+// routeConflict describes a route that can never match, because another
+// route, evaluated before it, matches every request that it would match
+// (shadowed), or a pair of routes with the same conditions and priority,
+// where which one matches depends only on the order of the route
+// definitions (ambiguous). Routes with different conditions matching some
+// of the same requests with the same priority are not detected.
+type routeConflict struct {
+	Type  string `json:"type"`
+	Route string `json:"route"`
+	By    string `json:"by"`
+}
+
+func (c routeConflict) String() string {
+	if c.Type == conflictAmbiguous {
+		return fmt.Sprintf("routes %s and %s are ambiguous, they match the same requests with the same priority", c.By, c.Route)
+	}
+
+	return fmt.Sprintf("route %s is shadowed by %s", c.Route, c.By)
+}
+
+func isDeterministic(p *eskip.Predicate) bool {
+	if nonDeterministicPredicates[p.Name] {
+		return false
+	}
+
+	for _, a := range p.Args {
+		if np, ok := a.(*eskip.Predicate); ok && !isDeterministic(np) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Conditions returns the conditions of a route, apart from the path and
+// the Weight predicate, in a comparable form: two routes with the same
+// path and the same conditions match the same requests, regardless of
+// the order of their predicates. It returns false when the route has a
+// non-deterministic predicate, e.g. Traffic, which is not included in
+// the conditions. Such a route doesn't shadow other routes, and it is
+// not shadowed by them.
+func Conditions(r *eskip.Route) (map[string]bool, bool) {
+	c := make(map[string]bool)
+	if r.Method != "" {
+		c["Method "+r.Method] = true
+	}
+
+	for _, rx := range r.HostRegexps {
+		c["Host "+rx] = true
+	}
+
+	for _, rx := range r.PathRegexps {
+		c["PathRegexp "+rx] = true
+	}
+
+	for k, v := range r.Headers {
+		c["Header "+http.CanonicalHeaderKey(k)+" "+v] = true
+	}
+
+	for k, rxs := range r.HeaderRegexps {
+		for _, rx := range rxs {
+			c["HeaderRegexp "+http.CanonicalHeaderKey(k)+" "+rx] = true
+		}
+	}
+
+	deterministic := true
+	for _, p := range r.Predicates {
+		if isTreePredicate(p.Name) || p.Name == WeightName {
+			continue
+		}
+
+		if !isDeterministic(p) {
+			deterministic = false
+			continue
+		}
+
+		b, err := json.Marshal(p)
+		if err != nil {
+			deterministic = false
+			continue
+		}
+
+		c[string(b)] = true
+	}
+
+	return c, deterministic
+}
+
+// the conditions of a leaf, calculated on the first check for conflicts,
+// and reused on the next updates while the route doesn't change
+type leafConditionSet struct {
+	done          bool
+	conditions    map[string]bool
+	deterministic bool
+}
+
+func (l *leafMatcher) conditionSet() (map[string]bool, bool) {
+	if l.conditions == nil {
+		return Conditions(&l.route.Route)
+	}
+
+	if !l.conditions.done {
+		l.conditions.conditions, l.conditions.deterministic = Conditions(&l.route.Route)
+		l.conditions.done = true
+	}
+
+	return l.conditions.conditions, l.conditions.deterministic
+}
+
+func isSubset(a, b map[string]bool) bool {
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checks if a leaf matches all the paths that the other leaf matches,
+// when they are stored in the same path matcher. Path matchers of
+// subtrees can contain leaves with conflicting exact paths, too.
+func coversPath(l, other *leafMatcher) bool {
+	switch {
+	case l.route.pathSubtree != "":
+		return true
+	case other.route.pathSubtree != "":
+		return false
+	default:
+		return cleanPath(l.route.path, MatchingOptionsNone) == cleanPath(other.route.path, MatchingOptionsNone)
+	}
+}
+
+// returns the smallest condition key, or false if there are no conditions
+func firstKey(c map[string]bool) (string, bool) {
+	var (
+		first string
+		has   bool
+	)
+
+	for k := range c {
+		if !has || k < first {
+			first, has = k, true
+		}
+	}
+
+	return first, has
+}
+
+// finds the conflicts in a sorted list of leaves, that are evaluated in
+// their order. Every route is reported only once.
+func leafConflicts(leaves leafMatchers, reported map[*leafMatcher]bool) []routeConflict {
+	// a leaf can shadow another only if the other one has all of its
+	// conditions, so indexing them by a single condition is enough to
+	// find the candidates, without comparing all the pairs
+	var (
+		conditions    = make([]map[string]bool, len(leaves))
+		byKey         = make(map[string][]int)
+		unconditional []int
+	)
+
+	for i, l := range leaves {
+		c, deterministic := l.conditionSet()
+		conditions[i] = c
+		if !deterministic {
+			continue
+		}
+
+		if k, ok := firstKey(c); ok {
+			byKey[k] = append(byKey[k], i)
+		} else {
+			unconditional = append(unconditional, i)
+		}
+	}
+
+	var conflicts []routeConflict
+	for j, l := range leaves {
+		if reported[l] {
+			continue
+		}
+
+		// the candidates are in the order of evaluation, so it is
+		// enough to find the first one of each list
+		by := -1
+		check := func(candidates []int) {
+			for _, i := range candidates {
+				if i >= j || by >= 0 && i >= by {
+					return
+				}
+
+				if coversPath(leaves[i], l) && isSubset(conditions[i], conditions[j]) {
+					by = i
+					return
+				}
+			}
+		}
+
+		check(unconditional)
+		for k := range conditions[j] {
+			check(byKey[k])
+		}
+
+		if by < 0 {
+			continue
+		}
+
+		typ := conflictShadowed
+		if leafWeight(leaves[by]) == leafWeight(l) {
+			typ = conflictAmbiguous
+		}
+
+		conflicts = append(conflicts, routeConflict{Type: typ, Route: l.route.Id, By: leaves[by].route.Id})
+		reported[l] = true
+	}
+
+	return conflicts
+}
+
+// analyses the routing tree, and returns the routes that are shadowed by
+// other routes with the same path, or that are ambiguous
+func (m *matcher) conflicts() []routeConflict {
+	var pms []*pathMatcher
+	visited := make(map[*pathMatcher]bool)
+	m.paths.Walk(func(v interface{}) {
+		if pm, ok := v.(*pathMatcher); ok && !visited[pm] {
+			visited[pm] = true
+			pms = append(pms, pm)
+		}
+	})
+
+	var conflicts []routeConflict
+	reported := make(map[*leafMatcher]bool)
+	for _, pm := range pms {
+		conflicts = append(conflicts, leafConflicts(pm.leaves, reported)...)
+	}
+
+	conflicts = append(conflicts, leafConflicts(m.rootLeaves, reported)...)
+
+	// the order of the walk is not stable
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Route < conflicts[j].Route
+	})
+
+	return conflicts
+}
+
+// ServeConflicts renders the routes of the current routing table that
+// can never match because they are shadowed by other routes, and the
+// pairs of routes matching the same requests with the same priority.
+func (r *Routing) ServeConflicts(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	rt := r.routeTable.Load().(*routeTable)
+	if strings.Contains(req.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		conflicts := rt.conflicts
+		if conflicts == nil {
+			conflicts = []routeConflict{}
+		}
+
+		if err := json.NewEncoder(w).Encode(conflicts); err != nil {
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	for _, c := range rt.conflicts {
+		fmt.Fprintln(w, c)
+	}
+}
@@ -0,0 +1,129 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+// only the name matters in the analysis
+type trafficSpec struct{}
+
+func (trafficSpec) Name() string                                 { return "Traffic" }
+func (trafficSpec) Create(args []interface{}) (Predicate, error) { return &truePredicate{}, nil }
+
+func testMatcher(t *testing.T, doc string) *matcher {
+	defs, err := eskip.Parse(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var routes []*Route
+	for _, d := range defs {
+		r, err := processRouteDef(mapPredicates([]PredicateSpec{countrySpec{}, trafficSpec{}}), nil, MatchingOptionsNone, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		routes = append(routes, r)
+	}
+
+	m, errs := newMatcher(routes, MatchingOptionsNone)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	return m
+}
+
+func TestConflicts(t *testing.T) {
+	for _, test := range []struct {
+		title    string
+		routes   string
+		expected []routeConflict
+	}{{
+		title: "no conflicts",
+		routes: `
+			foo: Path("/foo") -> <shunt>;
+			fooGet: Path("/foo") && Method("GET") -> <shunt>;
+			bar: Path("/bar") -> <shunt>;
+			root: Host(/^www[.]/) -> <shunt>;
+		`,
+	}, {
+		title: "same conditions",
+		routes: `
+			foo1: Path("/foo") && Method("GET") -> <shunt>;
+			foo2: Path("/foo") && Method("GET") -> <shunt>;
+		`,
+		expected: []routeConflict{{Type: conflictAmbiguous, Route: "foo2", By: "foo1"}},
+	}, {
+		title: "shadowed by weight",
+		routes: `
+			header: Path("/foo") && Header("X-Foo", "bar") && Country("de") -> <shunt>;
+			weighted: Path("/foo") && Weight(3) -> <shunt>;
+		`,
+		expected: []routeConflict{{Type: conflictShadowed, Route: "header", By: "weighted"}},
+	}, {
+		title: "exact path shadowed by subtree",
+		routes: `
+			subtree: PathSubtree("/foo") && Weight(1) -> <shunt>;
+			exact: Path("/foo") -> <shunt>;
+			other: Path("/foo/") -> <shunt>;
+		`,
+		expected: []routeConflict{
+			{Type: conflictShadowed, Route: "exact", By: "subtree"},
+			{Type: conflictShadowed, Route: "other", By: "subtree"},
+		},
+	}, {
+		title: "subtree not shadowed by exact path",
+		routes: `
+			subtree: PathSubtree("/foo") -> <shunt>;
+			exact: Path("/foo") && Weight(1) -> <shunt>;
+		`,
+	}, {
+		title: "non-deterministic predicate",
+		routes: `
+			traffic: Host(/^www[.]/) && Traffic(0.5) && Weight(1) -> <shunt>;
+			host: Host(/^www[.]/) && Weight(2) -> <shunt>;
+			trafficShadowed: Host(/^www[.]/) && Traffic(0.3) -> <shunt>;
+		`,
+		expected: []routeConflict{{Type: conflictShadowed, Route: "trafficShadowed", By: "host"}},
+	}, {
+		title: "nested predicates",
+		routes: `
+			or1: Or(Method("GET"), Method("HEAD")) -> <shunt>;
+			or2: Or(Method("GET"), Method("HEAD")) -> <shunt>;
+			or3: Or(Method("GET"), Method("POST")) -> <shunt>;
+		`,
+		expected: []routeConflict{{Type: conflictAmbiguous, Route: "or2", By: "or1"}},
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			c := testMatcher(t, test.routes).conflicts()
+			if len(c) != 0 || len(test.expected) != 0 {
+				if !reflect.DeepEqual(c, test.expected) {
+					t.Errorf("invalid conflicts, expected: %v, got: %v", test.expected, c)
+				}
+			}
+		})
+	}
+}
+
+func TestServeConflicts(t *testing.T) {
+	m := testMatcher(t, `
+		foo1: Path("/foo") -> <shunt>;
+		foo2: Path("/foo") -> <shunt>;
+	`)
+
+	r := &Routing{}
+	r.routeTable.Store(&routeTable{m: m, conflicts: m.conflicts()})
+
+	w := httptest.NewRecorder()
+	r.ServeConflicts(w, httptest.NewRequest("GET", "/routes/conflicts", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "routes foo1 and foo2 are ambiguous") {
+		t.Errorf("invalid response: %d %s", w.Code, w.Body.String())
+	}
+}
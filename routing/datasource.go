@@ -2,7 +2,9 @@ package routing
 
 import (
 	"fmt"
+	"math"
 	"net/url"
+	"regexp"
 	"time"
 
 	"github.com/zalando/skipper/eskip"
@@ -228,6 +230,7 @@ func createFilters(fr filters.Registry, defs []*eskip.Filter) ([]*RouteFilter, e
 	for i, def := range defs {
 		f, err := createFilter(fr, def)
 		if err != nil {
+			closeFilters(fs)
 			return nil, err
 		}
 
@@ -333,20 +336,16 @@ func mergeLegacyNonTreePredicates(r *eskip.Route) error {
 	return nil
 }
 
-// initialize predicate instances from their spec with the concrete arguments
-func processPredicates(cpm map[string]PredicateSpec, defs []*eskip.Predicate) ([]Predicate, error) {
+// initialize predicate instances from their spec with the concrete arguments,
+// and the logical predicates from their nested predicates
+func processPredicates(cpm map[string]PredicateSpec, defs []*eskip.Predicate, mo MatchingOptions) ([]Predicate, error) {
 	cps := make([]Predicate, 0, len(defs))
 	for _, def := range defs {
-		if isTreePredicate(def.Name) {
+		if isTreePredicate(def.Name) || def.Name == WeightName {
 			continue
 		}
 
-		spec, ok := cpm[def.Name]
-		if !ok {
-			return nil, fmt.Errorf("predicate not found: '%s'", def.Name)
-		}
-
-		cp, err := spec.Create(def.Args)
+		cp, err := createPredicate(cpm, def, false, mo)
 		if err != nil {
 			return nil, err
 		}
@@ -415,29 +414,70 @@ func processTreePredicates(r *Route, predicates []*eskip.Predicate) error {
 	return nil
 }
 
+// processes the Weight predicate, if any, that needs to have a single
+// integer argument
+func processWeightPredicate(r *Route, defs []*eskip.Predicate) error {
+	var has bool
+	for _, p := range defs {
+		if p.Name != WeightName {
+			continue
+		}
+
+		if has {
+			return fmt.Errorf("multiple Weight predicates in the route: %s", r.Id)
+		}
+
+		has = true
+		if len(p.Args) != 1 {
+			return predicates.ErrInvalidPredicateParameters
+		}
+
+		switch w := p.Args[0].(type) {
+		case int:
+			r.weight = w
+		case float64:
+			if w != math.Trunc(w) {
+				return predicates.ErrInvalidPredicateParameters
+			}
+
+			r.weight = int(w)
+		default:
+			return predicates.ErrInvalidPredicateParameters
+		}
+	}
+
+	return nil
+}
+
 // processes a route definition for the routing table
-func processRouteDef(cpm map[string]PredicateSpec, fr filters.Registry, def *eskip.Route) (*Route, error) {
+func processRouteDef(cpm map[string]PredicateSpec, fr filters.Registry, mo MatchingOptions, def *eskip.Route) (*Route, error) {
 	scheme, host, err := splitBackend(def)
 	if err != nil {
 		return nil, err
 	}
 
-	fs, err := createFilters(fr, def.Filters)
+	if err := mergeLegacyNonTreePredicates(def); err != nil {
+		return nil, err
+	}
+
+	cps, err := processPredicates(cpm, def.Predicates, mo)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := mergeLegacyNonTreePredicates(def); err != nil {
+	r := &Route{Route: *def, Scheme: scheme, Host: host, Predicates: cps}
+	if err := processTreePredicates(r, def.Predicates); err != nil {
 		return nil, err
 	}
 
-	cps, err := processPredicates(cpm, def.Predicates)
-	if err != nil {
+	if err := processWeightPredicate(r, def.Predicates); err != nil {
 		return nil, err
 	}
 
-	r := &Route{Route: *def, Scheme: scheme, Host: host, Predicates: cps, Filters: fs}
-	if err := processTreePredicates(r, def.Predicates); err != nil {
+	// the filters are created last, so that they don't need to be
+	// closed when the rest of the route is invalid
+	r.Filters, err = createFilters(fr, def.Filters)
+	if err != nil {
 		return nil, err
 	}
 
@@ -454,18 +494,84 @@ func mapPredicates(cps []PredicateSpec) map[string]PredicateSpec {
 	return cpm
 }
 
+// a processed route, with a copy of the definition that it was created
+// from
+type cachedRoute struct {
+	def   *eskip.Route
+	route *Route
+}
+
+// the processed routes of the previous update, by route id
+type routeCache map[string]cachedRoute
+
+// returns a copy of a processed route, sharing the filter and predicate
+// instances and the prepared leaf matcher. The fields of the load
+// balanced groups are not copied, because they are set on every update.
+func (r *Route) copyProcessed() *Route {
+	return &Route{
+		Route:       r.Route,
+		path:        r.path,
+		pathSubtree: r.pathSubtree,
+		weight:      r.weight,
+		leaf:        r.leaf,
+		def:         r.def,
+		Scheme:      r.Scheme,
+		Host:        r.Host,
+		Predicates:  r.Predicates,
+		Filters:     r.Filters,
+	}
+}
+
+// returns the printed definition of a route, used for the hash of the
+// routing table
+func (r *Route) definition() string {
+	if r.def != "" {
+		return r.def
+	}
+
+	return r.Id + ": " + r.Route.String()
+}
+
 // processes a set of route definitions for the routing table
 func processRouteDefs(o Options, fr filters.Registry, defs []*eskip.Route) (routes []*Route, invalidDefs []*eskip.Route) {
+	routes, invalidDefs, _ = processRouteDefsCached(o, fr, defs, nil)
+	return
+}
+
+// processes a set of route definitions for the routing table, reusing
+// the processed routes, together with their filter instances and leaf
+// matchers, from the previous update when their definition didn't
+// change. Only the copies of the cached routes are returned, because the
+// fallback groups and the post-processors may modify them. It returns
+// the cache for the next update, containing only the current valid
+// routes.
+func processRouteDefsCached(o Options, fr filters.Registry, defs []*eskip.Route, cache routeCache) (routes []*Route, invalidDefs []*eskip.Route, next routeCache) {
 	cpm := mapPredicates(o.Predicates)
+	next = make(routeCache)
 	for _, def := range defs {
-		route, err := processRouteDef(cpm, fr, def)
-		if err == nil {
-			routes = append(routes, route)
-		} else {
+		if c, ok := cache[def.Id]; ok && eskip.Eq(c.def, def) {
+			next[def.Id] = c
+			routes = append(routes, c.route.copyProcessed())
+			continue
+		}
+
+		// the definition needs to be copied before processing,
+		// because the processing modifies it
+		original := eskip.Copy(def)
+		route, err := processRouteDef(cpm, fr, o.MatchingOptions, def)
+		if err != nil {
 			invalidDefs = append(invalidDefs, def)
 			o.Log.Errorf("failed to process route (%v): %v", def.Id, err)
+			continue
 		}
+
+		// invalid conditions are reported by the matcher
+		route.leaf, _ = newLeaf(route, make(map[string]*regexp.Regexp))
+		route.def = route.Id + ": " + route.Route.String()
+		next[def.Id] = cachedRoute{def: original, route: route}
+		routes = append(routes, route.copyProcessed())
 	}
+
 	return
 }
 
@@ -473,7 +579,14 @@ type routeTable struct {
 	m             *matcher
 	validRoutes   []*eskip.Route
 	invalidRoutes []*eskip.Route
+	conflicts     []routeConflict
 	created       time.Time
+	version       uint64
+	hash          string
+
+	// the filter instances of the routes, and of the routes cached for
+	// the next update, that the table keeps open
+	filters []filters.Filter
 }
 
 // receives the next version of the routing table on the output channel,
@@ -484,13 +597,21 @@ func receiveRouteMatcher(o Options, out chan<- *routeTable, quit <-chan struct{}
 		rt           *routeTable
 		outRelay     chan<- *routeTable
 		updatesRelay <-chan []*eskip.Route
+		cache        routeCache
 	)
 	updatesRelay = updates
 	for {
 		select {
 		case defs := <-updatesRelay:
 			o.Log.Info("route settings received")
-			routes, invalidRoutes := processRouteDefs(o, o.FilterRegistry, defs)
+			start := time.Now()
+
+			var (
+				routes        []*Route
+				invalidRoutes []*eskip.Route
+			)
+
+			routes, invalidRoutes, cache = processRouteDefsCached(o, o.FilterRegistry, defs, cache)
 
 			// TODO: consider if the fallbacks logic should be a post processor
 			routes = applyFallbackGroups(routes)
@@ -498,10 +619,17 @@ func receiveRouteMatcher(o Options, out chan<- *routeTable, quit <-chan struct{}
 				routes = o.PostProcessors[i].Do(routes)
 			}
 
+			// the post processors may drop members of the groups, e.g.
+			// the unhealthy ones, so the groups are linked again
+			if len(o.PostProcessors) > 0 {
+				routes = applyFallbackGroups(routes)
+			}
+
 			m, errs := newMatcher(routes, o.MatchingOptions)
 
 			invalidRouteIds := make(map[string]struct{})
 			validRoutes := []*eskip.Route{}
+			var validDefs []string
 
 			for _, err := range errs {
 				o.Log.Error(err)
@@ -513,6 +641,17 @@ func receiveRouteMatcher(o Options, out chan<- *routeTable, quit <-chan struct{}
 					invalidRoutes = append(invalidRoutes, &r.Route)
 				} else {
 					validRoutes = append(validRoutes, &r.Route)
+					validDefs = append(validDefs, r.definition())
+				}
+			}
+
+			// the details can be long with many routes, they are
+			// logged only on debug level
+			conflicts := m.conflicts()
+			if len(conflicts) > 0 {
+				o.Log.Warnf("route conflicts found: %d, listed by the /routes/conflicts endpoint", len(conflicts))
+				for _, c := range conflicts {
+					o.Log.Debug(c)
 				}
 			}
 
@@ -520,8 +659,13 @@ func receiveRouteMatcher(o Options, out chan<- *routeTable, quit <-chan struct{}
 				m:             m,
 				validRoutes:   validRoutes,
 				invalidRoutes: invalidRoutes,
+				conflicts:     conflicts,
+				filters:       append(routeFilters(routes), cacheFilters(cache)...),
 				created:       time.Now().UTC(),
+				hash:          tableHash(validDefs),
 			}
+
+			o.metrics().MeasureSince(buildTimeKey, start)
 			updatesRelay = nil
 			outRelay = out
 		case outRelay <- rt:
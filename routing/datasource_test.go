@@ -1,9 +1,12 @@
 package routing
 
 import (
+	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/logging"
 	"github.com/zalando/skipper/logging/loggingtest"
@@ -53,7 +56,7 @@ func TestNoMultipleTreePredicates(t *testing.T) {
 			pr := make(map[string]PredicateSpec)
 			fr := make(filters.Registry)
 			for _, d := range defs {
-				if _, err := processRouteDef(pr, fr, d); err != nil {
+				if _, err := processRouteDef(pr, fr, MatchingOptionsNone, d); err != nil {
 					erred = true
 					break
 				}
@@ -162,3 +165,140 @@ func TestLogging(t *testing.T) {
 		)
 	})
 }
+
+func TestWeightPredicate(t *testing.T) {
+	for _, ti := range []struct {
+		route  string
+		weight int
+		err    bool
+	}{{
+		`Path("/foo") -> <shunt>`,
+		0,
+		false,
+	}, {
+		`Path("/foo") && Weight(3) -> <shunt>`,
+		3,
+		false,
+	}, {
+		`Weight(0) -> <shunt>`,
+		0,
+		false,
+	}, {
+		`Weight(1.5) -> <shunt>`,
+		0,
+		true,
+	}, {
+		`Weight("1") -> <shunt>`,
+		0,
+		true,
+	}, {
+		`Weight(1) && Weight(2) -> <shunt>`,
+		0,
+		true,
+	}, {
+		`Not(Weight(1)) -> <shunt>`,
+		0,
+		true,
+	}} {
+		defs, err := eskip.Parse(ti.route)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := processRouteDef(make(map[string]PredicateSpec), make(filters.Registry), MatchingOptionsNone, defs[0])
+		if ti.err {
+			if err == nil {
+				t.Error(ti.route, "failed to fail")
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Error(ti.route, err)
+			continue
+		}
+
+		if len(r.Predicates) != 0 || r.weight != ti.weight {
+			t.Error(ti.route, "invalid weight", r.weight, len(r.Predicates))
+		}
+	}
+}
+
+type countingSpec struct{ created int }
+
+type countingFilter struct{}
+
+func (s *countingSpec) Name() string { return "counting" }
+
+func (s *countingSpec) CreateFilter([]interface{}) (filters.Filter, error) {
+	s.created++
+	return &countingFilter{}, nil
+}
+
+func (f *countingFilter) Request(filters.FilterContext)  {}
+func (f *countingFilter) Response(filters.FilterContext) {}
+
+func TestProcessRouteDefsCached(t *testing.T) {
+	spec := &countingSpec{}
+	fr := make(filters.Registry)
+	fr.Register(spec)
+	o := Options{Log: loggingtest.New()}
+	defer o.Log.(*loggingtest.Logger).Close()
+
+	defs, err := eskip.Parse(`
+		foo: Path("/foo") -> counting() -> <shunt>;
+		bar: Path("/bar") -> counting() -> <shunt>;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes, _, cache := processRouteDefsCached(o, fr, defs, nil)
+	if len(routes) != 2 || spec.created != 2 {
+		t.Fatal("failed to process the routes")
+	}
+
+	previousFoo := routes[0]
+	previousFoo.Next = previousFoo
+
+	defs, err = eskip.Parse(`
+		foo: Path("/foo") -> counting() -> <shunt>;
+		bar: Path("/bar") -> counting(42) -> <shunt>;
+		baz: Path("/baz") -> unknown() -> <shunt>;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes, invalid, cache := processRouteDefsCached(o, fr, defs, cache)
+	if len(routes) != 2 || len(invalid) != 1 || len(cache) != 2 {
+		t.Fatal("failed to process the update")
+	}
+
+	if spec.created != 3 {
+		t.Error("failed to reuse the unchanged route, filters created:", spec.created)
+	}
+
+	if routes[0] == previousFoo || routes[0].Filters[0] != previousFoo.Filters[0] || routes[0].Next != nil {
+		t.Error("invalid copy of the reused route")
+	}
+
+	if routes[0].leaf == nil || routes[0].leaf != previousFoo.leaf || routes[0].def != previousFoo.def {
+		t.Error("failed to reuse the leaf matcher and the definition of the unchanged route")
+	}
+
+	if routes[1].def != `bar: Path("/bar") -> counting(42) -> <shunt>` {
+		t.Error("invalid definition of the changed route:", routes[1].def)
+	}
+
+	m, errs := newMatcher(routes, MatchingOptionsNone)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	r, _ := m.match(&http.Request{Method: "GET", URL: &url.URL{Path: "/foo"}})
+	if r != routes[0] {
+		t.Error("failed to match the reused route")
+	}
+}
@@ -11,7 +11,17 @@ route definitions in a lookup tree.
 2. The rest of the request attributes is matched against the non-path
 conditions of the routes found in the lookup tree, from the most to the
 least strict one. The result is the first route where every condition is
-met.
+met. The strictness of a route is the number of its non-path conditions,
+which can be adjusted with the Weight predicate, e.g. Weight(2) adds 2
+to it. The routes evaluated for a path and their effective weight can be
+listed with the path query parameter of the route listing endpoint,
+e.g. /routes?path=/foo.
+
+After every update, the routes that can never match, because another
+route with the same path and a subset of their conditions is evaluated
+before them, are detected, together with the ambiguous routes that have
+the same conditions and the same priority. Their number is logged as a
+warning, and the details on debug level. (See Routing.ServeConflicts.)
 
 (The regular expression conditions for the path, 'PathRegexp', are
 applied only in step 2.)
@@ -47,6 +57,14 @@ HeaderRegexp("Key", "^Value$").
 must be present in the request and one of the associated values must
 match the expression.
 
+- Not, Or, And: logical composition of nested predicates. Not negates a
+single nested predicate, Or matches when any, And when all of the nested
+predicates match. The nested predicates can be any of the above, except
+Path and PathSubtree, or custom predicates, e.g.
+Or(Not(Host(/^www[.]/)), Header("X-Beta", "true")). The nested
+predicates are not used for the lookup tree, they are evaluated after
+the path was matched.
+
 
 Wildcards
 
@@ -65,6 +83,11 @@ predicate, the name of this parameter will be "*". This makes the
 PathSubtree("/foo") predicate equivalent to having routes with
 Path("/foo"), Path("/foo/") and Path("/foo/**") predicates.
 
+The named capture groups of the Host and PathRegexp predicates are
+available as path parameters, too, e.g. the value of the user parameter
+with PathRegexp(/^\/users\/(?P<user>[^\/]+)/). When the same name is
+used by a path wildcard, the value of the wildcard is used.
+
 
 Custom Predicates
 
@@ -91,6 +114,19 @@ updates, and, if an update is received, generates a new lookup tree. In
 case of communication failure during polling, it reloads the whole set
 of routes from the failing client.
 
+When generating the new lookup tree, the routes whose definition didn't
+change since the previous update are not processed again, and they keep
+their filter instances, preserving the state of the filters, e.g. the
+windows of the rate limits. They also keep their prepared matching
+conditions. Only the new and the changed routes get new filter
+instances. The time of building the routing table, including the check
+for conflicts, is measured in the routing.build metric.
+
+The filter instances implementing filters.FilterCloser are closed when
+no routing table uses them anymore, including the ones kept in the
+history. The closing is delayed by FilterCloseDelay, to let the
+in-flight requests finish.
+
 The active set of routes from the last successful update are used until
 the next successful update happens.
 
@@ -51,6 +51,10 @@ func applyFallbackGroups(r []*Route) []*Route {
 			current.Group = name
 			current.IsLoadBalanced = true
 		}
+
+		// the groups are linked again after the post processors,
+		// when the last member may have been dropped
+		current.Next = nil
 	}
 
 	return r
@@ -0,0 +1,320 @@
+package routing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+const (
+	defaultRouteHistory = 10
+	routesVersionName   = "X-Version"
+)
+
+// historyEntry describes an applied routing table in the history.
+type historyEntry struct {
+	Version uint64    `json:"version"`
+	Hash    string    `json:"hash"`
+	Created time.Time `json:"created"`
+	Count   int       `json:"count"`
+	Current bool      `json:"current"`
+}
+
+type routeChange struct {
+	ID    string `json:"id"`
+	Route string `json:"route,omitempty"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// routesDiff contains the changes between two versions of the routing
+// table, in the order of the route ids.
+type routesDiff struct {
+	From    uint64        `json:"from"`
+	To      uint64        `json:"to"`
+	Added   []routeChange `json:"added"`
+	Removed []routeChange `json:"removed"`
+	Changed []routeChange `json:"changed"`
+}
+
+// returns the hash of the printed route definitions, independent of
+// their order
+func tableHash(defs []string) string {
+	sort.Strings(defs)
+	h := sha256.New()
+	for _, d := range defs {
+		h.Write([]byte(d))
+		h.Write([]byte{'\n'})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// applies a new routing table with the next version, and stores it in
+// the history. The filters of the tables dropped from the history are
+// closed. The lock needs to be held by the caller.
+func (r *Routing) apply(rt *routeTable) {
+	r.version++
+	rt.version = r.version
+
+	var dropped []*routeTable
+	r.history = append(r.history, rt)
+	if len(r.history) > r.historySize {
+		n := len(r.history) - r.historySize
+		dropped = append(dropped, r.history[:n]...)
+		r.history = r.history[n:]
+	}
+
+	if r.rejected != rt {
+		dropped = append(dropped, r.rejected)
+	}
+
+	r.rejected = nil
+	r.routeTable.Store(rt)
+	r.discard(dropped...)
+}
+
+// returns a routing table from the history. The lock needs to be held by
+// the caller.
+func (r *Routing) historyVersion(v uint64) *routeTable {
+	for _, rt := range r.history {
+		if rt.version == v {
+			return rt
+		}
+	}
+
+	return nil
+}
+
+func mapRouteStrings(routes []*eskip.Route) map[string]string {
+	m := make(map[string]string)
+	for _, r := range routes {
+		m[r.Id] = r.String()
+	}
+
+	return m
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func diffTables(from, to *routeTable) routesDiff {
+	d := routesDiff{
+		From:    from.version,
+		To:      to.version,
+		Added:   []routeChange{},
+		Removed: []routeChange{},
+		Changed: []routeChange{},
+	}
+
+	mfrom, mto := mapRouteStrings(from.validRoutes), mapRouteStrings(to.validRoutes)
+	for _, id := range sortedKeys(mfrom) {
+		if r, ok := mto[id]; !ok {
+			d.Removed = append(d.Removed, routeChange{ID: id, Route: mfrom[id]})
+		} else if r != mfrom[id] {
+			d.Changed = append(d.Changed, routeChange{ID: id, From: mfrom[id], To: r})
+		}
+	}
+
+	for _, id := range sortedKeys(mto) {
+		if _, ok := mfrom[id]; !ok {
+			d.Added = append(d.Added, routeChange{ID: id, Route: mto[id]})
+		}
+	}
+
+	return d
+}
+
+func acceptsJSON(req *http.Request) bool {
+	return strings.Contains(req.Header.Get("Accept"), "application/json")
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(
+			w,
+			http.StatusText(http.StatusInternalServerError),
+			http.StatusInternalServerError,
+		)
+	}
+}
+
+func extractVersion(req *http.Request, key string) (uint64, error) {
+	return strconv.ParseUint(req.Form.Get(key), 10, 64)
+}
+
+// ServeHistory renders the list of the last applied routing tables, with
+// their version, content hash, creation time and number of routes.
+func (r *Routing) ServeHistory(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	current := r.routeTable.Load().(*routeTable)
+	r.mu.Lock()
+	entries := make([]historyEntry, 0, len(r.history))
+	for i := len(r.history) - 1; i >= 0; i-- {
+		rt := r.history[i]
+		entries = append(entries, historyEntry{
+			Version: rt.version,
+			Hash:    rt.hash,
+			Created: rt.created,
+			Count:   len(rt.validRoutes),
+			Current: rt == current,
+		})
+	}
+
+	pinned := r.pinned
+	r.mu.Unlock()
+
+	if acceptsJSON(req) {
+		writeJSON(w, entries)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	for _, e := range entries {
+		var flags string
+		if e.Current {
+			flags = " current"
+			if pinned {
+				flags += " pinned"
+			}
+		}
+
+		fmt.Fprintf(w, "%d %s %s %d%s\n", e.Version, e.Hash, e.Created.Format(time.RFC3339), e.Count, flags)
+	}
+}
+
+// ServeDiff renders the changes between two versions of the routing table
+// in the history, set by the from and to query parameters. When to is not
+// set, the current routing table is used.
+func (r *Routing) ServeDiff(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req.ParseForm()
+	fromVersion, err := extractVersion(req, "from")
+	if err != nil {
+		http.Error(w, "invalid from version", http.StatusBadRequest)
+		return
+	}
+
+	current := r.routeTable.Load().(*routeTable)
+	toVersion := current.version
+	if req.Form.Get("to") != "" {
+		if toVersion, err = extractVersion(req, "to"); err != nil {
+			http.Error(w, "invalid to version", http.StatusBadRequest)
+			return
+		}
+	}
+
+	r.mu.Lock()
+	from, to := r.historyVersion(fromVersion), r.historyVersion(toVersion)
+	r.mu.Unlock()
+
+	if from == nil || to == nil {
+		http.Error(w, "version not found", http.StatusNotFound)
+		return
+	}
+
+	d := diffTables(from, to)
+	if acceptsJSON(req) {
+		writeJSON(w, d)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "--- version %d\n+++ version %d\n", d.From, d.To)
+	for _, c := range d.Removed {
+		fmt.Fprintf(w, "@@ %s removed @@\n-%s: %s\n", c.ID, c.ID, c.Route)
+	}
+
+	for _, c := range d.Added {
+		fmt.Fprintf(w, "@@ %s added @@\n+%s: %s\n", c.ID, c.ID, c.Route)
+	}
+
+	for _, c := range d.Changed {
+		fmt.Fprintf(w, "@@ %s changed @@\n-%s: %s\n+%s: %s\n", c.ID, c.ID, c.From, c.ID, c.To)
+	}
+}
+
+// ServeRollback, on POST requests, applies the routing table version set
+// by the version query parameter from the history. It stays active until
+// the data clients deliver the next update. When the pin query parameter
+// is true, the updates are not applied until the routing table is
+// unpinned with a DELETE request, which applies the last update received
+// in the meantime, if any. The update is checked against the route drop
+// and invalid route limits, and when it exceeds them, it is rejected,
+// and can be applied with ServeForceApply.
+func (r *Routing) ServeRollback(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "POST":
+		req.ParseForm()
+		v, err := extractVersion(req, "version")
+		if err != nil {
+			http.Error(w, "invalid version", http.StatusBadRequest)
+			return
+		}
+
+		pin := req.Form.Get("pin") == "true"
+
+		r.mu.Lock()
+		rt := r.historyVersion(v)
+		if rt != nil {
+			r.routeTable.Store(rt)
+			r.pinned = pin
+		}
+
+		r.mu.Unlock()
+
+		if rt == nil {
+			http.Error(w, "version not found", http.StatusNotFound)
+			return
+		}
+
+		r.log.Infof("route settings rolled back to version %d, pinned: %t", v, pin)
+		w.Header().Set(routesVersionName, strconv.FormatUint(v, 10))
+	case "DELETE":
+		r.mu.Lock()
+		pending := r.pending
+		r.pinned = false
+		r.pending = nil
+		if pending != nil {
+			// the pending update is checked against the pinned
+			// table, the same way as the received updates
+			if reason := checkUpdate(r.options, r.routeTable.Load().(*routeTable), pending); reason != "" {
+				r.reject(r.options, pending, reason)
+			} else {
+				r.apply(pending)
+			}
+		}
+
+		rt := r.routeTable.Load().(*routeTable)
+		r.mu.Unlock()
+
+		r.log.Infof("route settings unpinned, version %d active", rt.version)
+		w.Header().Set(routesVersionName, strconv.FormatUint(rt.version, 10))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,197 @@
+package routing_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/routing/testdataclient"
+)
+
+type historyTest struct {
+	t       *testing.T
+	dc      *testdataclient.Client
+	log     *loggingtest.Logger
+	routing *routing.Routing
+}
+
+func newHistoryTest(t *testing.T, o routing.Options) *historyTest {
+	dc, err := testdataclient.NewDoc(`foo: Path("/foo") -> "https://foo.example.org"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := loggingtest.New()
+	o.FilterRegistry = builtin.MakeRegistry()
+	o.DataClients = []routing.DataClient{dc}
+	o.PollTimeout = pollTimeout
+	o.Log = l
+
+	ht := &historyTest{t: t, dc: dc, log: l, routing: routing.New(o)}
+	ht.waitFor("route settings applied")
+	return ht
+}
+
+func (ht *historyTest) close() {
+	ht.routing.Close()
+	ht.log.Close()
+}
+
+func (ht *historyTest) waitFor(msg string) {
+	if err := ht.log.WaitFor(msg, 12*pollTimeout); err != nil {
+		ht.t.Fatal(err)
+	}
+
+	ht.log.Reset()
+}
+
+func (ht *historyTest) update(doc string, deleted ...string) {
+	if err := ht.dc.UpdateDoc(doc, deleted); err != nil {
+		ht.t.Fatal(err)
+	}
+}
+
+func (ht *historyTest) backend(path string) string {
+	r, _ := ht.routing.Route(&http.Request{URL: &url.URL{Path: path}})
+	if r == nil {
+		return ""
+	}
+
+	return r.Backend
+}
+
+func (ht *historyTest) serve(h http.HandlerFunc, method, u string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, u, nil)
+	req.Header.Set("Accept", "application/json")
+	h(w, req)
+	return w
+}
+
+func TestHistoryAndDiff(t *testing.T) {
+	ht := newHistoryTest(t, routing.Options{RouteHistory: 2})
+	defer ht.close()
+
+	ht.update(`foo: Path("/foo") -> "https://foo2.example.org"; bar: Path("/bar") -> <shunt>`)
+	ht.waitFor("route settings applied")
+	ht.update(``, "bar")
+	ht.waitFor("route settings applied")
+
+	w := ht.serve(ht.routing.ServeHistory, "GET", "/routes/history")
+	var history []struct {
+		Version uint64 `json:"version"`
+		Hash    string `json:"hash"`
+		Count   int    `json:"count"`
+		Current bool   `json:"current"`
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 2 ||
+		history[0].Version != 3 || !history[0].Current || history[0].Count != 1 ||
+		history[1].Version != 2 || history[1].Current || history[1].Count != 2 ||
+		history[0].Hash == "" || history[0].Hash == history[1].Hash {
+		t.Fatalf("invalid history: %+v", history)
+	}
+
+	w = ht.serve(ht.routing.ServeDiff, "GET", "/routes/diff?from=1")
+	if w.Code != http.StatusNotFound {
+		t.Error("failed to fail for a version not in the history", w.Code)
+	}
+
+	w = ht.serve(ht.routing.ServeDiff, "GET", "/routes/diff?from=2")
+	var d struct {
+		Removed []struct {
+			ID string `json:"id"`
+		} `json:"removed"`
+		Changed []interface{} `json:"changed"`
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Removed) != 1 || d.Removed[0].ID != "bar" || len(d.Changed) != 0 {
+		t.Errorf("invalid diff: %+v", d)
+	}
+
+	w = httptest.NewRecorder()
+	ht.routing.ServeDiff(w, httptest.NewRequest("GET", "/routes/diff?from=2&to=3", nil))
+	if !strings.Contains(w.Body.String(), "@@ bar removed @@\n-bar: Path(\"/bar\") -> <shunt>") {
+		t.Errorf("invalid text diff: %s", w.Body.String())
+	}
+}
+
+func TestRollback(t *testing.T) {
+	ht := newHistoryTest(t, routing.Options{})
+	defer ht.close()
+
+	ht.update(`foo: Path("/foo") -> "https://foo2.example.org"`)
+	ht.waitFor("route settings applied")
+
+	w := ht.serve(ht.routing.ServeRollback, "POST", "/routes/rollback?version=1")
+	if w.Code != http.StatusOK || ht.backend("/foo") != "https://foo.example.org" {
+		t.Fatal("failed to roll back", w.Code, ht.backend("/foo"))
+	}
+
+	ht.update(`foo: Path("/foo") -> "https://foo3.example.org"`)
+	ht.waitFor("route settings applied")
+	if ht.backend("/foo") != "https://foo3.example.org" {
+		t.Fatal("failed to apply the next update after the rollback")
+	}
+
+	w = ht.serve(ht.routing.ServeRollback, "POST", "/routes/rollback?version=2&pin=true")
+	if w.Code != http.StatusOK || ht.backend("/foo") != "https://foo2.example.org" {
+		t.Fatal("failed to roll back", w.Code)
+	}
+
+	ht.update(`foo: Path("/foo") -> "https://foo4.example.org"`)
+	ht.waitFor("not applied while the routing table is pinned")
+	if ht.backend("/foo") != "https://foo2.example.org" {
+		t.Fatal("unexpected update while pinned")
+	}
+
+	w = ht.serve(ht.routing.ServeRollback, "DELETE", "/routes/rollback")
+	if w.Code != http.StatusOK || ht.backend("/foo") != "https://foo4.example.org" || w.Header().Get("X-Version") != "4" {
+		t.Error("failed to apply the pending update", w.Code, w.Header().Get("X-Version"))
+	}
+
+	w = ht.serve(ht.routing.ServeRollback, "POST", "/routes/rollback?version=42")
+	if w.Code != http.StatusNotFound {
+		t.Error("failed to fail", w.Code)
+	}
+}
+
+func TestUnpinRejected(t *testing.T) {
+	ht := newHistoryTest(t, routing.Options{MaxRouteDrop: 1})
+	defer ht.close()
+
+	ht.update(`bar: Path("/bar") -> "https://bar.example.org"; baz: Path("/baz") -> <shunt>`)
+	ht.waitFor("route settings applied")
+
+	w := ht.serve(ht.routing.ServeRollback, "POST", "/routes/rollback?version=2&pin=true")
+	if w.Code != http.StatusOK {
+		t.Fatal("failed to pin", w.Code)
+	}
+
+	ht.update(``, "bar", "baz")
+	ht.waitFor("not applied while the routing table is pinned")
+
+	w = ht.serve(ht.routing.ServeRollback, "DELETE", "/routes/rollback")
+	if w.Code != http.StatusOK || ht.backend("/bar") == "" || w.Header().Get("X-Version") != "2" {
+		t.Fatal("failed to reject the pending update", w.Code, w.Header().Get("X-Version"))
+	}
+
+	w = ht.serve(ht.routing.ServeForceApply, "POST", "/routes/force-apply")
+	if w.Code != http.StatusOK || ht.backend("/bar") != "" {
+		t.Error("failed to apply the rejected update", w.Code)
+	}
+}
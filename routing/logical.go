@@ -0,0 +1,204 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+const (
+	// NotName represents the name of the builtin predicate that
+	// negates a nested predicate, e.g. Not(Host(/^www[.]/)).
+	NotName = "Not"
+
+	// OrName represents the name of the builtin predicate that
+	// matches when any of its nested predicates match, e.g.
+	// Or(Header("X-Beta", "true"), Cookie("beta", /^true$/)).
+	OrName = "Or"
+
+	// AndName represents the name of the builtin predicate that
+	// matches when all of its nested predicates match. It can be used
+	// to group predicates in Or or Not.
+	AndName = "And"
+)
+
+type (
+	notPredicate struct{ predicate Predicate }
+	orPredicate  struct{ predicates []Predicate }
+	andPredicate struct{ predicates []Predicate }
+
+	hostPredicate   struct{ rx *regexp.Regexp }
+	methodPredicate struct{ method string }
+
+	// matches the path normalized the same way as for the top level
+	// PathRegexp conditions
+	pathRegexpPredicate struct {
+		rx      *regexp.Regexp
+		options MatchingOptions
+	}
+
+	headerPredicate struct {
+		name  string
+		check func(string) bool
+	}
+)
+
+func (p *notPredicate) Match(r *http.Request) bool { return !p.predicate.Match(r) }
+
+func (p *orPredicate) Match(r *http.Request) bool {
+	for _, pi := range p.predicates {
+		if pi.Match(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *andPredicate) Match(r *http.Request) bool { return matchPredicates(p.predicates, r) }
+
+func (p *hostPredicate) Match(r *http.Request) bool   { return p.rx.MatchString(r.Host) }
+func (p *methodPredicate) Match(r *http.Request) bool { return r.Method == p.method }
+func (p *headerPredicate) Match(r *http.Request) bool { return matchHeader(r.Header, p.name, p.check) }
+
+func (p *pathRegexpPredicate) Match(r *http.Request) bool {
+	return p.rx.MatchString(cleanPath(r.URL.Path, p.options))
+}
+
+func isLogicalPredicate(name string) bool {
+	switch name {
+	case NotName, OrName, AndName:
+		return true
+	default:
+		return false
+	}
+}
+
+// creates the nested predicates of Not, Or and And
+func createNestedPredicates(cpm map[string]PredicateSpec, def *eskip.Predicate, mo MatchingOptions) ([]Predicate, error) {
+	if len(def.Args) == 0 || def.Name == NotName && len(def.Args) != 1 {
+		return nil, fmt.Errorf("invalid number of nested predicates in %s", def.Name)
+	}
+
+	var ps []Predicate
+	for _, a := range def.Args {
+		nested, ok := a.(*eskip.Predicate)
+		if !ok {
+			return nil, fmt.Errorf("expected predicate argument in %s", def.Name)
+		}
+
+		p, err := createPredicate(cpm, nested, true, mo)
+		if err != nil {
+			return nil, err
+		}
+
+		ps = append(ps, p)
+	}
+
+	return ps, nil
+}
+
+func compileArg(p *eskip.Predicate, a string) (*regexp.Regexp, error) {
+	rx, err := regexp.Compile(a)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression in %s: %v", p.Name, err)
+	}
+
+	return rx, nil
+}
+
+// creates the predicates that are stored in the fields of the route when
+// used on the top level, but need a predicate implementation when they
+// are nested in Not, Or or And
+func createFieldPredicate(def *eskip.Predicate, mo MatchingOptions) (Predicate, error) {
+	switch def.Name {
+	case hostRegexpName, pathRegexpName:
+		a, err := getFreeStringArgs(1, def)
+		if err != nil {
+			return nil, err
+		}
+
+		rx, err := compileArg(def, a[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if def.Name == hostRegexpName {
+			return &hostPredicate{rx: rx}, nil
+		}
+
+		return &pathRegexpPredicate{rx: rx, options: mo}, nil
+	case methodName:
+		a, err := getFreeStringArgs(1, def)
+		if err != nil {
+			return nil, err
+		}
+
+		return &methodPredicate{method: a[0]}, nil
+	case headerName:
+		a, err := getFreeStringArgs(2, def)
+		if err != nil {
+			return nil, err
+		}
+
+		value := a[1]
+		return &headerPredicate{
+			name:  http.CanonicalHeaderKey(a[0]),
+			check: func(v string) bool { return v == value },
+		}, nil
+	case headerRegexpName:
+		a, err := getFreeStringArgs(2, def)
+		if err != nil {
+			return nil, err
+		}
+
+		rx, err := compileArg(def, a[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return &headerPredicate{name: http.CanonicalHeaderKey(a[0]), check: rx.MatchString}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// creates a predicate instance from its spec, or, in case of the logical
+// predicates, from the nested predicates, recursively.
+func createPredicate(cpm map[string]PredicateSpec, def *eskip.Predicate, nested bool, mo MatchingOptions) (Predicate, error) {
+	if isLogicalPredicate(def.Name) {
+		ps, err := createNestedPredicates(cpm, def, mo)
+		if err != nil {
+			return nil, err
+		}
+
+		switch def.Name {
+		case NotName:
+			return &notPredicate{predicate: ps[0]}, nil
+		case OrName:
+			return &orPredicate{predicates: ps}, nil
+		default:
+			return &andPredicate{predicates: ps}, nil
+		}
+	}
+
+	if nested {
+		if isTreePredicate(def.Name) || def.Name == WeightName {
+			return nil, fmt.Errorf("%s cannot be nested in Not, Or or And", def.Name)
+		}
+
+		p, err := createFieldPredicate(def, mo)
+		if p != nil || err != nil {
+			return p, err
+		}
+	}
+
+	spec, ok := cpm[def.Name]
+	if !ok {
+		return nil, fmt.Errorf("predicate not found: '%s'", def.Name)
+	}
+
+	return spec.Create(def.Args)
+}
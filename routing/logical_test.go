@@ -0,0 +1,128 @@
+package routing
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+)
+
+type countryPredicate string
+
+type countrySpec struct{}
+
+func (countrySpec) Name() string { return "Country" }
+
+func (countrySpec) Create(args []interface{}) (Predicate, error) {
+	return countryPredicate(args[0].(string)), nil
+}
+
+func (p countryPredicate) Match(r *http.Request) bool { return r.Header.Get("X-Country") == string(p) }
+
+func TestLogicalPredicates(t *testing.T) {
+	type check struct {
+		method  string
+		host    string
+		path    string
+		headers http.Header
+		match   bool
+	}
+
+	for _, test := range []struct {
+		title      string
+		predicates string
+		fail       bool
+		checks     []check
+	}{{
+		title:      "not host",
+		predicates: `Not(Host(/^www[.]example[.]org$/))`,
+		checks: []check{
+			{host: "www.example.org", match: false},
+			{host: "api.example.org", match: true},
+		},
+	}, {
+		title:      "or header and custom",
+		predicates: `Or(Header("X-Beta", "true"), Country("de"))`,
+		checks: []check{
+			{headers: http.Header{"X-Beta": []string{"true"}}, match: true},
+			{headers: http.Header{"X-Country": []string{"de"}}, match: true},
+			{headers: http.Header{"X-Beta": []string{"false"}}, match: false},
+		},
+	}, {
+		title:      "grouped",
+		predicates: `Or(And(Method("POST"), PathRegexp(/^\/api/)), Not(HeaderRegexp("x-test", /^on$/)))`,
+		checks: []check{
+			{method: "POST", path: "/api/foo", headers: http.Header{"X-Test": []string{"on"}}, match: true},
+			{method: "GET", path: "/api/foo", headers: http.Header{"X-Test": []string{"on"}}, match: false},
+			{method: "GET", path: "/api/foo", match: true},
+		},
+	}, {
+		title:      "not with multiple args",
+		predicates: `Not(Method("GET"), Method("POST"))`,
+		fail:       true,
+	}, {
+		title:      "invalid arg",
+		predicates: `Or("foo")`,
+		fail:       true,
+	}, {
+		title:      "nested tree predicate",
+		predicates: `Not(Path("/foo"))`,
+		fail:       true,
+	}, {
+		title:      "unknown nested predicate",
+		predicates: `Not(Foo())`,
+		fail:       true,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			defs, err := eskip.ParsePredicates(test.predicates)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ps, err := processPredicates(mapPredicates([]PredicateSpec{countrySpec{}}), defs, MatchingOptionsNone)
+			if test.fail {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, c := range test.checks {
+				r := &http.Request{
+					Method: c.method,
+					Host:   c.host,
+					URL:    &url.URL{Path: c.path},
+					Header: c.headers,
+				}
+
+				if matchPredicates(ps, r) != c.match {
+					t.Errorf("unexpected match result for %v, expected: %v", c, c.match)
+				}
+			}
+		})
+	}
+}
+
+func TestNestedPathRegexpNormalizedPath(t *testing.T) {
+	defs, err := eskip.ParsePredicates(`Not(PathRegexp(/^\/foo\/bar$/))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps, err := processPredicates(make(map[string]PredicateSpec), defs, IgnoreTrailingSlash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"/foo/bar", "/foo//bar/", "/foo/baz/../bar"} {
+		if matchPredicates(ps, &http.Request{URL: &url.URL{Path: p}}) {
+			t.Errorf("failed to normalize the path: %s", p)
+		}
+	}
+}
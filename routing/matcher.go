@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/dimfeld/httppath"
+	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/pathmux"
 )
 
@@ -34,13 +35,29 @@ type leafMatcher struct {
 	headersExact  map[string]string
 	headersRegexp map[string][]*regexp.Regexp
 	predicates    []Predicate
+	weight        int
+	namedGroups   bool
 	route         *Route
+
+	// shared by the copies of the leaf reused across updates, see
+	// leafConditionSet
+	conditions *leafConditionSet
 }
 
 type leafMatchers []*leafMatcher
 
+// routeEvaluation describes a route in the order of evaluation for a
+// path, with its effective weight.
+type routeEvaluation struct {
+	Order  int          `json:"order"`
+	Weight int          `json:"weight"`
+	Route  *eskip.Route `json:"route"`
+}
+
+// the priority of a leaf matcher is calculated from the number of its
+// conditions, plus the weight set by the Weight predicate
 func leafWeight(l *leafMatcher) int {
-	w := 0
+	w := l.weight
 
 	if l.method != "" {
 		w++
@@ -168,7 +185,35 @@ func newLeaf(r *Route, rxs map[string]*regexp.Regexp) (*leafMatcher, error) {
 		headersExact:  canonicalizeHeaders(r.Headers),
 		headersRegexp: canonicalizeHeaderRegexps(allHeaderRxs),
 		predicates:    r.Predicates,
-		route:         r}, nil
+		weight:        r.weight,
+		namedGroups:   hasNamedGroups(hostRxs) || hasNamedGroups(pathRxs),
+		route:         r,
+		conditions:    &leafConditionSet{}}, nil
+}
+
+// returns a copy of the leaf matcher prepared when the route was
+// processed, or creates a new one
+func routeLeaf(r *Route, rxs map[string]*regexp.Regexp) (*leafMatcher, error) {
+	if r.leaf == nil {
+		return newLeaf(r, rxs)
+	}
+
+	l := *r.leaf
+	l.route = r
+	return &l, nil
+}
+
+// checks if any of the regexps has named capture groups
+func hasNamedGroups(rxs []*regexp.Regexp) bool {
+	for _, rx := range rxs {
+		for _, name := range rx.SubexpNames() {
+			if name != "" {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 // returns the free form wildcard parameter of a path
@@ -316,7 +361,7 @@ func newMatcher(rs []*Route, o MatchingOptions) (*matcher, []*definitionError) {
 	compiledRxs := make(map[string]*regexp.Regexp)
 
 	for i, r := range rs {
-		l, err := newLeaf(r, compiledRxs)
+		l, err := routeLeaf(r, compiledRxs)
 		if err != nil {
 			errors = append(errors, &definitionError{r.Id, i, err})
 			continue
@@ -378,6 +423,47 @@ func matchRegexps(rxs []*regexp.Regexp, s string) bool {
 	return true
 }
 
+// adds the values of the named capture groups of the regexps to the
+// parameters. Existing parameters are not overwritten.
+func addGroupParams(params map[string]string, rxs []*regexp.Regexp, s string) map[string]string {
+	for _, rx := range rxs {
+		m := rx.FindStringSubmatch(s)
+		if m == nil {
+			continue
+		}
+
+		for i, name := range rx.SubexpNames() {
+			if name == "" {
+				continue
+			}
+
+			if _, exists := params[name]; exists {
+				continue
+			}
+
+			if params == nil {
+				params = make(map[string]string)
+			}
+
+			params[name] = m[i]
+		}
+	}
+
+	return params
+}
+
+// adds the named capture groups of the host and path regexps of a
+// matched leaf to the parameters. The wildcard parameters from the path
+// take precedence.
+func regexpParams(l *leafMatcher, req *http.Request, path string, params map[string]string) map[string]string {
+	if !l.namedGroups {
+		return params
+	}
+
+	params = addGroupParams(params, l.hostRxs, req.Host)
+	return addGroupParams(params, l.pathRxs, path)
+}
+
 // matches a set of request headers to a fix and regexp header condition
 func matchHeader(h http.Header, key string, check func(string) bool) bool {
 	vals, has := h[key]
@@ -465,8 +551,8 @@ func matchLeaves(leaves leafMatchers, req *http.Request, path string) *leafMatch
 }
 
 // tries to match a request against the available definitions. If a match is found,
-// returns the associated value, and the wildcard parameters from the path definition,
-// if any.
+// returns the associated value, and the wildcard parameters from the path definition
+// and the named capture groups of the host and path regexps, if any.
 func (m *matcher) match(r *http.Request) (*Route, map[string]string) {
 	// normalize path before matching
 	// in case ignoring trailing slashes, match without the trailing slash
@@ -477,14 +563,49 @@ func (m *matcher) match(r *http.Request) (*Route, map[string]string) {
 	params, l := matchPathTree(m.paths, path, lrm)
 
 	if l != nil {
-		return l.route, params
+		return l.route, regexpParams(l, r, path, params)
 	}
 
 	// if no path match, match root leaves for other conditions
 	l = matchLeaves(m.rootLeaves, r, path)
 	if l != nil {
-		return l.route, nil
+		return l.route, regexpParams(l, r, path, nil)
 	}
 
 	return nil, nil
 }
+
+// collects the leaves of all the path tree entries matching a path, in
+// the order of backtracking, without evaluating the leaf conditions
+type leafCollector struct {
+	visited map[*pathMatcher]bool
+	leaves  leafMatchers
+}
+
+func (c *leafCollector) Match(value interface{}) (bool, interface{}) {
+	if pm, ok := value.(*pathMatcher); ok && !c.visited[pm] {
+		c.visited[pm] = true
+		c.leaves = append(c.leaves, pm.leaves...)
+	}
+
+	return false, nil
+}
+
+// returns the routes that are evaluated for a request with the given
+// path, in the order of the evaluation
+func (m *matcher) evaluationOrder(path string) []routeEvaluation {
+	path = cleanPath(path, m.matchingOptions)
+	c := &leafCollector{visited: make(map[*pathMatcher]bool)}
+	m.paths.LookupMatcher(path, c)
+
+	var evals []routeEvaluation
+	for i, l := range append(c.leaves, m.rootLeaves...) {
+		evals = append(evals, routeEvaluation{
+			Order:  i + 1,
+			Weight: leafWeight(l),
+			Route:  &l.route.Route,
+		})
+	}
+
+	return evals
+}
@@ -1126,3 +1126,83 @@ func BenchmarkConstructionMass(b *testing.B) {
 		}
 	}
 }
+
+func TestWeightOrder(t *testing.T) {
+	defs, err := eskip.Parse(`
+		header: Path("/foo") && Header("X-Foo", "bar") -> "https://header.example.org";
+		weighted: Path("/foo") && Weight(2) -> "https://weighted.example.org";
+		subtree: PathSubtree("/") -> "https://subtree.example.org";
+		root: Method("GET") -> "https://root.example.org";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var routes []*Route
+	for _, d := range defs {
+		r, err := processRouteDef(make(map[string]PredicateSpec), nil, MatchingOptionsNone, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		routes = append(routes, r)
+	}
+
+	m, errs := newMatcher(routes, MatchingOptionsNone)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	req := &http.Request{URL: &url.URL{Path: "/foo"}, Header: http.Header{"X-Foo": []string{"bar"}}}
+	if r, _ := m.match(req); r == nil || r.Id != "weighted" {
+		t.Error("failed to match the weighted route", r)
+	}
+
+	var got []string
+	for _, e := range m.evaluationOrder("/foo") {
+		got = append(got, fmt.Sprintf("%d:%s:%d", e.Order, e.Route.Id, e.Weight))
+	}
+
+	expected := []string{"1:weighted:2", "2:header:1", "3:subtree:0", "4:root:1"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("invalid evaluation order, expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestRegexpParams(t *testing.T) {
+	m, err := docToMatcher(`
+		user: Path("/users/:id") && PathRegexp(/^\/users\/(?P<id>[0-9]+)$/) && Host(/^(?P<tenant>[a-z]+)[.]example[.]org$/) -> <shunt>;
+		item: PathRegexp(/^\/items\/(?P<item>[^\/]+)\/(?P<rest>.*)$/) -> <shunt>;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		host     string
+		path     string
+		route    string
+		expected map[string]string
+	}{{
+		host:     "acme.example.org",
+		path:     "/users/42",
+		route:    "user",
+		expected: map[string]string{"id": "42", "tenant": "acme"},
+	}, {
+		host:     "acme.example.org",
+		path:     "/items/foo/bar/baz",
+		route:    "item",
+		expected: map[string]string{"item": "foo", "rest": "bar/baz"},
+	}} {
+		req := &http.Request{Host: test.host, URL: &url.URL{Path: test.path}}
+		r, params := m.match(req)
+		if r == nil || r.Id != test.route {
+			t.Error("failed to match", test.path, r)
+			continue
+		}
+
+		if fmt.Sprint(params) != fmt.Sprint(test.expected) {
+			t.Errorf("invalid params, expected: %v, got: %v", test.expected, params)
+		}
+	}
+}
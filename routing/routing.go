@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/logging"
+	"github.com/zalando/skipper/metrics"
 )
 
 const (
@@ -25,6 +27,14 @@ const (
 	// at https://godoc.org/github.com/zalando/skipper/eskip)
 	PathSubtreeName = "PathSubtree"
 
+	// WeightName represents the name of the builtin predicate that adds
+	// to the computed priority of a route, when there are multiple
+	// routes matching the same path, e.g. Weight(2). It doesn't
+	// affect the matching of the requests.
+	WeightName = "Weight"
+
+	rejectedUpdatesKey       = "routing.updates.rejected"
+	buildTimeKey             = "routing.build"
 	routesTimestampName      = "X-Timestamp"
 	routesCountName          = "X-Count"
 	defaultRouteListingLimit = 1024
@@ -118,6 +128,49 @@ type Options struct {
 
 	// PostProcessrs contains custom route post-processors.
 	PostProcessors []PostProcessor
+
+	// SignalFirstLoad enables signaling on the first load
+	// of the routing configuration, see Routing.FirstLoad().
+	SignalFirstLoad bool
+
+	// MaxRouteDrop, when greater than zero, is the maximum number of
+	// valid routes that a single update can remove from the routing
+	// table. Updates exceeding it are rejected, and the previous
+	// routing table is kept, until forced with Routing.ServeForceApply.
+	MaxRouteDrop int
+
+	// MaxRouteDropRatio, when greater than zero, is the maximum ratio,
+	// between 0 and 1, of the valid routes that a single update can
+	// remove from the routing table.
+	MaxRouteDropRatio float64
+
+	// MaxInvalidRouteRatio, when greater than zero, is the maximum
+	// ratio, between 0 and 1, of the invalid routes in an update.
+	MaxInvalidRouteRatio float64
+
+	// Metrics is used to count the rejected updates, and to measure
+	// the time of building the routing tables. When not set,
+	// metrics.Default is used.
+	Metrics metrics.Metrics
+
+	// RouteHistory is the number of the last applied routing tables
+	// kept for the history, diff and rollback endpoints. When zero,
+	// the last 10 tables are kept.
+	RouteHistory int
+
+	// FilterCloseDelay is the time waited before closing the filter
+	// instances implementing filters.FilterCloser, after no routing
+	// table uses them anymore, including the ones in the history, to
+	// let the in-flight requests finish. When zero, it is one minute.
+	FilterCloseDelay time.Duration
+}
+
+func (o Options) metrics() metrics.Metrics {
+	if o.Metrics == nil {
+		return metrics.Default
+	}
+
+	return o.Metrics
 }
 
 // RouteFilter contains extensions to generic filter
@@ -141,6 +194,15 @@ type Route struct {
 	// path predicate matching a subtree
 	pathSubtree string
 
+	// added to the priority of the route by the Weight predicate
+	weight int
+
+	// the leaf matcher and the printed definition prepared when the
+	// route was processed, reused on the next updates while the route
+	// doesn't change
+	leaf *leafMatcher
+	def  string
+
 	// The backend scheme and host.
 	Scheme, Host string
 
@@ -178,7 +240,9 @@ type Route struct {
 
 // PostProcessor is an interface for custom post-processors applying changes
 // to the routes after they were created from their data representation and
-// before they were passed to the proxy.
+// before they were passed to the proxy. The post-processors may drop or
+// add routes, but they are not expected to change the conditions of the
+// existing ones, because those are prepared for matching before.
 //
 // This feature is experimental.
 type PostProcessor interface {
@@ -190,7 +254,21 @@ type PostProcessor interface {
 type Routing struct {
 	routeTable atomic.Value // of struct routeTable
 	log        logging.Logger
+	firstLoad  chan struct{}
 	quit       chan struct{}
+
+	mu          sync.Mutex
+	rejected    *routeTable
+	version     uint64
+	history     []*routeTable
+	historySize int
+	pinned      bool
+	pending     *routeTable
+
+	// used for checking the pending update when unpinning
+	options Options
+
+	filterCloseDelay time.Duration
 }
 
 // New initializes a routing instance, and starts listening for route
@@ -200,7 +278,28 @@ func New(o Options) *Routing {
 		o.Log = &logging.DefaultLog{}
 	}
 
-	r := &Routing{log: o.Log, quit: make(chan struct{})}
+	if o.RouteHistory <= 0 {
+		o.RouteHistory = defaultRouteHistory
+	}
+
+	if o.FilterCloseDelay <= 0 {
+		o.FilterCloseDelay = defaultFilterCloseDelay
+	}
+
+	r := &Routing{
+		log:         o.Log,
+		firstLoad:   make(chan struct{}),
+		quit:        make(chan struct{}),
+		historySize: o.RouteHistory,
+		options:     o,
+
+		filterCloseDelay: o.FilterCloseDelay,
+	}
+
+	if !o.SignalFirstLoad {
+		close(r.firstLoad)
+	}
+
 	initialMatcher, _ := newMatcher(nil, MatchingOptionsNone)
 	rt := &routeTable{
 		m:       initialMatcher,
@@ -230,6 +329,7 @@ func (r *Routing) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if req.Method == "HEAD" {
 		w.Header().Set(routesTimestampName, createdUnix)
 		w.Header().Set(routesCountName, strconv.Itoa(len(rt.validRoutes)))
+		w.Header().Set(routesVersionName, strconv.FormatUint(rt.version, 10))
 
 		if strings.Contains(req.Header.Get("Accept"), "application/json") {
 			w.Header().Set("Content-Type", "application/json")
@@ -254,6 +354,12 @@ func (r *Routing) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	w.Header().Set(routesTimestampName, createdUnix)
 	w.Header().Set(routesCountName, strconv.Itoa(len(rt.validRoutes)))
+	w.Header().Set(routesVersionName, strconv.FormatUint(rt.version, 10))
+
+	if path := req.Form.Get("path"); path != "" {
+		serveEvaluationOrder(w, req, rt.m.evaluationOrder(path))
+		return
+	}
 
 	routes := slice(rt.validRoutes, offset, limit)
 	if strings.Contains(req.Header.Get("Accept"), "application/json") {
@@ -272,14 +378,110 @@ func (r *Routing) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	eskip.Fprint(w, extractPretty(req), routes...)
 }
 
+// renders the routes that are evaluated for a path, in the order of the
+// evaluation, together with their effective weight
+func serveEvaluationOrder(w http.ResponseWriter, req *http.Request, evals []routeEvaluation) {
+	if strings.Contains(req.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if evals == nil {
+			evals = []routeEvaluation{}
+		}
+
+		if err := json.NewEncoder(w).Encode(evals); err != nil {
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	pretty := extractPretty(req)
+	for i, e := range evals {
+		if i > 0 {
+			fmt.Fprint(w, "\n")
+			if pretty.Pretty {
+				fmt.Fprint(w, "\n")
+			}
+		}
+
+		fmt.Fprintf(w, "// order: %d, weight: %d\n", e.Order, e.Weight)
+		eskip.Fprint(w, pretty, e.Route)
+	}
+}
+
+// checks the next routing table against the configured thresholds,
+// and returns the reason when it needs to be rejected
+func checkUpdate(o Options, current, next *routeTable) string {
+	valid, invalid := len(next.validRoutes), len(next.invalidRoutes)
+	if o.MaxInvalidRouteRatio > 0 && valid+invalid > 0 {
+		if ratio := float64(invalid) / float64(valid+invalid); ratio > o.MaxInvalidRouteRatio {
+			return fmt.Sprintf("invalid route ratio %.2f exceeds %.2f", ratio, o.MaxInvalidRouteRatio)
+		}
+	}
+
+	drop := len(current.validRoutes) - valid
+	if drop <= 0 {
+		return ""
+	}
+
+	if o.MaxRouteDrop > 0 && drop > o.MaxRouteDrop {
+		return fmt.Sprintf("route drop %d exceeds %d", drop, o.MaxRouteDrop)
+	}
+
+	if o.MaxRouteDropRatio > 0 {
+		if ratio := float64(drop) / float64(len(current.validRoutes)); ratio > o.MaxRouteDropRatio {
+			return fmt.Sprintf("route drop ratio %.2f exceeds %.2f", ratio, o.MaxRouteDropRatio)
+		}
+	}
+
+	return ""
+}
+
 func (r *Routing) startReceivingUpdates(o Options) {
 	c := make(chan *routeTable)
 	go receiveRouteMatcher(o, c, r.quit)
 	go func() {
+		firstLoad := o.SignalFirstLoad
+		loaded := false
 		for {
 			select {
 			case rt := <-c:
-				r.routeTable.Store(rt)
+				r.mu.Lock()
+				if r.pinned {
+					previous := r.pending
+					r.pending = rt
+					r.discard(previous)
+					r.mu.Unlock()
+					r.log.Info("route settings received, not applied while the routing table is pinned")
+					continue
+				}
+
+				// the lock is held until the table is applied, to
+				// not override a concurrent rollback with pinning
+
+				// the first load is never rejected, there is
+				// no previous routing table to keep
+				if loaded {
+					if reason := checkUpdate(o, r.routeTable.Load().(*routeTable), rt); reason != "" {
+						r.reject(o, rt, reason)
+						r.mu.Unlock()
+						continue
+					}
+				}
+
+				r.apply(rt)
+				r.mu.Unlock()
+
+				loaded = true
+				if firstLoad {
+					close(r.firstLoad)
+					firstLoad = false
+				}
+
 				r.log.Info("route settings applied")
 			case <-r.quit:
 				return
@@ -288,11 +490,55 @@ func (r *Routing) startReceivingUpdates(o Options) {
 	}()
 }
 
+// stores a routing table as rejected, discarding the previously rejected
+// one. The lock needs to be held by the caller.
+func (r *Routing) reject(o Options, rt *routeTable, reason string) {
+	previous := r.rejected
+	r.rejected = rt
+	r.discard(previous)
+
+	o.metrics().IncCounter(rejectedUpdatesKey)
+	r.log.Errorf(
+		"route settings rejected, keeping the previous routing table: %s, valid routes: %d, invalid routes: %d",
+		reason,
+		len(rt.validRoutes),
+		len(rt.invalidRoutes),
+	)
+}
+
+// ServeForceApply applies the last rejected routing table, when it is
+// called with a POST request. It responds with 404, when there is no
+// rejected update pending. The rejected update is discarded, when a
+// subsequent update is applied.
+func (r *Routing) ServeForceApply(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.mu.Lock()
+	rt := r.rejected
+	if rt != nil {
+		r.apply(rt)
+	}
+
+	r.mu.Unlock()
+
+	if rt == nil {
+		http.Error(w, "no rejected update", http.StatusNotFound)
+		return
+	}
+
+	r.log.Infof("route settings applied by force, valid routes: %d", len(rt.validRoutes))
+	w.Header().Set(routesCountName, strconv.Itoa(len(rt.validRoutes)))
+}
+
 // Route matches a request in the current routing tree.
 //
 // If the request matches a route, returns the route and a map of
 // parameters constructed from the wildcard parameters in the path
-// condition if any. If there is no match, it returns nil.
+// condition, and from the named capture groups of the Host and
+// PathRegexp conditions, if any. If there is no match, it returns nil.
 func (r *Routing) Route(req *http.Request) (*Route, map[string]string) {
 	rt := r.routeTable.Load().(*routeTable)
 	return rt.m.match(req)
@@ -325,6 +571,14 @@ func (r *Routing) Get() *RouteLookup {
 	return &RouteLookup{matcher: rt.m}
 }
 
+// FirstLoad returns a channel that gets closed after the first
+// routing configuration has been applied, when the
+// SignalFirstLoad option is set. Otherwise, the channel is closed
+// already.
+func (r *Routing) FirstLoad() <-chan struct{} {
+	return r.firstLoad
+}
+
 // Close closes routing, stops receiving routes.
 func (r *Routing) Close() {
 	close(r.quit)
@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,6 +20,7 @@ import (
 	"github.com/zalando/skipper/filters/builtin"
 	"github.com/zalando/skipper/filters/filtertest"
 	"github.com/zalando/skipper/logging/loggingtest"
+	"github.com/zalando/skipper/metrics"
 	"github.com/zalando/skipper/routing"
 	"github.com/zalando/skipper/routing/testdataclient"
 )
@@ -166,6 +169,46 @@ func TestReceivesInitial(t *testing.T) {
 	}
 }
 
+func TestSignalFirstLoad(t *testing.T) {
+	dc := testdataclient.New([]*eskip.Route{{Id: "route1", Path: "/some-path", Backend: "https://www.example.org"}})
+	l := loggingtest.New()
+	defer l.Close()
+
+	rt := routing.New(routing.Options{
+		DataClients:     []routing.DataClient{dc},
+		Log:             l,
+		SignalFirstLoad: true,
+	})
+
+	defer rt.Close()
+
+	select {
+	case <-rt.FirstLoad():
+	case <-time.After(120 * time.Millisecond):
+		t.Fatal("timeout waiting for the first load")
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.com/some-path", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r, _ := rt.Route(req); r == nil || r.Id != "route1" {
+		t.Error("failed to match the route after the first load")
+	}
+}
+
+func TestFirstLoadNotSignaled(t *testing.T) {
+	rt := routing.New(routing.Options{})
+	defer rt.Close()
+
+	select {
+	case <-rt.FirstLoad():
+	default:
+		t.Error("first load channel not closed")
+	}
+}
+
 func TestReceivesFullOnFailedUpdate(t *testing.T) {
 	dc := testdataclient.New([]*eskip.Route{{Id: "route1", Path: "/some-path", Backend: "https://www.example.org"}})
 	tr, err := newTestRouting(dc)
@@ -598,6 +641,48 @@ func TestRoutingHandlerJsonResponse(t *testing.T) {
 	}
 }
 
+func TestRoutingHandlerAnnotations(t *testing.T) {
+	dc, _ := testdataclient.NewDoc(`
+        route1: @owner("team-x") CustomPredicate("custom1") -> "https://route1.example.org"`)
+	cps := []routing.PredicateSpec{&predicate{}}
+	tr, _ := newTestRoutingWithPredicates(cps, dc)
+	defer tr.close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", tr.routing)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected server error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var routes []*eskip.Route
+	if err := json.NewDecoder(resp.Body).Decode(&routes); err != nil {
+		t.Fatalf("failed to decode the response body: %v", err)
+	}
+
+	if len(routes) != 1 || routes[0].Annotations["owner"] != "team-x" {
+		t.Errorf("failed to list the route annotations: %v", routes)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected server error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(b), `@owner("team-x")`) {
+		t.Errorf("failed to print the route annotations: %s", string(b))
+	}
+}
+
 func TestRoutingHandlerFilterInvalidRoutes(t *testing.T) {
 	dc, _ := testdataclient.NewDoc(`
         route1: CustomPredicate("custom1") -> "https://route1.example.org";
@@ -736,6 +821,193 @@ func TestRoutingHandlerHEAD(t *testing.T) {
 	}
 }
 
+func TestRoutingHandlerEvaluationOrder(t *testing.T) {
+	dc, _ := testdataclient.NewDoc(`
+		api: PathSubtree("/api") -> "https://api.example.org";
+		beta: PathSubtree("/api") && Header("X-Beta", "true") && Weight(2) -> "https://beta.example.org";
+		other: Path("/other") -> "https://other.example.org";
+	`)
+
+	tr, err := newTestRouting(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tr.close()
+	if err := tr.waitForRouteSetting(); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(tr.routing)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/routes?path=/api/foo", nil)
+	req.Header.Set("Accept", "application/json")
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+
+	var evals []struct {
+		Order  int          `json:"order"`
+		Weight int          `json:"weight"`
+		Route  *eskip.Route `json:"route"`
+	}
+
+	if err := json.NewDecoder(rsp.Body).Decode(&evals); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(evals) != 2 ||
+		evals[0].Route.Id != "beta" || evals[0].Order != 1 || evals[0].Weight != 3 ||
+		evals[1].Route.Id != "api" || evals[1].Order != 2 || evals[1].Weight != 0 {
+		t.Errorf("invalid evaluation order: %v", evals)
+	}
+
+	rsp, err = http.Get(server.URL + "/routes?path=/api/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+	b, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(b), "// order: 1, weight: 3\nbeta: ") {
+		t.Errorf("invalid text output: %s", string(b))
+	}
+}
+
+type counterMetrics struct {
+	metrics.Metrics
+	mx       sync.Mutex
+	counters map[string]int
+}
+
+func (m *counterMetrics) IncCounter(key string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.counters[key]++
+}
+
+func (m *counterMetrics) counter(key string) int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.counters[key]
+}
+
+func TestRejectUpdates(t *testing.T) {
+	for _, test := range []struct {
+		title   string
+		options routing.Options
+		update  string
+		deleted []string
+		check   string
+		reject  bool
+	}{{
+		title:   "drop within the limit",
+		options: routing.Options{MaxRouteDrop: 1},
+		deleted: []string{"foo"},
+		check:   "/foo",
+	}, {
+		title:   "drop exceeds the limit",
+		options: routing.Options{MaxRouteDrop: 1},
+		deleted: []string{"foo", "bar"},
+		check:   "/foo",
+		reject:  true,
+	}, {
+		title:   "drop ratio exceeds the limit",
+		options: routing.Options{MaxRouteDropRatio: 0.5},
+		deleted: []string{"foo", "bar", "baz"},
+		check:   "/foo",
+		reject:  true,
+	}, {
+		title:   "invalid ratio exceeds the limit",
+		options: routing.Options{MaxInvalidRouteRatio: 0.2},
+		update:  `bar: Path("/bar") -> unknownFilter() -> <shunt>`,
+		check:   "/bar",
+		reject:  true,
+	}} {
+		t.Run(test.title, func(t *testing.T) {
+			dc, err := testdataclient.NewDoc(`
+				foo: Path("/foo") -> <shunt>;
+				bar: Path("/bar") -> <shunt>;
+				baz: Path("/baz") -> <shunt>;
+				qux: Path("/qux") -> <shunt>;
+			`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l := loggingtest.New()
+			defer l.Close()
+
+			m := &counterMetrics{Metrics: metrics.Void, counters: make(map[string]int)}
+			o := test.options
+			o.FilterRegistry = builtin.MakeRegistry()
+			o.DataClients = []routing.DataClient{dc}
+			o.PollTimeout = pollTimeout
+			o.Log = l
+			o.Metrics = m
+			rt := routing.New(o)
+			defer rt.Close()
+
+			if err := l.WaitFor("route settings applied", 12*pollTimeout); err != nil {
+				t.Fatal(err)
+			}
+
+			l.Reset()
+			if err := dc.UpdateDoc(test.update, test.deleted); err != nil {
+				t.Fatal(err)
+			}
+
+			expectedLog := "route settings applied"
+			if test.reject {
+				expectedLog = "route settings rejected"
+			}
+
+			if err := l.WaitFor(expectedLog, 12*pollTimeout); err != nil {
+				t.Fatal(err)
+			}
+
+			found := func() bool {
+				r, _ := rt.Route(&http.Request{URL: &url.URL{Path: test.check}})
+				return r != nil
+			}
+
+			if found() != test.reject {
+				t.Error("unexpected routing table after the update")
+			}
+
+			if test.reject && m.counter("routing.updates.rejected") != 1 {
+				t.Error("failed to count the rejected update")
+			}
+
+			w := httptest.NewRecorder()
+			rt.ServeForceApply(w, httptest.NewRequest("POST", "/routes/force-apply", nil))
+			if !test.reject {
+				if w.Code != http.StatusNotFound {
+					t.Error("unexpected force apply status", w.Code)
+				}
+
+				return
+			}
+
+			if w.Code != http.StatusOK {
+				t.Fatal("failed to force apply", w.Code)
+			}
+
+			if found() {
+				t.Error("failed to apply the rejected update")
+			}
+		})
+	}
+}
+
 func TestUpdateFailsRecovers(t *testing.T) {
 	l := loggingtest.New()
 	defer l.Close()
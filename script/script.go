@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	lua "github.com/yuin/gopher-lua"
 	"github.com/zalando/skipper/filters"
@@ -77,7 +78,9 @@ func (s *script) getState() (*lua.LState, error) {
 }
 
 func (s *script) putState(L *lua.LState) {
-	if s.pool == nil { // pool closed
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pool == nil || s.closed { // pool closed
 		L.Close()
 		return
 	}
@@ -139,6 +142,27 @@ type script struct {
 	source      string
 	routeParams []string
 	pool        chan *lua.LState
+
+	// protects the pool from receiving states after closing
+	mu     sync.Mutex
+	closed bool
+}
+
+// Close closes the pooled lua states, when the route of the filter was
+// removed or replaced. The states used by the requests still in flight
+// are closed when they are returned.
+func (s *script) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for {
+		select {
+		case L := <-s.pool:
+			L.Close()
+		default:
+			return
+		}
+	}
 }
 
 func (s *script) Request(f filters.FilterContext) {
@@ -2,6 +2,7 @@ package script
 
 import (
 	"net/http"
+	"sync"
 	"testing"
 
 	opentracing "github.com/opentracing/opentracing-go"
@@ -38,6 +39,60 @@ func TestLoadScript(t *testing.T) {
 	}
 }
 
+func TestCloseScript(t *testing.T) {
+	ls := &luaScript{}
+	f, err := ls.CreateFilter([]interface{}{`function request(ctx, params); end`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := f.(*script)
+	if len(s.pool) != InitialPoolSize {
+		t.Fatalf("invalid initial pool size: %d", len(s.pool))
+	}
+
+	L, err := s.getState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.(filters.FilterCloser).Close()
+	if len(s.pool) != 0 {
+		t.Errorf("failed to close the pooled states: %d", len(s.pool))
+	}
+
+	s.putState(L)
+	if len(s.pool) != 0 {
+		t.Error("failed to close the state returned after closing the filter")
+	}
+}
+
+func TestCloseScriptConcurrently(t *testing.T) {
+	ls := &luaScript{}
+	f, err := ls.CreateFilter([]interface{}{`function request(ctx, params); end`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := f.(*script)
+	var wg sync.WaitGroup
+	for i := 0; i < 2*MaxPoolSize; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if L, err := s.getState(); err == nil {
+				s.putState(L)
+			}
+		}()
+	}
+
+	s.Close()
+	wg.Wait()
+	if len(s.pool) != 0 {
+		t.Errorf("states left in the pool of the closed filter: %d", len(s.pool))
+	}
+}
+
 type luaContext struct {
 	request  *http.Request
 	response *http.Response
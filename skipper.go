@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -21,11 +22,7 @@ import (
 	"github.com/zalando/skipper/loadbalancer"
 	"github.com/zalando/skipper/logging"
 	"github.com/zalando/skipper/metrics"
-	"github.com/zalando/skipper/predicates/cookie"
-	"github.com/zalando/skipper/predicates/interval"
-	"github.com/zalando/skipper/predicates/query"
-	"github.com/zalando/skipper/predicates/source"
-	"github.com/zalando/skipper/predicates/traffic"
+	predicates "github.com/zalando/skipper/predicates/builtin"
 	"github.com/zalando/skipper/proxy"
 	"github.com/zalando/skipper/ratelimit"
 	"github.com/zalando/skipper/routing"
@@ -207,6 +204,29 @@ type Options struct {
 	// instead of full details of the updated/deleted routes.
 	SuppressRouteUpdateLogs bool
 
+	// MaxRouteDrop rejects the routing updates that would remove more
+	// valid routes than this number. Zero means no limit.
+	MaxRouteDrop int
+
+	// MaxRouteDropRatio rejects the routing updates that would remove
+	// a higher ratio of the valid routes. Zero means no limit.
+	MaxRouteDropRatio float64
+
+	// MaxInvalidRouteRatio rejects the routing updates with a higher
+	// ratio of invalid routes. Zero means no limit.
+	MaxInvalidRouteRatio float64
+
+	// RouteHistory sets the number of the last routing tables kept for
+	// the history, diff and rollback support endpoints. When zero, the
+	// last 10 tables are kept.
+	RouteHistory int
+
+	// FilterCloseDelay sets the time to wait for the in-flight requests,
+	// before closing the filters of the removed or replaced routes, once
+	// their routing table dropped out of the history. When zero, it is
+	// one minute.
+	FilterCloseDelay time.Duration
+
 	// Dev mode. Currently this flag disables prioritization of the
 	// consumer side over the feeding side during the routing updates to
 	// populate the updated routes faster.
@@ -277,6 +297,24 @@ type Options struct {
 	// instead of the default uniform one.
 	MetricsUseExpDecaySample bool
 
+	// EnableLabelledRequestMetrics enables the Prometheus histograms of
+	// the serve and backend response times, labelled with the route,
+	// host, method, status code and backend of the requests.
+	EnableLabelledRequestMetrics bool
+
+	// HistogramMetricBuckets sets the upper bounds of the buckets of the
+	// Prometheus histograms, in seconds.
+	HistogramMetricBuckets []float64
+
+	// MetricsHostLabelLimit limits the number of distinct hosts in the
+	// Prometheus metrics. The hosts above the limit are collapsed into
+	// a single label value. Negative values disable the limit.
+	MetricsHostLabelLimit int
+
+	// MetricsRouteAnnotationLabels sets the route annotations whose
+	// values are added as labels to the labelled request metrics.
+	MetricsRouteAnnotationLabels []string
+
 	// The following options, for backwards compatibility, are true
 	// by default: EnableAllFiltersMetrics, EnableRouteResponseMetrics,
 	// EnableRouteBackendErrorsCounters, EnableRouteStreamingErrorsCounters,
@@ -315,6 +353,23 @@ type Options struct {
 	// Enables logs in JSON format
 	AccessLogJSONEnabled bool
 
+	// AccessLogFormat sets a custom format for the access log entries.
+	// See logging.Options.AccessLogFormat.
+	AccessLogFormat string
+
+	// AccessLogJSONFields selects the fields of the access log entries
+	// in JSON format. See logging.Options.AccessLogJSONFields.
+	AccessLogJSONFields []string
+
+	// AccessLogSampling enables the sampling of the access log
+	// entries. See logging.Options.AccessLogSampling.
+	AccessLogSampling bool
+
+	// AccessLogSampleRatio sets the ratio of the written access log
+	// entries, when sampling is enabled. See
+	// logging.Options.AccessLogSampleRatio.
+	AccessLogSampleRatio float64
+
 	DebugListener string
 
 	//Path of certificate when using TLS
@@ -400,6 +455,19 @@ type Options struct {
 	// the last item of the string list of the X-Forwarded-For
 	// header, in this case you want to set this to true.
 	ReverseSourcePredicate bool
+
+	// StickySessionSecret enables session affinity for the load
+	// balanced routes. The proxy pins the clients to the selected
+	// group members with a cookie signed by this secret.
+	StickySessionSecret string
+
+	// StickySessionCookieName sets the name prefix of the session
+	// affinity cookies. Default: skipper-lb.
+	StickySessionCookieName string
+
+	// StickySessionMaxAge sets the max age of the session affinity
+	// cookies. When not set, session cookies are used.
+	StickySessionMaxAge time.Duration
 }
 
 func createDataClients(o Options, auth innkeeper.Authentication) ([]routing.DataClient, error) {
@@ -515,14 +583,16 @@ func initLog(o Options) error {
 		}
 	}
 
-	logging.Init(logging.Options{
+	return logging.Init(logging.Options{
 		ApplicationLogPrefix: o.ApplicationLogPrefix,
 		ApplicationLogOutput: logOutput,
 		AccessLogOutput:      accessLogOutput,
 		AccessLogDisabled:    o.AccessLogDisabled,
-		AccessLogJSONEnabled: o.AccessLogJSONEnabled})
-
-	return nil
+		AccessLogJSONEnabled: o.AccessLogJSONEnabled,
+		AccessLogFormat:      o.AccessLogFormat,
+		AccessLogJSONFields:  o.AccessLogJSONFields,
+		AccessLogSampling:    o.AccessLogSampling,
+		AccessLogSampleRatio: o.AccessLogSampleRatio})
 }
 
 func (o *Options) isHTTPS() bool {
@@ -558,6 +628,47 @@ func listenAndServe(proxy http.Handler, o *Options) error {
 	return srv.ListenAndServe()
 }
 
+// routeHosts passes the hosts of the routes to the metrics backend, when
+// it limits the host label values. The metrics backend is created after
+// the routing, so the hosts of the last update are stored until then.
+type routeHosts struct {
+	mu           sync.Mutex
+	setter       metrics.RouteHostsSetter
+	hostRegexps  []string
+	backendHosts []string
+}
+
+func (h *routeHosts) Do(routes []*routing.Route) []*routing.Route {
+	var hostRegexps, backendHosts []string
+	for _, r := range routes {
+		hostRegexps = append(hostRegexps, r.HostRegexps...)
+		if r.Host != "" {
+			backendHosts = append(backendHosts, r.Host)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hostRegexps, h.backendHosts = hostRegexps, backendHosts
+	if h.setter != nil {
+		h.setter.SetRouteHosts(hostRegexps, backendHosts)
+	}
+
+	return routes
+}
+
+func (h *routeHosts) setMetrics(m metrics.Metrics) {
+	setter, ok := m.(metrics.RouteHostsSetter)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.setter = setter
+	setter.SetRouteHosts(h.hostRegexps, h.backendHosts)
+}
+
 // Run skipper.
 func Run(o Options) error {
 	// init log
@@ -621,18 +732,9 @@ func Run(o Options) error {
 	}
 
 	// include bundled custom predicates
-	o.CustomPredicates = append(o.CustomPredicates,
-		source.New(),
-		source.NewFromLast(),
-		interval.NewBetween(),
-		interval.NewBefore(),
-		interval.NewAfter(),
-		cookie.New(),
-		query.New(),
-		traffic.New(),
-		loadbalancer.NewGroup(),
-		loadbalancer.NewMember(),
-	)
+	o.CustomPredicates = append(o.CustomPredicates, predicates.Specs()...)
+
+	hosts := &routeHosts{}
 
 	// create a routing engine
 	routing := routing.New(routing.Options{
@@ -643,7 +745,13 @@ func Run(o Options) error {
 		Predicates:      o.CustomPredicates,
 		UpdateBuffer:    updateBuffer,
 		SuppressLogs:    o.SuppressRouteUpdateLogs,
-		PostProcessors:  []routing.PostProcessor{loadbalancer.HealthcheckPostProcessor{LB: lbInstance}},
+		PostProcessors:  []routing.PostProcessor{loadbalancer.HealthcheckPostProcessor{LB: lbInstance}, hosts},
+
+		MaxRouteDrop:         o.MaxRouteDrop,
+		MaxRouteDropRatio:    o.MaxRouteDropRatio,
+		MaxInvalidRouteRatio: o.MaxInvalidRouteRatio,
+		RouteHistory:         o.RouteHistory,
+		FilterCloseDelay:     o.FilterCloseDelay,
 	})
 	defer routing.Close()
 
@@ -664,6 +772,12 @@ func Run(o Options) error {
 		DualStack:              o.DualStackBackend,
 		TLSHandshakeTimeout:    o.TLSHandshakeTimeoutBackend,
 		MaxIdleConns:           o.MaxIdleConnsBackend,
+		StickySessions: loadbalancer.NewSticky(loadbalancer.StickyOptions{
+			CookieName: o.StickySessionCookieName,
+			Secret:     []byte(o.StickySessionSecret),
+			MaxAge:     o.StickySessionMaxAge,
+			Secure:     o.isHTTPS(),
+		}),
 	}
 
 	if o.EnableBreakers || len(o.BreakerSettings) > 0 {
@@ -695,6 +809,11 @@ func Run(o Options) error {
 		mux := http.NewServeMux()
 		mux.Handle("/routes", routing)
 		mux.Handle("/routes/", routing)
+		mux.HandleFunc("/routes/conflicts", routing.ServeConflicts)
+		mux.HandleFunc("/routes/force-apply", routing.ServeForceApply)
+		mux.HandleFunc("/routes/history", routing.ServeHistory)
+		mux.HandleFunc("/routes/diff", routing.ServeDiff)
+		mux.HandleFunc("/routes/rollback", routing.ServeRollback)
 
 		if o.EnablePrometheusMetrics {
 			o.MetricsFlavours = append(o.MetricsFlavours, "prometheus")
@@ -731,7 +850,12 @@ func Run(o Options) error {
 			EnableRouteBackendMetrics:          o.EnableRouteBackendMetrics,
 			UseExpDecaySample:                  o.MetricsUseExpDecaySample,
 			DisableCompatibilityDefaults:       o.DisableMetricsCompatibilityDefaults,
+			EnableLabelledRequestMetrics:       o.EnableLabelledRequestMetrics,
+			HistogramBuckets:                   o.HistogramMetricBuckets,
+			HostLabelLimit:                     o.MetricsHostLabelLimit,
+			RouteAnnotationLabels:              o.MetricsRouteAnnotationLabels,
 		})
+		hosts.setMetrics(metrics.Default)
 		mux.Handle("/metrics", metricsHandler)
 		mux.Handle("/metrics/", metricsHandler)
 		mux.Handle("/debug/pprof", metricsHandler)
@@ -0,0 +1,324 @@
+package otel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	logrus "github.com/sirupsen/logrus"
+)
+
+const tracesPath = "/v1/traces"
+
+// OTLP span kinds and status codes
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+	spanKindProducer = 4
+	spanKindConsumer = 5
+
+	statusCodeError = 2
+)
+
+type exporter struct {
+	url           string
+	serviceName   string
+	batchSize     int
+	flushInterval time.Duration
+	client        *http.Client
+	queue         chan *span
+	quit          chan struct{}
+	done          chan struct{}
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type spanEvent struct {
+	TimeUnixNano string     `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []keyValue `json:"attributes,omitempty"`
+}
+
+type spanStatus struct {
+	Code int `json:"code,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	TraceState        string      `json:"traceState,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []keyValue  `json:"attributes,omitempty"`
+	Events            []spanEvent `json:"events,omitempty"`
+	Status            spanStatus  `json:"status"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+func newExporter(o Options) *exporter {
+	if o.Endpoint == "" {
+		o.Endpoint = DefaultEndpoint
+	}
+
+	if o.ServiceName == "" {
+		o.ServiceName = DefaultServiceName
+	}
+
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = DefaultFlushInterval
+	}
+
+	if o.QueueSize <= 0 {
+		o.QueueSize = DefaultQueueSize
+	}
+
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	e := &exporter{
+		url:           strings.TrimSuffix(o.Endpoint, "/") + tracesPath,
+		serviceName:   o.ServiceName,
+		batchSize:     o.BatchSize,
+		flushInterval: o.FlushInterval,
+		client:        o.Client,
+		queue:         make(chan *span, o.QueueSize),
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	go e.run()
+	return e
+}
+
+func (e *exporter) add(s *span) {
+	select {
+	case e.queue <- s:
+	default:
+		logrus.Debug("otel: export queue full, dropping span")
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	var batch []*span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := e.export(batch); err != nil {
+			logrus.Errorf("otel: failed to export spans: %v", err)
+		}
+
+		batch = nil
+	}
+
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= e.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.quit:
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+					if len(batch) >= e.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *exporter) export(spans []*span) error {
+	req := exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{stringAttribute("service.name", e.serviceName)}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: "skipper"},
+			Spans: make([]otlpSpan, 0, len(spans)),
+		}},
+	}}}
+
+	for _, s := range spans {
+		req.ResourceSpans[0].ScopeSpans[0].Spans = append(req.ResourceSpans[0].ScopeSpans[0].Spans, toOTLP(s))
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := e.client.Post(e.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	defer rsp.Body.Close()
+	io.Copy(ioutil.Discard, rsp.Body)
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response status: %d", rsp.StatusCode)
+	}
+
+	return nil
+}
+
+func (e *exporter) close() {
+	select {
+	case <-e.quit:
+	default:
+		close(e.quit)
+	}
+
+	<-e.done
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func stringAttribute(key, value string) keyValue {
+	return keyValue{Key: key, Value: anyValue{StringValue: &value}}
+}
+
+func toAttribute(key string, value interface{}) keyValue {
+	switch v := value.(type) {
+	case string:
+		return stringAttribute(key, v)
+	case bool:
+		return keyValue{Key: key, Value: anyValue{BoolValue: &v}}
+	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(v)
+		return keyValue{Key: key, Value: anyValue{IntValue: &s}}
+	case float32:
+		f := float64(v)
+		return keyValue{Key: key, Value: anyValue{DoubleValue: &f}}
+	case float64:
+		return keyValue{Key: key, Value: anyValue{DoubleValue: &v}}
+	default:
+		return stringAttribute(key, fmt.Sprint(v))
+	}
+}
+
+func spanKind(v interface{}) int {
+	switch fmt.Sprint(v) {
+	case string(ext.SpanKindRPCServerEnum):
+		return spanKindServer
+	case string(ext.SpanKindRPCClientEnum):
+		return spanKindClient
+	case string(ext.SpanKindProducerEnum):
+		return spanKindProducer
+	case string(ext.SpanKindConsumerEnum):
+		return spanKindConsumer
+	default:
+		return spanKindInternal
+	}
+}
+
+func toOTLP(s *span) otlpSpan {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	o := otlpSpan{
+		TraceID:           s.context.TraceIDHex(),
+		SpanID:            s.context.SpanIDHex(),
+		TraceState:        s.context.TraceState,
+		Name:              s.operation,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(s.start),
+		EndTimeUnixNano:   unixNano(s.end),
+	}
+
+	if s.parentID != [8]byte{} {
+		o.ParentSpanID = SpanContext{SpanID: s.parentID}.SpanIDHex()
+	}
+
+	for k, v := range s.tags {
+		switch k {
+		case string(ext.SpanKind):
+			o.Kind = spanKind(v)
+			continue
+		case string(ext.Error):
+			if b, ok := v.(bool); ok && b {
+				o.Status.Code = statusCodeError
+			}
+		}
+
+		o.Attributes = append(o.Attributes, toAttribute(k, v))
+	}
+
+	for _, l := range s.logs {
+		ev := spanEvent{TimeUnixNano: unixNano(l.time), Name: "log"}
+		for _, f := range l.fields {
+			if f.Key() == "event" {
+				ev.Name = fmt.Sprint(f.Value())
+				continue
+			}
+
+			ev.Attributes = append(ev.Attributes, toAttribute(f.Key(), f.Value()))
+		}
+
+		o.Events = append(o.Events, ev)
+	}
+
+	return o
+}
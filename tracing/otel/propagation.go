@@ -0,0 +1,183 @@
+package otel
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	ot "github.com/opentracing/opentracing-go"
+)
+
+const (
+	traceParentHeader = "traceparent"
+	traceStateHeader  = "tracestate"
+	baggageHeader     = "baggage"
+
+	b3Header             = "b3"
+	b3TraceIDHeader      = "x-b3-traceid"
+	b3SpanIDHeader       = "x-b3-spanid"
+	b3ParentSpanIDHeader = "x-b3-parentspanid"
+	b3SampledHeader      = "x-b3-sampled"
+	b3FlagsHeader        = "x-b3-flags"
+)
+
+func decodeID(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) {
+		return false
+	}
+
+	if _, err := hex.Decode(dst, []byte(s)); err != nil {
+		return false
+	}
+
+	for _, b := range dst {
+		if b != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodes 64 or 128 bit B3 trace IDs
+func decodeB3TraceID(dst *[16]byte, s string) bool {
+	if len(s) == 16 {
+		s = strings.Repeat("0", 16) + s
+	}
+
+	return decodeID(dst[:], s)
+}
+
+func injectW3C(c SpanContext, w ot.TextMapWriter) {
+	flags := "00"
+	if c.Sampled() {
+		flags = "01"
+	}
+
+	w.Set(traceParentHeader, fmt.Sprintf("00-%s-%s-%s", c.TraceIDHex(), c.SpanIDHex(), flags))
+	if c.TraceState != "" {
+		w.Set(traceStateHeader, c.TraceState)
+	}
+}
+
+func extractW3C(h map[string]string) (c SpanContext, err error) {
+	tp, ok := h[traceParentHeader]
+	if !ok {
+		err = ot.ErrSpanContextNotFound
+		return
+	}
+
+	parts := strings.Split(strings.TrimSpace(tp), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || parts[0] == "00" && len(parts) != 4 {
+		err = ot.ErrSpanContextCorrupted
+		return
+	}
+
+	var flags [1]byte
+	if !decodeID(c.TraceID[:], parts[1]) || !decodeID(c.SpanID[:], parts[2]) || len(parts[3]) != 2 {
+		err = ot.ErrSpanContextCorrupted
+		return
+	}
+
+	if _, err = hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		err = ot.ErrSpanContextCorrupted
+		return
+	}
+
+	c.sampled = flags[0]&1 == 1
+	c.TraceState = h[traceStateHeader]
+	return
+}
+
+func injectB3(c SpanContext, w ot.TextMapWriter) {
+	sampled := "0"
+	if c.Sampled() {
+		sampled = "1"
+	}
+
+	w.Set(b3TraceIDHeader, c.TraceIDHex())
+	w.Set(b3SpanIDHeader, c.SpanIDHex())
+	w.Set(b3SampledHeader, sampled)
+}
+
+func extractB3(h map[string]string) (c SpanContext, err error) {
+	if single, ok := h[b3Header]; ok {
+		return extractB3Single(single)
+	}
+
+	traceID, ok := h[b3TraceIDHeader]
+	if !ok {
+		err = ot.ErrSpanContextNotFound
+		return
+	}
+
+	if !decodeB3TraceID(&c.TraceID, traceID) || !decodeID(c.SpanID[:], h[b3SpanIDHeader]) {
+		err = ot.ErrSpanContextCorrupted
+		return
+	}
+
+	c.sampled = h[b3SampledHeader] == "1" || h[b3SampledHeader] == "true" || h[b3FlagsHeader] == "1"
+	return
+}
+
+// b3: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+func extractB3Single(v string) (c SpanContext, err error) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 2 {
+		// a single sampling state without IDs is not supported
+		err = ot.ErrSpanContextNotFound
+		return
+	}
+
+	if !decodeB3TraceID(&c.TraceID, parts[0]) || !decodeID(c.SpanID[:], parts[1]) {
+		err = ot.ErrSpanContextCorrupted
+		return
+	}
+
+	c.sampled = len(parts) > 2 && (parts[2] == "1" || parts[2] == "d")
+	return
+}
+
+func injectBaggage(c SpanContext, w ot.TextMapWriter) {
+	if len(c.baggage) == 0 {
+		return
+	}
+
+	var items []string
+	for k, v := range c.baggage {
+		items = append(items, url.QueryEscape(k)+"="+url.QueryEscape(v))
+	}
+
+	w.Set(baggageHeader, strings.Join(items, ","))
+}
+
+func extractBaggage(h map[string]string) map[string]string {
+	v, ok := h[baggageHeader]
+	if !ok {
+		return nil
+	}
+
+	b := make(map[string]string)
+	for _, item := range strings.Split(v, ",") {
+		// properties after the first semicolon are ignored
+		kv := strings.SplitN(strings.SplitN(item, ";", 2)[0], "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		k, err := url.QueryUnescape(strings.TrimSpace(kv[0]))
+		if err != nil {
+			continue
+		}
+
+		v, err := url.QueryUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			continue
+		}
+
+		b[k] = v
+	}
+
+	return b
+}
@@ -0,0 +1,207 @@
+package otel
+
+import (
+	"net/http"
+	"testing"
+
+	ot "github.com/opentracing/opentracing-go"
+)
+
+func testTracer(t *testing.T, propagation ...string) *Tracer {
+	tr, err := New(Options{SampleRatio: 1, Propagation: propagation, Endpoint: "http://127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tr
+}
+
+func TestExtract(t *testing.T) {
+	for _, ti := range []struct {
+		msg         string
+		header      http.Header
+		propagation []string
+		traceID     string
+		spanID      string
+		sampled     bool
+		traceState  string
+		err         error
+	}{{
+		msg:    "no context",
+		header: http.Header{},
+		err:    ot.ErrSpanContextNotFound,
+	}, {
+		msg: "w3c",
+		header: http.Header{
+			"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			"Tracestate":  []string{"congo=t61rcWkgMzE"},
+		},
+		traceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		spanID:     "00f067aa0ba902b7",
+		sampled:    true,
+		traceState: "congo=t61rcWkgMzE",
+	}, {
+		msg:     "w3c, not sampled",
+		header:  http.Header{"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"}},
+		traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		spanID:  "00f067aa0ba902b7",
+	}, {
+		msg:     "w3c, future version",
+		header:  http.Header{"Traceparent": []string{"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-will-be-like"}},
+		traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		spanID:  "00f067aa0ba902b7",
+		sampled: true,
+	}, {
+		msg:    "w3c, invalid trace ID",
+		header: http.Header{"Traceparent": []string{"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}},
+		err:    ot.ErrSpanContextCorrupted,
+	}, {
+		msg:    "w3c, invalid version",
+		header: http.Header{"Traceparent": []string{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+		err:    ot.ErrSpanContextCorrupted,
+	}, {
+		msg: "b3 multi",
+		header: http.Header{
+			"X-B3-Traceid": []string{"80f198ee56343ba864fe8b2a57d3eff7"},
+			"X-B3-Spanid":  []string{"e457b5a2e4d86bd1"},
+			"X-B3-Sampled": []string{"1"},
+		},
+		traceID: "80f198ee56343ba864fe8b2a57d3eff7",
+		spanID:  "e457b5a2e4d86bd1",
+		sampled: true,
+	}, {
+		msg: "b3 multi, 64 bit trace ID",
+		header: http.Header{
+			"X-B3-Traceid": []string{"64fe8b2a57d3eff7"},
+			"X-B3-Spanid":  []string{"e457b5a2e4d86bd1"},
+		},
+		traceID: "000000000000000064fe8b2a57d3eff7",
+		spanID:  "e457b5a2e4d86bd1",
+	}, {
+		msg:     "b3 single",
+		header:  http.Header{"B3": []string{"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90"}},
+		traceID: "80f198ee56343ba864fe8b2a57d3eff7",
+		spanID:  "e457b5a2e4d86bd1",
+		sampled: true,
+	}, {
+		msg:    "b3 single, invalid",
+		header: http.Header{"B3": []string{"foo-bar"}},
+		err:    ot.ErrSpanContextCorrupted,
+	}, {
+		msg: "w3c preferred",
+		header: http.Header{
+			"Traceparent":  []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			"X-B3-Traceid": []string{"80f198ee56343ba864fe8b2a57d3eff7"},
+			"X-B3-Spanid":  []string{"e457b5a2e4d86bd1"},
+		},
+		traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		spanID:  "00f067aa0ba902b7",
+		sampled: true,
+	}, {
+		msg: "b3 only",
+		header: http.Header{
+			"Traceparent":  []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			"X-B3-Traceid": []string{"80f198ee56343ba864fe8b2a57d3eff7"},
+			"X-B3-Spanid":  []string{"e457b5a2e4d86bd1"},
+		},
+		propagation: []string{PropagationB3},
+		traceID:     "80f198ee56343ba864fe8b2a57d3eff7",
+		spanID:      "e457b5a2e4d86bd1",
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			tr := testTracer(t, ti.propagation...)
+			defer tr.Close()
+
+			sc, err := tr.Extract(ot.HTTPHeaders, ot.HTTPHeadersCarrier(ti.header))
+			if err != ti.err {
+				t.Fatalf("unexpected error, expected: %v, got: %v", ti.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			c := sc.(SpanContext)
+			if c.TraceIDHex() != ti.traceID || c.SpanIDHex() != ti.spanID {
+				t.Errorf(
+					"invalid IDs, expected: %s/%s, got: %s/%s",
+					ti.traceID, ti.spanID, c.TraceIDHex(), c.SpanIDHex(),
+				)
+			}
+
+			if c.Sampled() != ti.sampled {
+				t.Errorf("invalid sampled flag, expected: %v, got: %v", ti.sampled, c.Sampled())
+			}
+
+			if c.TraceState != ti.traceState {
+				t.Errorf("invalid trace state, expected: %s, got: %s", ti.traceState, c.TraceState)
+			}
+		})
+	}
+}
+
+func TestInjectContinuesTrace(t *testing.T) {
+	tr := testTracer(t)
+	defer tr.Close()
+
+	in := http.Header{
+		"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"Tracestate":  []string{"congo=t61rcWkgMzE"},
+		"Baggage":     []string{"user=foo%20bar,team=baz;prop=1"},
+	}
+
+	sc, err := tr.Extract(ot.HTTPHeaders, ot.HTTPHeadersCarrier(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := tr.StartSpan("test", ot.ChildOf(sc))
+	if s.BaggageItem("user") != "foo bar" || s.BaggageItem("team") != "baz" {
+		t.Error("failed to extract baggage")
+	}
+
+	out := make(http.Header)
+	if err := tr.Inject(s.Context(), ot.HTTPHeaders, ot.HTTPHeadersCarrier(out)); err != nil {
+		t.Fatal(err)
+	}
+
+	spanID := s.Context().(SpanContext).SpanIDHex()
+	if out.Get("Traceparent") != "00-4bf92f3577b34da6a3ce929d0e0e4736-"+spanID+"-01" {
+		t.Errorf("invalid traceparent: %s", out.Get("Traceparent"))
+	}
+
+	if out.Get("Tracestate") != "congo=t61rcWkgMzE" {
+		t.Errorf("invalid tracestate: %s", out.Get("Tracestate"))
+	}
+
+	if out.Get("X-B3-Traceid") != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		out.Get("X-B3-Spanid") != spanID ||
+		out.Get("X-B3-Sampled") != "1" {
+		t.Errorf("invalid b3 headers: %v", out)
+	}
+
+	back, err := tr.Extract(ot.HTTPHeaders, ot.HTTPHeadersCarrier(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var baggage int
+	back.ForeachBaggageItem(func(string, string) bool { baggage++; return true })
+	if baggage != 2 {
+		t.Errorf("failed to roundtrip baggage, got %d items", baggage)
+	}
+}
+
+func TestInjectUnsupported(t *testing.T) {
+	tr := testTracer(t)
+	defer tr.Close()
+
+	s := tr.StartSpan("test")
+	if err := tr.Inject(s.Context(), ot.Binary, nil); err != ot.ErrUnsupportedFormat {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := tr.Extract(ot.HTTPHeaders, "foo"); err != ot.ErrInvalidCarrier {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
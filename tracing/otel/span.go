@@ -0,0 +1,234 @@
+package otel
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// the standard opentracing tag, see ext.SamplingPriority
+const samplingPriorityTag = "sampling.priority"
+
+// SpanContext holds the identifiers of a span, and the state
+// propagated together with them.
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceState string
+
+	sampled bool
+	baggage map[string]string
+	trace   *localTrace
+}
+
+// the spans of the same trace started in the current process share the
+// sampling decision, and the finished spans are held back until the
+// local root is finished, so that the decision can change while the
+// request is being processed.
+type localTrace struct {
+	mx           sync.Mutex
+	sampled      bool
+	rootFinished bool
+	finished     []*span
+}
+
+type logRecord struct {
+	time   time.Time
+	fields []log.Field
+}
+
+type span struct {
+	tracer    *Tracer
+	localRoot bool
+	parentID  [8]byte
+
+	mx        sync.Mutex
+	operation string
+	context   SpanContext
+	start     time.Time
+	end       time.Time
+	tags      map[string]interface{}
+	logs      []logRecord
+}
+
+func newLocalTrace(sampled bool) *localTrace {
+	return &localTrace{sampled: sampled}
+}
+
+func (t *localTrace) setSampled(priority interface{}) {
+	var sampled bool
+	switch p := priority.(type) {
+	case uint16:
+		sampled = p > 0
+	case int:
+		sampled = p > 0
+	case bool:
+		sampled = p
+	default:
+		return
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.sampled = sampled
+}
+
+func (t *localTrace) isSampled() bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.sampled
+}
+
+// returns the spans that need to be exported
+func (t *localTrace) finish(s *span) []*span {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.rootFinished {
+		if t.sampled {
+			return []*span{s}
+		}
+
+		return nil
+	}
+
+	t.finished = append(t.finished, s)
+	if !s.localRoot {
+		return nil
+	}
+
+	t.rootFinished = true
+	f := t.finished
+	t.finished = nil
+	if !t.sampled {
+		return nil
+	}
+
+	return f
+}
+
+// TraceIDHex returns the hex representation of the trace ID.
+func (c SpanContext) TraceIDHex() string { return hex.EncodeToString(c.TraceID[:]) }
+
+// SpanIDHex returns the hex representation of the span ID.
+func (c SpanContext) SpanIDHex() string { return hex.EncodeToString(c.SpanID[:]) }
+
+// Sampled tells whether the trace is sampled.
+func (c SpanContext) Sampled() bool {
+	if c.trace != nil {
+		return c.trace.isSampled()
+	}
+
+	return c.sampled
+}
+
+// ForeachBaggageItem implements the opentracing.SpanContext interface.
+func (c SpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for k, v := range c.baggage {
+		if !handler(k, v) {
+			return
+		}
+	}
+}
+
+func (s *span) Finish() { s.FinishWithOptions(ot.FinishOptions{}) }
+
+func (s *span) FinishWithOptions(opts ot.FinishOptions) {
+	s.mx.Lock()
+	s.end = opts.FinishTime
+	if s.end.IsZero() {
+		s.end = time.Now()
+	}
+
+	for _, lr := range opts.LogRecords {
+		s.logs = append(s.logs, logRecord{time: lr.Timestamp, fields: lr.Fields})
+	}
+
+	for _, ld := range opts.BulkLogData {
+		lr := ld.ToLogRecord()
+		s.logs = append(s.logs, logRecord{time: lr.Timestamp, fields: lr.Fields})
+	}
+
+	s.mx.Unlock()
+
+	for _, fs := range s.context.trace.finish(s) {
+		s.tracer.exporter.add(fs)
+	}
+}
+
+func (s *span) Context() ot.SpanContext {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.context
+}
+
+func (s *span) SetOperationName(operationName string) ot.Span {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.operation = operationName
+	return s
+}
+
+func (s *span) SetTag(key string, value interface{}) ot.Span {
+	if key == samplingPriorityTag {
+		s.context.trace.setSampled(value)
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.tags[key] = value
+	return s
+}
+
+func (s *span) LogFields(fields ...log.Field) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.logs = append(s.logs, logRecord{time: time.Now(), fields: fields})
+}
+
+func (s *span) LogKV(alternatingKeyValues ...interface{}) {
+	fields, err := log.InterleavedKVToFields(alternatingKeyValues...)
+	if err != nil {
+		fields = []log.Field{log.Error(err)}
+	}
+
+	s.LogFields(fields...)
+}
+
+func (s *span) SetBaggageItem(restrictedKey, value string) ot.Span {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	b := make(map[string]string, len(s.context.baggage)+1)
+	for k, v := range s.context.baggage {
+		b[k] = v
+	}
+
+	b[restrictedKey] = value
+	s.context.baggage = b
+	return s
+}
+
+func (s *span) BaggageItem(restrictedKey string) string {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.context.baggage[restrictedKey]
+}
+
+func (s *span) Tracer() ot.Tracer { return s.tracer }
+
+func (s *span) LogEvent(event string) { s.LogFields(log.String("event", event)) }
+
+func (s *span) LogEventWithPayload(event string, payload interface{}) {
+	s.LogFields(log.String("event", event), log.Object("payload", payload))
+}
+
+func (s *span) Log(data ot.LogData) {
+	lr := data.ToLogRecord()
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.logs = append(s.logs, logRecord{time: lr.Timestamp, fields: lr.Fields})
+}
@@ -0,0 +1,350 @@
+/*
+Package otel implements a built-in opentracing tracer, compatible with
+OpenTelemetry collectors.
+
+The tracer propagates the trace context with the W3C Trace Context
+headers (traceparent and tracestate), and with the B3 headers used by
+Zipkin compatible systems, and it exports the recorded spans in
+batches, over OTLP/HTTP, using the JSON encoding.
+
+Sampling is head-based: the decision is taken when the first span of
+a trace is started in skipper, and when the incoming request carries a
+trace context, the sampled flag of the caller is respected. The
+decision can be overridden, as long as the local root span of the
+trace is not finished, by setting the standard opentracing
+sampling.priority tag on any of the spans of the trace. This is what
+the tracingSampling filter does, to configure the sampling ratio per
+route.
+
+The tracer doesn't need to be built as a plugin. It can be enabled with
+skipper's -opentracing parameter, e.g:
+
+	-opentracing "otel endpoint=http://localhost:4318 service-name=skipper sample-ratio=0.1"
+
+The supported arguments are:
+
+	endpoint: the base URL of the OTLP/HTTP collector, default: http://localhost:4318
+	service-name: the service.name resource attribute, default: skipper
+	sample-ratio: the ratio of sampled root traces, between 0 and 1, default: 1
+	propagation: comma separated list of the propagation formats, w3c and/or b3, default: w3c,b3
+	batch-size: the maximum number of spans exported in a single request, default: 512
+	flush-interval: the maximum time that finished spans are buffered for, default: 5s
+	queue-size: the maximum number of spans waiting to be exported, default: 2048
+*/
+package otel
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ot "github.com/opentracing/opentracing-go"
+)
+
+const (
+	// Name of the tracer implementation, as used with the -opentracing
+	// parameter.
+	Name = "otel"
+
+	DefaultEndpoint      = "http://localhost:4318"
+	DefaultServiceName   = "skipper"
+	DefaultBatchSize     = 512
+	DefaultFlushInterval = 5 * time.Second
+	DefaultQueueSize     = 2048
+
+	// PropagationW3C selects the W3C Trace Context headers.
+	PropagationW3C = "w3c"
+
+	// PropagationB3 selects the B3 headers.
+	PropagationB3 = "b3"
+)
+
+// Options configure the tracer.
+type Options struct {
+
+	// Endpoint is the base URL of the OTLP/HTTP collector. The spans
+	// are posted to its /v1/traces path. Defaults to DefaultEndpoint.
+	Endpoint string
+
+	// ServiceName is reported as the service.name resource
+	// attribute. Defaults to DefaultServiceName.
+	ServiceName string
+
+	// SampleRatio is the ratio of the sampled traces started by
+	// skipper, between 0 and 1.
+	SampleRatio float64
+
+	// Propagation lists the formats used to inject the trace context.
+	// When extracting, all the listed formats are tried in order.
+	// Defaults to W3C and B3.
+	Propagation []string
+
+	// BatchSize is the maximum number of spans in a single export
+	// request. Defaults to DefaultBatchSize.
+	BatchSize int
+
+	// FlushInterval is the maximum time that the finished spans are
+	// buffered before exporting them. Defaults to
+	// DefaultFlushInterval.
+	FlushInterval time.Duration
+
+	// QueueSize is the maximum number of the finished spans waiting
+	// to be exported. When the queue is full, the spans are dropped.
+	// Defaults to DefaultQueueSize.
+	QueueSize int
+
+	// Client is used to send the export requests. Defaults to a
+	// client with a 10 seconds timeout.
+	Client *http.Client
+}
+
+// Tracer implements the opentracing.Tracer interface.
+type Tracer struct {
+	sampleRatio float64
+	propagation []string
+	exporter    *exporter
+
+	mx   sync.Mutex
+	rand *rand.Rand
+}
+
+// New creates a tracer and starts exporting the finished spans in the
+// background.
+func New(o Options) (*Tracer, error) {
+	if o.SampleRatio < 0 || o.SampleRatio > 1 {
+		return nil, fmt.Errorf("otel: invalid sample ratio: %v", o.SampleRatio)
+	}
+
+	if len(o.Propagation) == 0 {
+		o.Propagation = []string{PropagationW3C, PropagationB3}
+	}
+
+	for _, p := range o.Propagation {
+		if p != PropagationW3C && p != PropagationB3 {
+			return nil, fmt.Errorf("otel: invalid propagation format: %s", p)
+		}
+	}
+
+	return &Tracer{
+		sampleRatio: o.SampleRatio,
+		propagation: o.Propagation,
+		exporter:    newExporter(o),
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// InitTracer creates a tracer from the arguments of skipper's
+// -opentracing parameter. It has the same signature as the function
+// required from the tracer plugins.
+func InitTracer(opts []string) (ot.Tracer, error) {
+	o := Options{SampleRatio: 1}
+	for _, opt := range opts {
+		if opt == "" {
+			continue
+		}
+
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("otel: invalid argument: %s", opt)
+		}
+
+		var err error
+		switch kv[0] {
+		case "endpoint":
+			o.Endpoint = kv[1]
+		case "service-name":
+			o.ServiceName = kv[1]
+		case "sample-ratio":
+			o.SampleRatio, err = strconv.ParseFloat(kv[1], 64)
+		case "propagation":
+			o.Propagation = strings.Split(kv[1], ",")
+		case "batch-size":
+			o.BatchSize, err = strconv.Atoi(kv[1])
+		case "flush-interval":
+			o.FlushInterval, err = time.ParseDuration(kv[1])
+		case "queue-size":
+			o.QueueSize, err = strconv.Atoi(kv[1])
+		default:
+			return nil, fmt.Errorf("otel: unknown argument: %s", kv[0])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("otel: invalid value of %s: %v", kv[0], err)
+		}
+	}
+
+	return New(o)
+}
+
+func (t *Tracer) newID() (traceID [16]byte, spanID [8]byte) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.rand.Read(traceID[:])
+	t.rand.Read(spanID[:])
+	return
+}
+
+func (t *Tracer) newSpanID() [8]byte {
+	_, id := t.newID()
+	return id
+}
+
+func (t *Tracer) sample() bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.rand.Float64() < t.sampleRatio
+}
+
+// StartSpan starts a new span. Only the first ChildOf or FollowsFrom
+// reference is used as the parent.
+func (t *Tracer) StartSpan(operationName string, opts ...ot.StartSpanOption) ot.Span {
+	var so ot.StartSpanOptions
+	for _, o := range opts {
+		o.Apply(&so)
+	}
+
+	s := &span{
+		tracer:    t,
+		operation: operationName,
+		start:     so.StartTime,
+		tags:      make(map[string]interface{}),
+	}
+
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+
+	for k, v := range so.Tags {
+		s.tags[k] = v
+	}
+
+	var parent *SpanContext
+	for _, r := range so.References {
+		if c, ok := r.ReferencedContext.(SpanContext); ok {
+			parent = &c
+			break
+		}
+	}
+
+	if parent == nil {
+		traceID, spanID := t.newID()
+		s.context = SpanContext{
+			TraceID: traceID,
+			SpanID:  spanID,
+			trace:   newLocalTrace(t.sample()),
+		}
+
+		s.localRoot = true
+	} else {
+		s.parentID = parent.SpanID
+		s.context = SpanContext{
+			TraceID:    parent.TraceID,
+			SpanID:     t.newSpanID(),
+			TraceState: parent.TraceState,
+			baggage:    parent.baggage,
+			trace:      parent.trace,
+		}
+
+		if s.context.trace == nil {
+			s.context.trace = newLocalTrace(parent.sampled)
+			s.localRoot = true
+		}
+	}
+
+	if p, ok := s.tags[samplingPriorityTag]; ok {
+		s.context.trace.setSampled(p)
+	}
+
+	return s
+}
+
+// Inject writes the span context into the carrier. It supports the
+// opentracing.HTTPHeaders and the opentracing.TextMap formats.
+func (t *Tracer) Inject(sc ot.SpanContext, format interface{}, carrier interface{}) error {
+	c, ok := sc.(SpanContext)
+	if !ok {
+		return ot.ErrInvalidSpanContext
+	}
+
+	if format != ot.HTTPHeaders && format != ot.TextMap {
+		return ot.ErrUnsupportedFormat
+	}
+
+	w, ok := carrier.(ot.TextMapWriter)
+	if !ok {
+		return ot.ErrInvalidCarrier
+	}
+
+	for _, p := range t.propagation {
+		switch p {
+		case PropagationW3C:
+			injectW3C(c, w)
+		case PropagationB3:
+			injectB3(c, w)
+		}
+	}
+
+	injectBaggage(c, w)
+	return nil
+}
+
+// Extract reads the span context from the carrier. It supports the
+// opentracing.HTTPHeaders and the opentracing.TextMap formats.
+func (t *Tracer) Extract(format interface{}, carrier interface{}) (ot.SpanContext, error) {
+	if format != ot.HTTPHeaders && format != ot.TextMap {
+		return nil, ot.ErrUnsupportedFormat
+	}
+
+	r, ok := carrier.(ot.TextMapReader)
+	if !ok {
+		return nil, ot.ErrInvalidCarrier
+	}
+
+	h := make(map[string]string)
+	if err := r.ForeachKey(func(k, v string) error {
+		h[strings.ToLower(k)] = v
+		return nil
+	}); err != nil {
+		return nil, ot.ErrSpanContextCorrupted
+	}
+
+	for _, p := range t.propagation {
+		var (
+			c   SpanContext
+			err error
+		)
+
+		switch p {
+		case PropagationW3C:
+			c, err = extractW3C(h)
+		case PropagationB3:
+			c, err = extractB3(h)
+		}
+
+		if err == ot.ErrSpanContextNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.baggage = extractBaggage(h)
+		return c, nil
+	}
+
+	return nil, ot.ErrSpanContextNotFound
+}
+
+// Close exports the buffered spans and stops the background export.
+func (t *Tracer) Close() {
+	t.exporter.close()
+}
@@ -0,0 +1,230 @@
+package otel
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+type collector struct {
+	mx    sync.Mutex
+	spans []otlpSpan
+	reqs  []exportRequest
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != tracesPath || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.reqs = append(c.reqs, req)
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+func (c *collector) exported() []otlpSpan {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.spans
+}
+
+func startCollector(t *testing.T, o Options) (*collector, *httptest.Server, *Tracer) {
+	c := &collector{}
+	s := httptest.NewServer(c)
+	o.Endpoint = s.URL
+	tr, err := New(o)
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+
+	return c, s, tr
+}
+
+func TestExportSpans(t *testing.T) {
+	c, s, tr := startCollector(t, Options{SampleRatio: 1, ServiceName: "test-service"})
+	defer s.Close()
+
+	root := tr.StartSpan("ingress")
+	ext.SpanKindRPCServer.Set(root)
+	ext.HTTPMethod.Set(root, "GET")
+	child := tr.StartSpan("proxy", ot.ChildOf(root.Context()))
+	ext.SpanKindRPCClient.Set(child)
+	ext.Error.Set(child, true)
+	child.LogKV("event", "dial_context", "attempt", 2)
+	child.Finish()
+	root.Finish()
+	tr.Close()
+
+	spans := c.exported()
+	if len(spans) != 2 {
+		t.Fatalf("failed to export spans, got: %d", len(spans))
+	}
+
+	if c.reqs[0].ResourceSpans[0].Resource.Attributes[0].Key != "service.name" ||
+		*c.reqs[0].ResourceSpans[0].Resource.Attributes[0].Value.StringValue != "test-service" {
+		t.Error("failed to set the service name")
+	}
+
+	p, ch := spans[1], spans[0]
+	if p.Name != "ingress" || ch.Name != "proxy" {
+		t.Fatalf("unexpected spans: %s, %s", p.Name, ch.Name)
+	}
+
+	if p.TraceID != ch.TraceID || ch.ParentSpanID != p.SpanID || p.ParentSpanID != "" {
+		t.Error("invalid span relations")
+	}
+
+	if p.Kind != spanKindServer || ch.Kind != spanKindClient {
+		t.Errorf("invalid span kinds: %d, %d", p.Kind, ch.Kind)
+	}
+
+	if ch.Status.Code != statusCodeError {
+		t.Error("failed to set the error status")
+	}
+
+	if len(ch.Events) != 1 || ch.Events[0].Name != "dial_context" || *ch.Events[0].Attributes[0].Value.IntValue != "2" {
+		t.Errorf("invalid events: %v", ch.Events)
+	}
+}
+
+func TestExportBatches(t *testing.T) {
+	c, s, tr := startCollector(t, Options{SampleRatio: 1, BatchSize: 2, FlushInterval: time.Hour})
+	defer s.Close()
+	defer tr.Close()
+
+	for i := 0; i < 4; i++ {
+		tr.StartSpan("test").Finish()
+	}
+
+	timeout := time.After(3 * time.Second)
+	for len(c.exported()) != 4 {
+		select {
+		case <-timeout:
+			t.Fatal("failed to export full batches")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	for _, ti := range []struct {
+		msg      string
+		ratio    float64
+		parent   string
+		priority *uint16
+		expected int
+	}{{
+		msg:      "sampled root",
+		ratio:    1,
+		expected: 2,
+	}, {
+		msg:   "dropped root",
+		ratio: 0,
+	}, {
+		msg:      "sampled by remote parent",
+		parent:   "01",
+		expected: 2,
+	}, {
+		msg:    "dropped by remote parent",
+		ratio:  1,
+		parent: "00",
+	}, {
+		msg:      "dropped by priority",
+		ratio:    1,
+		priority: new(uint16),
+	}, {
+		msg:      "forced by priority",
+		ratio:    0,
+		parent:   "00",
+		priority: func() *uint16 { p := uint16(1); return &p }(),
+		expected: 2,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			c, s, tr := startCollector(t, Options{SampleRatio: ti.ratio})
+			defer s.Close()
+
+			var opts []ot.StartSpanOption
+			if ti.parent != "" {
+				sc, err := tr.Extract(ot.HTTPHeaders, ot.HTTPHeadersCarrier(http.Header{
+					"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-" + ti.parent},
+				}))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				opts = append(opts, ot.ChildOf(sc))
+			}
+
+			root := tr.StartSpan("ingress", opts...)
+
+			// the child is finished before the sampling decision changes
+			tr.StartSpan("filter", ot.ChildOf(root.Context())).Finish()
+
+			if ti.priority != nil {
+				ext.SamplingPriority.Set(root, *ti.priority)
+			}
+
+			root.Finish()
+			tr.Close()
+
+			if n := len(c.exported()); n != ti.expected {
+				t.Errorf("unexpected number of exported spans, expected: %d, got: %d", ti.expected, n)
+			}
+		})
+	}
+}
+
+func TestInitTracer(t *testing.T) {
+	tr, err := InitTracer([]string{
+		"endpoint=http://collector:4318",
+		"service-name=foo",
+		"sample-ratio=0.25",
+		"propagation=b3",
+		"flush-interval=1s",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otr := tr.(*Tracer)
+	defer otr.Close()
+	if otr.sampleRatio != 0.25 ||
+		len(otr.propagation) != 1 || otr.propagation[0] != PropagationB3 ||
+		otr.exporter.url != "http://collector:4318/v1/traces" ||
+		otr.exporter.serviceName != "foo" ||
+		otr.exporter.flushInterval != time.Second {
+		t.Error("failed to apply the arguments")
+	}
+
+	for _, args := range [][]string{
+		{"foo=bar"},
+		{"sample-ratio"},
+		{"sample-ratio=2"},
+		{"propagation=jaeger"},
+		{"batch-size=many"},
+	} {
+		if _, err := InitTracer(args); err == nil {
+			t.Errorf("failed to fail: %v", args)
+		}
+	}
+}
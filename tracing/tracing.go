@@ -3,7 +3,11 @@
 // Implementations of Opentracing API can be found in the https://github.com/skipper-plugins.
 // It follows how to implement a new tracer plugin for this interface.
 //
-// The tracers, except for "noop", are built as Go Plugins. Note the warning from Go's
+// The tracers, except for "noop" and the built-in "otel", are built as Go Plugins. The
+// "otel" tracer propagates the W3C Trace Context and the B3 headers, and exports the
+// spans over OTLP/HTTP, see the documentation of the tracing/otel package.
+//
+// Note the warning from Go's
 // plugin.go:
 //
 //    // The plugin support is currently incomplete, only supports Linux,
@@ -56,6 +60,7 @@ import (
 	"plugin"
 
 	ot "github.com/opentracing/opentracing-go"
+	"github.com/zalando/skipper/tracing/otel"
 )
 
 func LoadTracingPlugin(pluginDirs []string, opts []string) (tracer ot.Tracer, err error) {
@@ -77,8 +82,11 @@ func LoadPlugin(pluginDir string, opts []string) (ot.Tracer, error) {
 	var impl string
 	impl, opts = opts[0], opts[1:]
 
-	if impl == "noop" {
+	switch impl {
+	case "noop":
 		return &ot.NoopTracer{}, nil
+	case otel.Name:
+		return otel.InitTracer(opts)
 	}
 
 	pluginFile := filepath.Join(pluginDir, impl+".so") // FIXME this is Linux and other ELF...